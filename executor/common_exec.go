@@ -20,8 +20,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
-	"runtime"
+	"runtime/debug"
 	"sync"
 	"time"
 
@@ -34,6 +35,11 @@ const (
 
 var (
 	noDeadline = time.Time{}
+
+	// ErrQueueFull is returned to the callback of a task that is
+	// rejected because the Executor's maximum queue depth has
+	// been reached. See WithMaxQueueDepth.
+	ErrQueueFull = errors.New("executor queue full")
 )
 
 type contextErrorType int
@@ -46,26 +52,39 @@ const (
 )
 
 const (
-	defaultMaxAttempts   = 1
-	defaultMaxQueueDepth = 10
-	defaultParallelism   = 1
+	defaultMaxAttempts = 1
+	defaultParallelism = 1
 )
 
 var (
 	defaultDelayFunc = NewConstantDelayFunc(1 * time.Second)
 )
 
+// retryState describes what a retry is currently doing, for
+// introspection.
+type retryState int
+
+const (
+	retryQueued retryState = iota
+	retryRunning
+	retryWaiting
+)
+
 // retry encapsulates a nextAttempt (when to retry the action), the
 // data necessary to perform the retry, and how many attempts have
-// already been made.
+// already been made. The id, state, attemptStart, and attempts fields
+// are guarded by commonExec's trackMutex.
 type retry struct {
-	f           Func
-	cb          CallbackFunc
-	start       time.Time
-	nextAttempt time.Time
-	ctxt        context.Context
-	ctxtCancel  context.CancelFunc
-	attempts    int
+	f            Func
+	cb           CallbackFunc
+	start        time.Time
+	nextAttempt  time.Time
+	ctxt         context.Context
+	ctxtCancel   context.CancelFunc
+	attempts     int
+	id           uint64
+	state        retryState
+	attemptStart time.Time
 }
 
 // execImpl defines the underlying low-level interface for an Executor. commonExec
@@ -85,10 +104,26 @@ type commonExec struct {
 	delay          DelayFunc
 	timeout        time.Duration
 	attemptTimeout time.Duration
+	maxQueueDepth  int
+	retryBudget    int
+
+	panicPolicy  PanicPolicy
+	panicHandler PanicHandler
+	retryPanics  bool
 
 	time tbntime.Source
 	log  *log.Logger
 	diag DiagnosticsCallback
+
+	// ownedDiag is closed by Stop. It is set when the Executor
+	// created its own DiagnosticsCallback.
+	ownedDiag io.Closer
+
+	trackMutex sync.Mutex
+	nextID     uint64
+	live       map[*retry]struct{}
+	queued     int
+	waiting    int
 }
 
 type pair struct {
@@ -96,6 +131,30 @@ type pair struct {
 	try Try
 }
 
+// newCommonExec constructs a commonExec with default values, applies
+// the given Options, and delegates to the given execImpl.
+func newCommonExec(impl execImpl, options []Option) *commonExec {
+	c := &commonExec{
+		time:           tbntime.NewSource(),
+		parallelism:    defaultParallelism,
+		maxAttempts:    defaultMaxAttempts,
+		delay:          defaultDelayFunc,
+		timeout:        noTimeout,
+		attemptTimeout: noTimeout,
+		panicPolicy:    RescuePanics,
+		retryPanics:    true,
+		diag:           NewNoopDiagnosticsCallback(),
+		impl:           impl,
+		live:           map[*retry]struct{}{},
+	}
+
+	for _, apply := range options {
+		apply(c)
+	}
+
+	return c
+}
+
 func (c *commonExec) ExecAndForget(f Func) {
 	c.Exec(f, nil)
 }
@@ -117,6 +176,11 @@ func (c *commonExec) Exec(f Func, cb CallbackFunc) {
 		attempts:    0,
 	}
 
+	if !c.track(r) {
+		c.reject(r)
+		return
+	}
+
 	c.impl.add(c, r)
 }
 
@@ -132,8 +196,29 @@ func (c *commonExec) ExecGathered(fs []Func, cb CallbackFunc) {
 	c.execGathered(fs, cb)
 }
 
+func (c *commonExec) ExecGatheredWithPolicy(
+	fs []Func,
+	policy GatherPolicy,
+	cb GatheredCallbackFunc,
+) {
+	defer c.diag.TaskStarted(len(fs))
+
+	c.execGatheredWithPolicy(fs, policy, cb)
+}
+
 func (c *commonExec) Stop() {
 	c.impl.stop(c)
+
+	c.trackMutex.Lock()
+	defer c.trackMutex.Unlock()
+	c.live = map[*retry]struct{}{}
+	c.queued = 0
+	c.waiting = 0
+
+	if c.ownedDiag != nil {
+		c.ownedDiag.Close()
+		c.ownedDiag = nil
+	}
 }
 
 func (c *commonExec) SetDiagnosticsCallback(diag DiagnosticsCallback) {
@@ -224,6 +309,70 @@ func (c *commonExec) execGathered(
 
 }
 
+func (c *commonExec) execGatheredWithPolicy(
+	fs []Func,
+	policy GatherPolicy,
+	cb GatheredCallbackFunc,
+) {
+	if policy == nil {
+		policy = NewFailFastGatherPolicy()
+	}
+
+	if cb == nil {
+		cb = func(_ *GatheredResults) {}
+	}
+
+	n := len(fs)
+	if n == 0 {
+		cb(&GatheredResults{Successes: map[int]interface{}{}, Satisfied: true})
+		return
+	}
+
+	completed := make(chan pair, n)
+	start := c.time.Now()
+	globalDeadline := mkDeadline(start, c.timeout)
+	ctxt, ctxtCancel := c.mkContext(globalDeadline, true)
+
+	go func() {
+		defer ctxtCancel()
+
+		results := &GatheredResults{Successes: make(map[int]interface{}, n)}
+		decided := false
+
+		for remaining := n; remaining > 0; remaining-- {
+			p := <-completed
+			if p.try.IsError() {
+				results.Failures = append(
+					results.Failures,
+					IndexedError{Index: p.idx, Err: p.try.Error()},
+				)
+			} else {
+				results.Successes[p.idx] = p.try.Get()
+			}
+
+			if !decided {
+				decided, results.Satisfied = policy(
+					n,
+					len(results.Successes),
+					len(results.Failures),
+				)
+				if decided {
+					ctxtCancel()
+				}
+			}
+		}
+
+		results.Failures.sort()
+		cb(results)
+	}()
+
+	mcb := func(i int, t Try) {
+		completed <- pair{i, t}
+	}
+
+	c.execChildren(ctxt, ctxtCancel, start, fs, mcb)
+}
+
 func (c *commonExec) execChildren(
 	ctxt context.Context,
 	ctxtCancel context.CancelFunc,
@@ -249,6 +398,11 @@ func (c *commonExec) execChildren(
 			attempts:    0,
 		}
 
+		if !c.track(r) {
+			c.reject(r)
+			continue
+		}
+
 		c.impl.add(c, r)
 	}
 }
@@ -315,19 +469,26 @@ func mkDeadline(start time.Time, d time.Duration) time.Time {
 	return noDeadline
 }
 
-func (c *commonExec) attempt(r *retry) {
+// attempt makes a single attempt to execute the retry's Func. If the
+// attempt fails, it is either handed back to the execImpl for retry
+// or the callback is invoked. It returns the AttemptResult and Try of
+// the attempt, before any adjustment made for a failed retry.
+func (c *commonExec) attempt(r *retry) (AttemptResult, Try) {
 	attemptStart := c.time.Now()
 	c.diag.AttemptStarted(attemptStart.Sub(r.nextAttempt))
 
-	var t Try
+	var (
+		t        Try
+		panicked bool
+	)
 	ctxtErrType := checkCtxtError(r.ctxt, nil)
 	if ctxtErrType == noError {
-		r.attempts++
+		c.beginAttempt(r, attemptStart)
 
 		retryDeadline := mkDeadline(c.time.Now(), c.attemptTimeout)
 		ctxt, localCancel := c.mkChildContext(r.ctxt, retryDeadline)
 
-		t = rescuedCall(ctxt, r.f, c.log)
+		t, panicked = c.call(ctxt, r.f)
 		ctxtErrType = checkCtxtError(r.ctxt, ctxt)
 		localCancel()
 	} else {
@@ -339,7 +500,11 @@ func (c *commonExec) attempt(r *retry) {
 	retry := false
 
 	if t.IsError() {
-		if ctxtErrType == globalTimeoutError {
+		if panicked {
+			// rescued panic
+			attemptResult = AttemptPanic
+			retry = c.retryPanics
+		} else if ctxtErrType == globalTimeoutError {
 			// global timeout expired
 			attemptResult = AttemptGlobalTimeout
 
@@ -375,6 +540,7 @@ func (c *commonExec) attempt(r *retry) {
 	}
 
 	c.diag.AttemptCompleted(attemptResult, attemptDuration)
+	completedResult, completedTry := attemptResult, t
 
 	if retry {
 		delay := c.delay(r.attempts)
@@ -388,11 +554,21 @@ func (c *commonExec) attempt(r *retry) {
 				"failed action would timeout before next retry: %s",
 				t.Error().Error(),
 			))
-		} else if c.impl.retry(c, delay, r) {
-			return
+		} else if c.reserveRetry(r) {
+			if c.impl.retry(c, delay, r) {
+				return completedResult, completedTry
+			}
+		} else {
+			t = NewError(fmt.Errorf(
+				"failed action exceeded retry budget (%d): %s",
+				c.retryBudget,
+				t.Error().Error(),
+			))
 		}
 	}
 
+	c.untrack(r)
+
 	defer func() { c.diag.TaskCompleted(attemptResult, c.time.Now().Sub(r.start)) }()
 
 	if r.ctxtCancel != nil {
@@ -404,36 +580,133 @@ func (c *commonExec) attempt(r *retry) {
 		r.cb(t)
 		c.diag.CallbackDuration(c.time.Now().Sub(callbackStart))
 	}
+
+	return completedResult, completedTry
+}
+
+// track records a newly added retry for introspection. Returns
+// false, without tracking the retry, if the maximum queue depth has
+// been reached.
+func (c *commonExec) track(r *retry) bool {
+	c.trackMutex.Lock()
+	defer c.trackMutex.Unlock()
+
+	if c.maxQueueDepth > 0 && c.queued >= c.maxQueueDepth {
+		return false
+	}
+
+	c.nextID++
+	r.id = c.nextID
+	r.state = retryQueued
+	c.live[r] = struct{}{}
+	c.queued++
+	return true
+}
+
+// reject completes a retry that could not be queued, without making
+// any attempts.
+func (c *commonExec) reject(r *retry) {
+	defer c.diag.TaskCompleted(AttemptCancellation, 0)
+
+	if r.ctxtCancel != nil {
+		r.ctxtCancel()
+	}
+
+	if r.cb != nil {
+		r.cb(NewError(ErrQueueFull))
+	}
 }
 
-func rescuedCall(ctxt context.Context, f Func, log *log.Logger) (t Try) {
+// beginAttempt marks the retry as running and counts the attempt.
+func (c *commonExec) beginAttempt(r *retry, attemptStart time.Time) {
+	c.trackMutex.Lock()
+	defer c.trackMutex.Unlock()
+
+	c.release(r)
+	r.attempts++
+	r.state = retryRunning
+	r.attemptStart = attemptStart
+}
+
+// reserveRetry marks the retry as waiting for its next attempt.
+// Returns false if the retry budget is exhausted. The budget is not
+// consulted if the retry has no attempts remaining.
+func (c *commonExec) reserveRetry(r *retry) bool {
+	c.trackMutex.Lock()
+	defer c.trackMutex.Unlock()
+
+	if c.retryBudget > 0 &&
+		r.attempts < c.maxAttempts &&
+		c.waiting >= c.retryBudget {
+		return false
+	}
+
+	c.release(r)
+	r.state = retryWaiting
+	if _, ok := c.live[r]; ok {
+		c.waiting++
+	}
+	return true
+}
+
+// untrack removes a completed retry from introspection.
+func (c *commonExec) untrack(r *retry) {
+	c.trackMutex.Lock()
+	defer c.trackMutex.Unlock()
+
+	c.release(r)
+	delete(c.live, r)
+}
+
+// release removes the retry from the queued or waiting count
+// corresponding to its current state. Must be called with the
+// trackMutex held.
+func (c *commonExec) release(r *retry) {
+	if _, ok := c.live[r]; !ok {
+		return
+	}
+
+	switch r.state {
+	case retryQueued:
+		c.queued--
+	case retryWaiting:
+		c.waiting--
+	}
+}
+
+// call invokes f, handling any panic according to the PanicPolicy.
+// Returns true if a panic was rescued.
+func (c *commonExec) call(ctxt context.Context, f Func) (Try, bool) {
+	if c.panicPolicy == RepanicPanics {
+		return NewTry(f(ctxt)), false
+	}
+
+	t, pe := rescuedCall(ctxt, f, c.log)
+	if pe == nil {
+		return t, false
+	}
+
+	if c.panicPolicy == HandlePanics && c.panicHandler != nil {
+		c.panicHandler(pe)
+	}
+
+	return t, true
+}
+
+func rescuedCall(ctxt context.Context, f Func, log *log.Logger) (t Try, pe *PanicError) {
 	defer func() {
 		if p := recover(); p != nil {
-			stack := make([]byte, 2048)
-			runtime.Stack(stack, false)
+			pe = &PanicError{Value: p, Stack: debug.Stack()}
 
 			if log != nil {
 				log.Printf(
 					"rescued retry queue call\npanic: %v\n\n%s\n",
 					p,
-					string(stack),
+					string(pe.Stack),
 				)
 			}
 
-			switch s := p.(type) {
-			case string:
-				t = NewError(errors.New(s))
-
-			case error:
-				t = NewError(s)
-
-			case fmt.Stringer:
-				t = NewError(errors.New(s.String()))
-
-			default:
-				t = NewError(fmt.Errorf("%#v", p))
-			}
-
+			t = NewError(pe)
 		}
 	}()
 
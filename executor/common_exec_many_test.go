@@ -260,3 +260,136 @@ func testExecGatheredNoop(t *testing.T, mk mkExecutor) {
 	// doesn't crash:
 	e.ExecGathered([]Func{}, nil)
 }
+
+func testExecGatheredWithPolicyAll(t *testing.T, mk mkExecutor) {
+	e := mk(
+		WithMaxAttempts(1),
+		WithParallelism(1),
+	)
+	defer e.Stop()
+
+	f1 := func(_ context.Context) (interface{}, error) { return "p1", nil }
+	f2 := func(_ context.Context) (interface{}, error) { return nil, errors.New("p2") }
+	f3 := func(ctxt context.Context) (interface{}, error) {
+		if err := ctxt.Err(); err != nil {
+			assert.Failed(t, "unexpected context error in p3")
+		}
+
+		return nil, errors.New("p3")
+	}
+	f4 := func(_ context.Context) (interface{}, error) { return "p4", nil }
+
+	c := make(chan *GatheredResults, 10)
+	defer close(c)
+
+	e.ExecGatheredWithPolicy(
+		[]Func{f1, f2, f3, f4},
+		NewGatherAllPolicy(),
+		func(r *GatheredResults) { c <- r },
+	)
+
+	r := <-c
+	assert.False(t, r.Satisfied)
+	assert.DeepEqual(t, r.Successes, map[int]interface{}{0: "p1", 3: "p4"})
+	assert.Equal(t, len(r.Failures), 2)
+	assert.Equal(t, r.Failures[0].Index, 1)
+	assert.Equal(t, r.Failures[0].Err.Error(), "p2")
+	assert.Equal(t, r.Failures[1].Index, 2)
+	assert.Equal(t, r.Failures[1].Err.Error(), "p3")
+	assert.ErrorContains(t, r.Err(), "2 errors: 1: p2; 2: p3")
+}
+
+func testExecGatheredWithPolicyQuorum(t *testing.T, mk mkExecutor) {
+	e := mk(
+		WithMaxAttempts(1),
+		WithParallelism(3),
+	)
+	defer e.Stop()
+
+	f1 := func(_ context.Context) (interface{}, error) { return "p1", nil }
+	f2 := func(_ context.Context) (interface{}, error) { return nil, errors.New("p2") }
+	f3 := func(_ context.Context) (interface{}, error) { return "p3", nil }
+	f4 := func(ctxt context.Context) (interface{}, error) {
+		<-ctxt.Done()
+		return nil, ctxt.Err()
+	}
+
+	c := make(chan *GatheredResults, 10)
+	defer close(c)
+
+	e.ExecGatheredWithPolicy(
+		[]Func{f1, f2, f3, f4},
+		NewQuorumGatherPolicy(2),
+		func(r *GatheredResults) { c <- r },
+	)
+
+	r := <-c
+	assert.True(t, r.Satisfied)
+	assert.Nil(t, r.Err())
+	assert.DeepEqual(t, r.Successes, map[int]interface{}{0: "p1", 2: "p3"})
+	assert.Equal(t, len(r.Failures), 2)
+
+	// p2 may be canceled if the quorum is reached before it
+	// completes; p4 is always canceled.
+	assert.Equal(t, r.Failures[0].Index, 1)
+	msg := r.Failures[0].Err.Error()
+	assert.True(t, msg == "p2" || msg == "action canceled")
+	assert.Equal(t, r.Failures[1].Index, 3)
+	assert.Equal(t, r.Failures[1].Err.Error(), "action canceled")
+}
+
+func testExecGatheredWithPolicyFailFast(t *testing.T, mk mkExecutor) {
+	e := mk(
+		WithMaxAttempts(1),
+		WithParallelism(1),
+	)
+	defer e.Stop()
+
+	f1 := func(_ context.Context) (interface{}, error) { return nil, errors.New("p1") }
+	f2 := func(_ context.Context) (interface{}, error) { return "p2", nil }
+
+	c := make(chan *GatheredResults, 10)
+	defer close(c)
+
+	// Because parallelism == 1, either p1 fails and p2 is
+	// canceled, or p2 succeeds and p1 fails.
+	e.ExecGatheredWithPolicy(
+		[]Func{f1, f2},
+		NewFailFastGatherPolicy(),
+		func(r *GatheredResults) { c <- r },
+	)
+
+	r := <-c
+	assert.False(t, r.Satisfied)
+	assert.NonNil(t, r.Err())
+	assert.Equal(t, r.Failures[0].Index, 0)
+	assert.Equal(t, r.Failures[0].Err.Error(), "p1")
+	assert.Equal(t, len(r.Successes)+len(r.Failures), 2)
+}
+
+func testExecGatheredWithPolicyNoop(t *testing.T, mk mkExecutor) {
+	e := mk(
+		WithMaxAttempts(1),
+		WithParallelism(1),
+	)
+	defer e.Stop()
+
+	cbInvoked := false
+
+	e.ExecGatheredWithPolicy(
+		[]Func{},
+		NewGatherAllPolicy(),
+		func(r *GatheredResults) {
+			cbInvoked = true
+
+			assert.True(t, r.Satisfied)
+			assert.Equal(t, len(r.Successes), 0)
+			assert.Equal(t, len(r.Failures), 0)
+		},
+	)
+
+	assert.True(t, cbInvoked)
+
+	// doesn't crash:
+	e.ExecGatheredWithPolicy([]Func{}, nil, nil)
+}
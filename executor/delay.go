@@ -18,6 +18,7 @@ package executor
 
 import (
 	"math"
+	"math/rand"
 	"time"
 )
 
@@ -77,3 +78,28 @@ func NewConstantDelayFunc(delay time.Duration) DelayFunc {
 		return delay
 	}
 }
+
+// NewJitteredDelayFunc creates a DelayFunc that randomizes the delays
+// produced by the given DelayFunc. The jitter is the fraction of each
+// delay that is randomized. E.g., given a jitter of 0.2, a 1s delay
+// becomes a delay chosen uniformly from [800ms, 1.2s]. Jitter is
+// clamped to the range [0, 1]. A jitter of 0 returns the given
+// DelayFunc unchanged.
+func NewJitteredDelayFunc(delay DelayFunc, jitter float64) DelayFunc {
+	if jitter <= 0 {
+		return delay
+	} else if jitter > 1 {
+		jitter = 1
+	}
+
+	return func(attempt int) time.Duration {
+		d := delay(attempt)
+		if d <= 0 {
+			return d
+		}
+
+		// scale d by a factor in [1 - jitter, 1 + jitter]
+		factor := 1 + jitter*(2*rand.Float64()-1)
+		return time.Duration(float64(d) * factor)
+	}
+}
@@ -72,3 +72,28 @@ func TestNewConstantDelayFuncInvalidDelayIsZero(t *testing.T) {
 	delayFunc = NewConstantDelayFunc(0 * time.Second)
 	assert.Equal(t, delayFunc(0), time.Duration(0))
 }
+
+func TestNewJitteredDelayFunc(t *testing.T) {
+	delayFunc := NewJitteredDelayFunc(NewConstantDelayFunc(time.Second), 0.2)
+	for i := 0; i < 100; i++ {
+		d := delayFunc(i)
+		assert.True(t, d >= 800*time.Millisecond)
+		assert.True(t, d <= 1200*time.Millisecond)
+	}
+
+	delayFunc = NewJitteredDelayFunc(NewConstantDelayFunc(time.Second), 5)
+	for i := 0; i < 100; i++ {
+		d := delayFunc(i)
+		assert.True(t, d >= 0)
+		assert.True(t, d <= 2*time.Second)
+	}
+
+	delayFunc = NewJitteredDelayFunc(NewConstantDelayFunc(0), 0.5)
+	assert.Equal(t, delayFunc(1), time.Duration(0))
+}
+
+func TestNewJitteredDelayFuncNoJitter(t *testing.T) {
+	delayFunc := NewConstantDelayFunc(time.Second)
+	assert.SameInstance(t, NewJitteredDelayFunc(delayFunc, 0), delayFunc)
+	assert.SameInstance(t, NewJitteredDelayFunc(delayFunc, -1), delayFunc)
+}
@@ -57,6 +57,11 @@ const (
 	// returned an error.
 	AttemptError
 
+	// AttemptPanic indicates that the attempt failed because it
+	// panicked and the panic was rescued. It may be retried,
+	// depending on Executor configuration.
+	AttemptPanic
+
 	// Internal use only. Must come last.
 	attemptUnknown
 )
@@ -101,7 +106,7 @@ func NewNoopDiagnosticsCallback() DiagnosticsCallback {
 
 // Valid returns true if the AttemptResult is a valid value.
 func (r AttemptResult) Valid() bool {
-	return r >= AttemptSuccess && r <= AttemptError
+	return r >= AttemptSuccess && r <= AttemptPanic
 }
 
 // String returns a string representation of the AttemptResult.
@@ -117,6 +122,8 @@ func (r AttemptResult) String() string {
 		return "AttemptCancellation"
 	case AttemptError:
 		return "AttemptError"
+	case AttemptPanic:
+		return "AttemptPanic"
 	default:
 		return "AttemptUnknown"
 	}
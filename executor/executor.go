@@ -60,6 +60,19 @@ type Executor interface {
 	// the callback is invoked with an empty []interface{}.
 	ExecGathered([]Func, CallbackFunc)
 
+	// Invoke the given Funcs, as in ExecMany. The GatherPolicy
+	// determines when the outcome is decided, at which point any
+	// incomplete Funcs are canceled. Calls back with the
+	// successful results and every failure once all Funcs have
+	// completed. If no Funcs are given, the callback is invoked
+	// with empty, satisfied GatheredResults. A nil GatherPolicy
+	// acts as NewFailFastGatherPolicy.
+	ExecGatheredWithPolicy([]Func, GatherPolicy, GatheredCallbackFunc)
+
+	// Inspect returns a Snapshot describing the Executor's
+	// configuration and the state of its tasks.
+	Inspect() Snapshot
+
 	// Stop executor activity and release related resources. In
 	// progress actions will complete their current
 	// attempt. Pending actions and retries are dropped and
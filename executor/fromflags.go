@@ -19,11 +19,13 @@ package executor
 //go:generate mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE --write_package_comment=false
 
 import (
+	"io"
 	"log"
 	"runtime"
 	"time"
 
 	tbnflag "github.com/turbinelabs/nonstdlib/flag"
+	"github.com/turbinelabs/nonstdlib/log/console"
 )
 
 // DelayType represents an algorithm for computing retry delays.
@@ -44,15 +46,27 @@ const (
 	flagDefaultMaxAttempts    = 8
 	flagDefaultTimeout        = 0 * time.Second
 	flagDefaultAttemptTimeout = 0 * time.Second
+	flagDefaultJitter         = 0.0
+	flagDefaultRetryBudget    = 0
+	flagDefaultMaxQueueDepth  = 0
+	flagDefaultDiagPeriod     = 1 * time.Minute
 )
 
 // FromFlags validates and constructs an Executor from command line
 // flags.
 type FromFlags interface {
-	// Returns the configured Executor. Multiple invocations
-	// return the same Executor even if the arguments
-	// change. DiagnosticsCallback may be nil.
+	// Returns the configured Executor. Rescued panics and, if
+	// enabled, diagnostics are logged to the given Logger, which
+	// may be nil. Multiple invocations return the same Executor
+	// even if the arguments change.
 	Make(*log.Logger) Executor
+
+	// Returns the configured Executor. The given Options are
+	// applied after those derived from flags, and so take
+	// precedence over them. Multiple invocations (including
+	// invocations of Make) return the same Executor even if the
+	// arguments change.
+	MakeWithOptions(...Option) Executor
 }
 
 // FromFlagsDefaults represents default values for Executor
@@ -66,6 +80,22 @@ type FromFlagsDefaults struct {
 	Parallelism    int
 	Timeout        time.Duration
 	AttemptTimeout time.Duration
+
+	// Jitter is the fraction of each retry delay that is
+	// randomized. See NewJitteredDelayFunc.
+	Jitter float64
+
+	// RetryBudget is the maximum number of tasks waiting to
+	// retry at once. See WithRetryBudget.
+	RetryBudget int
+
+	// MaxQueueDepth is the maximum number of tasks awaiting
+	// their first attempt. See WithMaxQueueDepth.
+	MaxQueueDepth int
+
+	// DiagnosticsPeriod is the period between logged
+	// diagnostics, if enabled.
+	DiagnosticsPeriod time.Duration
 }
 
 // NewFromFlags constructs a FromFlags with application-agnostic
@@ -81,8 +111,8 @@ func NewFromFlagsWithDefaults(
 	defaults FromFlagsDefaults,
 ) FromFlags {
 	delayTypeChoice :=
-		tbnflag.NewChoice(string(ConstantDelayType), string(ExponentialDelayType)).
-			WithDefault(string(defaults.DefaultDelayType()))
+		tbnflag.NewChoiceOf(ConstantDelayType, ExponentialDelayType).
+			WithDefault(defaults.DefaultDelayType())
 
 	ff := &fromFlags{
 		delayType: delayTypeChoice,
@@ -139,6 +169,46 @@ func NewFromFlagsWithDefaults(
 			"means no timeout.",
 	)
 
+	f.Float64Var(
+		&ff.jitter,
+		"jitter",
+		defaults.DefaultJitter(),
+		"Specifies the fraction of each retry delay that is randomized. For example, "+
+			"0.2 produces delays between 80% and 120% of the configured delay. Values are "+
+			"clamped to the range [0, 1].",
+	)
+
+	f.IntVar(
+		&ff.retryBudget,
+		"retry-budget",
+		defaults.DefaultRetryBudget(),
+		"Specifies the maximum number of actions waiting to retry at once. Failed "+
+			"attempts beyond the budget are not retried. A budget of 0 means no limit.",
+	)
+
+	f.IntVar(
+		&ff.maxQueueDepth,
+		"max-queue-depth",
+		defaults.DefaultMaxQueueDepth(),
+		"Specifies the maximum number of actions awaiting their first attempt. Actions "+
+			"submitted beyond this depth fail immediately. A depth of 0 means no limit.",
+	)
+
+	f.BoolVar(
+		&ff.diagnostics,
+		"diagnostics",
+		false,
+		"If true, periodically logs executor diagnostics.",
+	)
+
+	f.DurationVar(
+		&ff.diagnosticsPeriod,
+		"diagnostics-period",
+		defaults.DefaultDiagnosticsPeriod(),
+		"Specifies the period between logged executor diagnostics. Ignored unless "+
+			"diagnostics are enabled.",
+	)
+
 	return ff
 }
 
@@ -211,39 +281,117 @@ func (defaults FromFlagsDefaults) DefaultAttemptTimeout() time.Duration {
 	return flagDefaultAttemptTimeout
 }
 
+// DefaultJitter returns the default retry delay jitter. If not
+// overridden, the default jitter is 0 (delays are not randomized).
+func (defaults FromFlagsDefaults) DefaultJitter() float64 {
+	if defaults.Jitter != 0 {
+		return defaults.Jitter
+	}
+
+	return flagDefaultJitter
+}
+
+// DefaultRetryBudget returns the default retry budget. If not
+// overridden, the default retry budget is 0 (unlimited).
+func (defaults FromFlagsDefaults) DefaultRetryBudget() int {
+	if defaults.RetryBudget != 0 {
+		return defaults.RetryBudget
+	}
+
+	return flagDefaultRetryBudget
+}
+
+// DefaultMaxQueueDepth returns the default maximum queue depth. If
+// not overridden, the default maximum queue depth is 0 (unlimited).
+func (defaults FromFlagsDefaults) DefaultMaxQueueDepth() int {
+	if defaults.MaxQueueDepth != 0 {
+		return defaults.MaxQueueDepth
+	}
+
+	return flagDefaultMaxQueueDepth
+}
+
+// DefaultDiagnosticsPeriod returns the default period between logged
+// diagnostics. If not overridden, the default period is 1 minute.
+func (defaults FromFlagsDefaults) DefaultDiagnosticsPeriod() time.Duration {
+	if defaults.DiagnosticsPeriod != 0 {
+		return defaults.DiagnosticsPeriod
+	}
+
+	return flagDefaultDiagPeriod
+}
+
 type fromFlags struct {
-	delayType      tbnflag.Choice
-	initialDelay   time.Duration
-	maxDelay       time.Duration
-	maxAttempts    int
-	parallelism    int
-	timeout        time.Duration
-	attemptTimeout time.Duration
+	delayType         tbnflag.ChoiceOf[DelayType]
+	initialDelay      time.Duration
+	maxDelay          time.Duration
+	maxAttempts       int
+	parallelism       int
+	timeout           time.Duration
+	attemptTimeout    time.Duration
+	jitter            float64
+	retryBudget       int
+	maxQueueDepth     int
+	diagnostics       bool
+	diagnosticsPeriod time.Duration
 
 	executor Executor
 }
 
 func (ff *fromFlags) Make(log *log.Logger) Executor {
+	return ff.MakeWithOptions(WithLogger(log))
+}
+
+func (ff *fromFlags) MakeWithOptions(options ...Option) Executor {
 	if ff.executor == nil {
 		var delayFunc DelayFunc
-		switch DelayType(ff.delayType.String()) {
+		switch ff.delayType.Value() {
 		case ExponentialDelayType:
 			delayFunc = NewExponentialDelayFunc(ff.initialDelay, ff.maxDelay)
 		case ConstantDelayType:
 			delayFunc = NewConstantDelayFunc(ff.initialDelay)
 		}
 
-		options := []Option{
-			WithRetryDelayFunc(delayFunc),
+		allOptions := []Option{
+			WithRetryDelayFunc(NewJitteredDelayFunc(delayFunc, ff.jitter)),
 			WithMaxAttempts(ff.maxAttempts),
 			WithParallelism(ff.parallelism),
 			WithTimeout(ff.timeout),
 			WithAttemptTimeout(ff.attemptTimeout),
-			WithLogger(log),
+			WithRetryBudget(ff.retryBudget),
+			WithMaxQueueDepth(ff.maxQueueDepth),
+		}
+		allOptions = append(allOptions, options...)
+
+		if ff.diagnostics {
+			allOptions = append(allOptions, ff.withLoggingDiagnostics())
 		}
 
-		ff.executor = NewGoroutineExecutor(options...)
+		ff.executor = NewGoroutineExecutor(allOptions...)
 	}
 
 	return ff.executor
 }
+
+// withLoggingDiagnostics returns an Option that enables logging
+// diagnostics, unless a DiagnosticsCallback was already supplied.
+// Diagnostics are logged to the Executor's Logger or, if it has none,
+// console.Info(). The DiagnosticsCallback is closed when the Executor
+// is stopped.
+func (ff *fromFlags) withLoggingDiagnostics() Option {
+	return func(e *commonExec) {
+		if _, ok := e.diag.(*noopDiagnosticsCallback); !ok {
+			return
+		}
+
+		logger := e.log
+		if logger == nil {
+			logger = console.Info()
+		}
+
+		e.diag = NewLoggingDiagnosticsCallback(logger, ff.diagnosticsPeriod)
+		if closer, ok := e.diag.(io.Closer); ok {
+			e.ownedDiag = closer
+		}
+	}
+}
@@ -21,6 +21,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/mock/gomock"
+
 	tbnflag "github.com/turbinelabs/nonstdlib/flag"
 	"github.com/turbinelabs/test/assert"
 	"github.com/turbinelabs/test/log"
@@ -43,6 +45,11 @@ func TestFromFlags(t *testing.T) {
 	assert.Equal(t, ffImpl.maxDelay, 30*time.Second)
 	assert.Equal(t, ffImpl.maxAttempts, 8)
 	assert.Equal(t, ffImpl.parallelism, expectedParallelism)
+	assert.Equal(t, ffImpl.jitter, 0.0)
+	assert.Equal(t, ffImpl.retryBudget, 0)
+	assert.Equal(t, ffImpl.maxQueueDepth, 0)
+	assert.False(t, ffImpl.diagnostics)
+	assert.Equal(t, ffImpl.diagnosticsPeriod, time.Minute)
 	assert.Nil(t, ffImpl.executor)
 
 	diag := NewNoopDiagnosticsCallback()
@@ -82,6 +89,8 @@ func TestFromFlags(t *testing.T) {
 		"-exec.parallelism=99",
 		"-exec.timeout=100ms",
 		"-exec.attempt-timeout=10ms",
+		"-exec.retry-budget=3",
+		"-exec.max-queue-depth=7",
 	})
 
 	assert.Equal(t, ffImpl.delayType.String(), string(ConstantDelayType))
@@ -91,6 +100,8 @@ func TestFromFlags(t *testing.T) {
 	assert.Equal(t, ffImpl.parallelism, 99)
 	assert.Equal(t, ffImpl.timeout, 100*time.Millisecond)
 	assert.Equal(t, ffImpl.attemptTimeout, 10*time.Millisecond)
+	assert.Equal(t, ffImpl.retryBudget, 3)
+	assert.Equal(t, ffImpl.maxQueueDepth, 7)
 
 	expectedParallelism = 99
 
@@ -111,6 +122,8 @@ func TestFromFlags(t *testing.T) {
 	assert.Equal(t, commonImpl.delay(1), 1*time.Second)
 	assert.Equal(t, commonImpl.delay(100000), 1*time.Second)
 	assert.Equal(t, commonImpl.timeout, 100*time.Millisecond)
+	assert.Equal(t, commonImpl.retryBudget, 3)
+	assert.Equal(t, commonImpl.maxQueueDepth, 7)
 	assert.Nil(t, commonImpl.log)
 	_, ok = commonImpl.diag.(*noopDiagnosticsCallback)
 	assert.True(t, ok)
@@ -130,18 +143,26 @@ func TestFromFlagsWithDefaults(t *testing.T) {
 	assert.Equal(t, ffImpl.parallelism, 2*runtime.NumCPU())
 	assert.Equal(t, ffImpl.timeout, 0*time.Second)
 	assert.Equal(t, ffImpl.attemptTimeout, 0*time.Second)
+	assert.Equal(t, ffImpl.jitter, 0.0)
+	assert.Equal(t, ffImpl.retryBudget, 0)
+	assert.Equal(t, ffImpl.maxQueueDepth, 0)
+	assert.Equal(t, ffImpl.diagnosticsPeriod, flagDefaultDiagPeriod)
 
 	prefixedFlagSet = tbnflag.NewTestFlagSet().Scope("exec", "whatever")
 	ff = NewFromFlagsWithDefaults(
 		prefixedFlagSet,
 		FromFlagsDefaults{
-			DelayType:      ConstantDelayType,
-			InitialDelay:   1 * time.Second,
-			MaxDelay:       2 * time.Second,
-			MaxAttempts:    3,
-			Parallelism:    5,
-			Timeout:        6 * time.Second,
-			AttemptTimeout: 7 * time.Millisecond,
+			DelayType:         ConstantDelayType,
+			InitialDelay:      1 * time.Second,
+			MaxDelay:          2 * time.Second,
+			MaxAttempts:       3,
+			Parallelism:       5,
+			Timeout:           6 * time.Second,
+			AttemptTimeout:    7 * time.Millisecond,
+			Jitter:            0.1,
+			RetryBudget:       8,
+			MaxQueueDepth:     9,
+			DiagnosticsPeriod: 10 * time.Second,
 		},
 	)
 	ffImpl = ff.(*fromFlags)
@@ -153,4 +174,71 @@ func TestFromFlagsWithDefaults(t *testing.T) {
 	assert.Equal(t, ffImpl.parallelism, 5)
 	assert.Equal(t, ffImpl.timeout, 6*time.Second)
 	assert.Equal(t, ffImpl.attemptTimeout, 7*time.Millisecond)
+	assert.Equal(t, ffImpl.jitter, 0.1)
+	assert.Equal(t, ffImpl.retryBudget, 8)
+	assert.Equal(t, ffImpl.maxQueueDepth, 9)
+	assert.Equal(t, ffImpl.diagnosticsPeriod, 10*time.Second)
+}
+
+func TestFromFlagsMakeWithOptions(t *testing.T) {
+	flagSet := tbnflag.NewTestFlagSet()
+	ff := NewFromFlags(flagSet.Scope("exec", "whatever"))
+	flagSet.Parse([]string{
+		"-exec.max-attempts=4",
+		"-exec.parallelism=3",
+		"-exec.jitter=0.5",
+	})
+
+	exec := ff.MakeWithOptions(WithMaxAttempts(2))
+	defer exec.Stop()
+	assert.SameInstance(t, ff.Make(nil), exec)
+
+	commonImpl := exec.(*commonExec)
+	assert.Equal(t, commonImpl.maxAttempts, 2)
+	assert.Equal(t, commonImpl.parallelism, 3)
+
+	for i := 0; i < 100; i++ {
+		d := commonImpl.delay(1)
+		assert.True(t, d >= 50*time.Millisecond)
+		assert.True(t, d <= 150*time.Millisecond)
+	}
+
+	_, ok := commonImpl.diag.(*noopDiagnosticsCallback)
+	assert.True(t, ok)
+}
+
+func TestFromFlagsDiagnostics(t *testing.T) {
+	log := log.NewNoopLogger()
+
+	flagSet := tbnflag.NewTestFlagSet()
+	ff := NewFromFlags(flagSet.Scope("exec", "whatever"))
+	flagSet.Parse([]string{
+		"-exec.diagnostics",
+		"-exec.diagnostics-period=5s",
+	})
+
+	exec := ff.Make(log)
+
+	ldc, ok := exec.(*commonExec).diag.(*loggingDiagnosticsCallback)
+	assert.True(t, ok)
+	assert.SameInstance(t, ldc.logger, log)
+	assert.Equal(t, ldc.period, 5*time.Second)
+
+	// stopping the executor stops periodic logging
+	exec.Stop()
+	select {
+	case <-ldc.quit:
+	default:
+		t.Error("expected logging diagnostics to be closed")
+	}
+
+	// an explicit DiagnosticsCallback takes precedence
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	diag := NewMockDiagnosticsCallback(ctrl)
+	ff.(*fromFlags).executor = nil
+	exec = ff.MakeWithOptions(WithDiagnostics(diag))
+	defer exec.Stop()
+	assert.SameInstance(t, exec.(*commonExec).diag, diag)
 }
@@ -0,0 +1,148 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// GatherPolicy is invoked each time a Func passed to
+// ExecGatheredWithPolicy completes. The values passed are the total
+// number of Funcs, and the number that have succeeded and failed so
+// far. It returns whether the outcome has been decided and, if so,
+// whether the policy was satisfied. Once the outcome is decided,
+// any Funcs that have not yet completed are canceled.
+type GatherPolicy func(total, succeeded, failed int) (done, satisfied bool)
+
+// NewGatherAllPolicy creates a GatherPolicy that waits for every
+// Func to complete, never canceling any of them. The policy is
+// satisfied only if every Func succeeds.
+func NewGatherAllPolicy() GatherPolicy {
+	return func(total, succeeded, failed int) (bool, bool) {
+		if succeeded+failed < total {
+			return false, false
+		}
+
+		return true, failed == 0
+	}
+}
+
+// NewFailFastGatherPolicy creates a GatherPolicy that is decided by
+// the first failure, which cancels any Funcs that have not yet
+// completed. The policy is satisfied only if every Func succeeds.
+func NewFailFastGatherPolicy() GatherPolicy {
+	return func(total, succeeded, failed int) (bool, bool) {
+		if failed > 0 {
+			return true, false
+		}
+
+		return succeeded == total, true
+	}
+}
+
+// NewQuorumGatherPolicy creates a GatherPolicy that is satisfied as
+// soon as quorum Funcs succeed. It fails as soon as enough Funcs
+// have failed that the quorum can no longer be reached. Either way,
+// Funcs that have not yet completed are canceled. Values less than 1
+// act as if 1 had been passed. Values greater than the number of
+// Funcs require every Func to succeed.
+func NewQuorumGatherPolicy(quorum int) GatherPolicy {
+	if quorum < 1 {
+		quorum = 1
+	}
+
+	return func(total, succeeded, failed int) (bool, bool) {
+		q := quorum
+		if q > total {
+			q = total
+		}
+
+		if succeeded >= q {
+			return true, true
+		} else if failed > total-q {
+			return true, false
+		}
+
+		return false, false
+	}
+}
+
+// GatheredCallbackFunc is invoked exactly once with the results of a
+// call to ExecGatheredWithPolicy.
+type GatheredCallbackFunc func(*GatheredResults)
+
+// GatheredResults contains the outcome of a call to
+// ExecGatheredWithPolicy.
+type GatheredResults struct {
+	// Successes maps the index of each Func that succeeded to its
+	// result.
+	Successes map[int]interface{}
+
+	// Failures lists the error returned by each Func that failed,
+	// ordered by index. Funcs canceled after the GatherPolicy was
+	// decided are included.
+	Failures MultiError
+
+	// Satisfied indicates whether the GatherPolicy was satisfied.
+	Satisfied bool
+}
+
+// Err returns nil if the GatherPolicy was satisfied. Otherwise, it
+// returns Failures.
+func (r *GatheredResults) Err() error {
+	if r.Satisfied {
+		return nil
+	}
+
+	return r.Failures
+}
+
+// IndexedError associates an error with the index of the Func that
+// produced it.
+type IndexedError struct {
+	Index int
+	Err   error
+}
+
+func (e IndexedError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Index, e.Err.Error())
+}
+
+// MultiError is an error composed of IndexedErrors.
+type MultiError []IndexedError
+
+func (m MultiError) Error() string {
+	switch len(m) {
+	case 0:
+		return "no errors"
+	case 1:
+		return m[0].Error()
+	}
+
+	msgs := make([]string, len(m))
+	for i, e := range m {
+		msgs[i] = e.Error()
+	}
+
+	return fmt.Sprintf("%d errors: %s", len(m), strings.Join(msgs, "; "))
+}
+
+func (m MultiError) sort() {
+	sort.Slice(m, func(i, j int) bool { return m[i].Index < m[j].Index })
+}
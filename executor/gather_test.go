@@ -0,0 +1,100 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+type policyOutcome struct {
+	done, satisfied bool
+}
+
+func decide(p GatherPolicy, total, succeeded, failed int) policyOutcome {
+	done, satisfied := p(total, succeeded, failed)
+	return policyOutcome{done, satisfied}
+}
+
+func TestNewGatherAllPolicy(t *testing.T) {
+	p := NewGatherAllPolicy()
+
+	assert.Equal(t, decide(p, 3, 0, 0), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 3, 1, 1), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 3, 0, 2), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 3, 3, 0), policyOutcome{true, true})
+	assert.Equal(t, decide(p, 3, 2, 1), policyOutcome{true, false})
+	assert.Equal(t, decide(p, 0, 0, 0), policyOutcome{true, true})
+}
+
+func TestNewFailFastGatherPolicy(t *testing.T) {
+	p := NewFailFastGatherPolicy()
+
+	assert.Equal(t, decide(p, 3, 0, 0), policyOutcome{false, true})
+	assert.Equal(t, decide(p, 3, 2, 0), policyOutcome{false, true})
+	assert.Equal(t, decide(p, 3, 3, 0), policyOutcome{true, true})
+	assert.Equal(t, decide(p, 3, 0, 1), policyOutcome{true, false})
+	assert.Equal(t, decide(p, 3, 2, 1), policyOutcome{true, false})
+}
+
+func TestNewQuorumGatherPolicy(t *testing.T) {
+	p := NewQuorumGatherPolicy(2)
+
+	assert.Equal(t, decide(p, 3, 0, 0), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 3, 1, 1), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 3, 2, 0), policyOutcome{true, true})
+	assert.Equal(t, decide(p, 3, 2, 1), policyOutcome{true, true})
+	assert.Equal(t, decide(p, 3, 0, 2), policyOutcome{true, false})
+
+	// quorum larger than total requires every func
+	assert.Equal(t, decide(p, 1, 0, 0), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 1, 1, 0), policyOutcome{true, true})
+	assert.Equal(t, decide(p, 1, 0, 1), policyOutcome{true, false})
+}
+
+func TestNewQuorumGatherPolicyInvalidQuorum(t *testing.T) {
+	p := NewQuorumGatherPolicy(-1)
+
+	assert.Equal(t, decide(p, 3, 0, 0), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 3, 1, 0), policyOutcome{true, true})
+	assert.Equal(t, decide(p, 3, 0, 2), policyOutcome{false, false})
+	assert.Equal(t, decide(p, 3, 0, 3), policyOutcome{true, false})
+}
+
+func TestGatheredResultsErr(t *testing.T) {
+	failures := MultiError{{Index: 1, Err: errors.New("boom")}}
+
+	r := &GatheredResults{Failures: failures, Satisfied: true}
+	assert.Nil(t, r.Err())
+
+	r.Satisfied = false
+	assert.DeepEqual(t, r.Err(), failures)
+}
+
+func TestMultiError(t *testing.T) {
+	m := MultiError{}
+	assert.Equal(t, m.Error(), "no errors")
+
+	m = append(m, IndexedError{Index: 2, Err: errors.New("p2")})
+	assert.Equal(t, m.Error(), "2: p2")
+
+	m = append(m, IndexedError{Index: 0, Err: errors.New("p0")})
+	m.sort()
+	assert.Equal(t, m.Error(), "2 errors: 0: p0; 2: p2")
+}
@@ -16,11 +16,7 @@ limitations under the License.
 
 package executor
 
-import (
-	"time"
-
-	tbntime "github.com/turbinelabs/nonstdlib/time"
-)
+import "time"
 
 type semRequest struct{}
 
@@ -41,35 +37,24 @@ type goroutineExecImpl struct {
 // NewGoroutineExecutor constructs a new Executor. Each task attempt
 // is executed in a new goroutine, but only a fixed number (the
 // parallelism) are allowed to execute at once. By default, the
-// Executor never retries, has parallelism of 1, and a maximum queue
-// depth of 10.
+// Executor never retries, has parallelism of 1, and an unlimited
+// queue depth.
 func NewGoroutineExecutor(options ...Option) Executor {
 	impl := &goroutineExecImpl{}
 
-	e := &commonExec{
-		time:           tbntime.NewSource(),
-		parallelism:    defaultParallelism,
-		maxAttempts:    defaultMaxAttempts,
-		delay:          defaultDelayFunc,
-		timeout:        noTimeout,
-		attemptTimeout: noTimeout,
-		diag:           NewNoopDiagnosticsCallback(),
-		impl:           impl,
-	}
-
-	for _, apply := range options {
-		apply(e)
-	}
+	e := newCommonExec(impl, options)
 
 	impl.sem = make(semaphore, e.parallelism)
 
 	if e.log != nil {
 		e.log.Printf(
-			"goroutine executor: max parallelism %d, max attempts %d, global timeout %s, attempt timeout %s",
+			"goroutine executor: max parallelism %d, max attempts %d, global timeout %s, attempt timeout %s, max queue depth %d, retry budget %d",
 			e.parallelism,
 			e.maxAttempts,
 			e.timeout,
 			e.attemptTimeout,
+			e.maxQueueDepth,
+			e.retryBudget,
 		)
 	}
 
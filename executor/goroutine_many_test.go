@@ -45,3 +45,19 @@ func TestGoroutineExecExecGatheredNoCallback(t *testing.T) {
 func TestGoroutineExecExecGatheredNoop(t *testing.T) {
 	testExecGatheredNoop(t, NewGoroutineExecutor)
 }
+
+func TestGoroutineExecExecGatheredWithPolicyAll(t *testing.T) {
+	testExecGatheredWithPolicyAll(t, NewGoroutineExecutor)
+}
+
+func TestGoroutineExecExecGatheredWithPolicyQuorum(t *testing.T) {
+	testExecGatheredWithPolicyQuorum(t, NewGoroutineExecutor)
+}
+
+func TestGoroutineExecExecGatheredWithPolicyFailFast(t *testing.T) {
+	testExecGatheredWithPolicyFailFast(t, NewGoroutineExecutor)
+}
+
+func TestGoroutineExecExecGatheredWithPolicyNoop(t *testing.T) {
+	testExecGatheredWithPolicyNoop(t, NewGoroutineExecutor)
+}
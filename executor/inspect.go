@@ -0,0 +1,176 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// maxSnapshotRetryDelays limits the number of retry delays reported
+// in SnapshotOptions.
+const maxSnapshotRetryDelays = 10
+
+// Snapshot describes the state of an Executor at a point in time.
+// Durations are encoded in JSON as nanoseconds.
+type Snapshot struct {
+	// Time is the time at which the Snapshot was taken.
+	Time time.Time `json:"time"`
+
+	// Options contains the Executor's configuration.
+	Options SnapshotOptions `json:"options"`
+
+	// InFlight contains tasks with an attempt in progress.
+	InFlight []TaskSnapshot `json:"in_flight"`
+
+	// Queued contains tasks waiting to make an attempt, typically
+	// because the Executor's parallelism has been reached.
+	Queued []TaskSnapshot `json:"queued"`
+
+	// Waiting contains tasks waiting for their next retry.
+	Waiting []TaskSnapshot `json:"waiting"`
+}
+
+// SnapshotOptions describes an Executor's configuration.
+type SnapshotOptions struct {
+	Parallelism    int           `json:"parallelism"`
+	MaxAttempts    int           `json:"max_attempts"`
+	Timeout        time.Duration `json:"timeout"`
+	AttemptTimeout time.Duration `json:"attempt_timeout"`
+	MaxQueueDepth  int           `json:"max_queue_depth"`
+	RetryBudget    int           `json:"retry_budget"`
+
+	// RetryDelays lists the delay before each retry, up to a
+	// limit of 10.
+	RetryDelays []time.Duration `json:"retry_delays"`
+}
+
+// TaskSnapshot describes a single task. Each Func passed to
+// ExecMany, ExecGathered, or ExecGatheredWithPolicy is a separate
+// task.
+type TaskSnapshot struct {
+	// ID uniquely identifies the task within the Executor.
+	ID uint64 `json:"id"`
+
+	// Started is the time the task was submitted.
+	Started time.Time `json:"started"`
+
+	// Age is the time elapsed since the task was submitted.
+	Age time.Duration `json:"age"`
+
+	// Attempts is the number of attempts started, including any
+	// in progress.
+	Attempts int `json:"attempts"`
+
+	// AttemptStarted and AttemptElapsed describe the attempt in
+	// progress. They are only set for in-flight tasks.
+	AttemptStarted *time.Time    `json:"attempt_started,omitempty"`
+	AttemptElapsed time.Duration `json:"attempt_elapsed,omitempty"`
+
+	// NextAttempt is the time of the next retry. It is only set
+	// for waiting tasks.
+	NextAttempt *time.Time `json:"next_attempt,omitempty"`
+}
+
+func (c *commonExec) Inspect() Snapshot {
+	now := c.time.Now()
+
+	snapshot := Snapshot{
+		Time:     now,
+		Options:  c.snapshotOptions(),
+		InFlight: []TaskSnapshot{},
+		Queued:   []TaskSnapshot{},
+		Waiting:  []TaskSnapshot{},
+	}
+
+	c.trackMutex.Lock()
+	defer c.trackMutex.Unlock()
+
+	for r := range c.live {
+		ts := TaskSnapshot{
+			ID:       r.id,
+			Started:  r.start,
+			Age:      now.Sub(r.start),
+			Attempts: r.attempts,
+		}
+
+		switch r.state {
+		case retryRunning:
+			attemptStart := r.attemptStart
+			ts.AttemptStarted = &attemptStart
+			ts.AttemptElapsed = now.Sub(r.attemptStart)
+			snapshot.InFlight = append(snapshot.InFlight, ts)
+
+		case retryWaiting:
+			nextAttempt := r.nextAttempt
+			ts.NextAttempt = &nextAttempt
+			snapshot.Waiting = append(snapshot.Waiting, ts)
+
+		default:
+			snapshot.Queued = append(snapshot.Queued, ts)
+		}
+	}
+
+	sortTaskSnapshots(snapshot.InFlight)
+	sortTaskSnapshots(snapshot.Queued)
+	sortTaskSnapshots(snapshot.Waiting)
+
+	return snapshot
+}
+
+func (c *commonExec) snapshotOptions() SnapshotOptions {
+	n := c.maxAttempts - 1
+	if n > maxSnapshotRetryDelays {
+		n = maxSnapshotRetryDelays
+	}
+
+	delays := make([]time.Duration, n)
+	for i := range delays {
+		delays[i] = c.delay(i + 1)
+	}
+
+	return SnapshotOptions{
+		Parallelism:    c.parallelism,
+		MaxAttempts:    c.maxAttempts,
+		Timeout:        c.timeout,
+		AttemptTimeout: c.attemptTimeout,
+		MaxQueueDepth:  c.maxQueueDepth,
+		RetryBudget:    c.retryBudget,
+		RetryDelays:    delays,
+	}
+}
+
+func sortTaskSnapshots(s []TaskSnapshot) {
+	sort.Slice(s, func(i, j int) bool { return s[i].ID < s[j].ID })
+}
+
+// NewInspectHandler returns an http.Handler that responds to every
+// request with the Executor's Snapshot, encoded as JSON.
+func NewInspectHandler(e Executor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		b, err := json.MarshalIndent(e.Inspect(), "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(b)
+	})
+}
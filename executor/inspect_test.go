@@ -0,0 +1,201 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	tbntime "github.com/turbinelabs/nonstdlib/time"
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestInspect(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		start := cs.Now()
+
+		e := NewTestExecutor(
+			cs,
+			WithRetryDelayFunc(NewExponentialDelayFunc(time.Second, 4*time.Second)),
+			WithMaxAttempts(3),
+			WithTimeout(time.Minute),
+			WithAttemptTimeout(10*time.Second),
+		)
+		defer e.Stop()
+
+		var inFlight Snapshot
+		f := func(_ context.Context) (interface{}, error) {
+			cs.Advance(time.Second)
+			inFlight = e.Inspect()
+			return nil, errors.New("failed")
+		}
+
+		e.Exec(f, nil)
+		e.Exec(f, nil)
+
+		snapshot := e.Inspect()
+		assert.Equal(t, snapshot.Time, start)
+		assert.DeepEqual(
+			t,
+			snapshot.Options,
+			SnapshotOptions{
+				Parallelism:    1,
+				MaxAttempts:    3,
+				Timeout:        time.Minute,
+				AttemptTimeout: 10 * time.Second,
+				RetryDelays:    []time.Duration{time.Second, 2 * time.Second},
+			},
+		)
+		assert.Equal(t, len(snapshot.InFlight), 0)
+		assert.Equal(t, len(snapshot.Waiting), 0)
+		assert.ArrayEqual(
+			t,
+			snapshot.Queued,
+			[]TaskSnapshot{
+				{ID: 1, Started: start},
+				{ID: 2, Started: start},
+			},
+		)
+
+		assert.True(t, e.RunNext())
+
+		attemptStart := start
+		assert.Equal(t, len(inFlight.Queued), 1)
+		assert.Equal(t, len(inFlight.Waiting), 0)
+		assert.ArrayEqual(
+			t,
+			inFlight.InFlight,
+			[]TaskSnapshot{
+				{
+					ID:             1,
+					Started:        start,
+					Age:            time.Second,
+					Attempts:       1,
+					AttemptStarted: &attemptStart,
+					AttemptElapsed: time.Second,
+				},
+			},
+		)
+
+		snapshot = e.Inspect()
+		nextAttempt := start.Add(2 * time.Second)
+		assert.Equal(t, len(snapshot.InFlight), 0)
+		assert.ArrayEqual(
+			t,
+			snapshot.Waiting,
+			[]TaskSnapshot{
+				{
+					ID:          1,
+					Started:     start,
+					Age:         time.Second,
+					Attempts:    1,
+					NextAttempt: &nextAttempt,
+				},
+			},
+		)
+		assert.ArrayEqual(
+			t,
+			snapshot.Queued,
+			[]TaskSnapshot{{ID: 2, Started: start, Age: time.Second}},
+		)
+
+		e.RunUntilIdle()
+
+		snapshot = e.Inspect()
+		assert.Equal(t, len(snapshot.InFlight), 0)
+		assert.Equal(t, len(snapshot.Queued), 0)
+		assert.Equal(t, len(snapshot.Waiting), 0)
+	})
+}
+
+func TestInspectAfterStop(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs)
+		e.ExecAndForget(func(_ context.Context) (interface{}, error) { return nil, nil })
+		assert.Equal(t, len(e.Inspect().Queued), 1)
+
+		e.Stop()
+		assert.Equal(t, len(e.Inspect().Queued), 0)
+	})
+}
+
+func TestNewInspectHandler(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs, WithParallelism(4))
+		defer e.Stop()
+
+		e.ExecAndForget(func(_ context.Context) (interface{}, error) { return nil, nil })
+
+		rec := httptest.NewRecorder()
+		NewInspectHandler(e).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+		assert.Equal(t, rec.Code, 200)
+		assert.Equal(t, rec.Header().Get("Content-Type"), "application/json")
+
+		decoded := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(rec.Body.Bytes(), &decoded))
+
+		options := decoded["options"].(map[string]interface{})
+		assert.Equal(t, options["parallelism"], 4.0)
+		assert.Equal(t, len(decoded["queued"].([]interface{})), 1)
+		assert.Equal(t, len(decoded["in_flight"].([]interface{})), 0)
+	})
+}
+
+func TestTaskSnapshotJSON(t *testing.T) {
+	start := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	decode := func(ts TaskSnapshot) map[string]interface{} {
+		b, err := json.Marshal(ts)
+		assert.Nil(t, err)
+
+		decoded := map[string]interface{}{}
+		assert.Nil(t, json.Unmarshal(b, &decoded))
+		return decoded
+	}
+
+	idle := decode(TaskSnapshot{ID: 1, Started: start})
+	assert.Equal(t, idle["started"], "2018-01-02T03:04:05Z")
+	for _, key := range []string{"attempt_started", "attempt_elapsed", "next_attempt"} {
+		_, ok := idle[key]
+		assert.False(t, ok)
+	}
+
+	attemptStart := start.Add(time.Second)
+	inFlight := decode(
+		TaskSnapshot{
+			ID:             1,
+			Started:        start,
+			AttemptStarted: &attemptStart,
+			AttemptElapsed: time.Second,
+		},
+	)
+	assert.Equal(t, inFlight["attempt_started"], "2018-01-02T03:04:06Z")
+	assert.Equal(t, inFlight["attempt_elapsed"], float64(time.Second))
+	_, ok := inFlight["next_attempt"]
+	assert.False(t, ok)
+
+	nextAttempt := start.Add(2 * time.Second)
+	waiting := decode(TaskSnapshot{ID: 1, Started: start, NextAttempt: &nextAttempt})
+	assert.Equal(t, waiting["next_attempt"], "2018-01-02T03:04:07Z")
+	_, ok = waiting["attempt_started"]
+	assert.False(t, ok)
+}
@@ -71,6 +71,28 @@ func (mr *MockExecutorMockRecorder) ExecGathered(arg0, arg1 interface{}) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecGathered", reflect.TypeOf((*MockExecutor)(nil).ExecGathered), arg0, arg1)
 }
 
+// ExecGatheredWithPolicy mocks base method
+func (m *MockExecutor) ExecGatheredWithPolicy(arg0 []Func, arg1 GatherPolicy, arg2 GatheredCallbackFunc) {
+	m.ctrl.Call(m, "ExecGatheredWithPolicy", arg0, arg1, arg2)
+}
+
+// ExecGatheredWithPolicy indicates an expected call of ExecGatheredWithPolicy
+func (mr *MockExecutorMockRecorder) ExecGatheredWithPolicy(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExecGatheredWithPolicy", reflect.TypeOf((*MockExecutor)(nil).ExecGatheredWithPolicy), arg0, arg1, arg2)
+}
+
+// Inspect mocks base method
+func (m *MockExecutor) Inspect() Snapshot {
+	ret := m.ctrl.Call(m, "Inspect")
+	ret0, _ := ret[0].(Snapshot)
+	return ret0
+}
+
+// Inspect indicates an expected call of Inspect
+func (mr *MockExecutorMockRecorder) Inspect() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Inspect", reflect.TypeOf((*MockExecutor)(nil).Inspect))
+}
+
 // Stop mocks base method
 func (m *MockExecutor) Stop() {
 	m.ctrl.Call(m, "Stop")
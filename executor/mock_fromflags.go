@@ -43,3 +43,19 @@ func (m *MockFromFlags) Make(arg0 *log.Logger) Executor {
 func (mr *MockFromFlagsMockRecorder) Make(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Make", reflect.TypeOf((*MockFromFlags)(nil).Make), arg0)
 }
+
+// MakeWithOptions mocks base method
+func (m *MockFromFlags) MakeWithOptions(arg0 ...Option) Executor {
+	varargs := []interface{}{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "MakeWithOptions", varargs...)
+	ret0, _ := ret[0].(Executor)
+	return ret0
+}
+
+// MakeWithOptions indicates an expected call of MakeWithOptions
+func (mr *MockFromFlagsMockRecorder) MakeWithOptions(arg0 ...interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MakeWithOptions", reflect.TypeOf((*MockFromFlags)(nil).MakeWithOptions), arg0...)
+}
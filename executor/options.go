@@ -35,6 +35,32 @@ func WithLogger(log *log.Logger) Option {
 	}
 }
 
+// WithPanicPolicy sets the PanicPolicy used when a Func
+// panics. Defaults to RescuePanics.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(e *commonExec) {
+		e.panicPolicy = policy
+	}
+}
+
+// WithPanicHandler sets a PanicHandler for rescued panics and sets
+// the PanicPolicy to HandlePanics.
+func WithPanicHandler(h PanicHandler) Option {
+	return func(e *commonExec) {
+		e.panicPolicy = HandlePanics
+		e.panicHandler = h
+	}
+}
+
+// WithPanicRetries sets whether attempts that fail because of a
+// rescued panic are retried. Defaults to true. Has no effect if the
+// PanicPolicy is RepanicPanics.
+func WithPanicRetries(retry bool) Option {
+	return func(e *commonExec) {
+		e.retryPanics = retry
+	}
+}
+
 // WithDiagnostics sets a DiagnosticsCallback for the Executor.
 func WithDiagnostics(cb DiagnosticsCallback) Option {
 	return func(e *commonExec) {
@@ -110,3 +136,36 @@ func WithTimeSource(src tbntime.Source) Option {
 		e.time = src
 	}
 }
+
+// WithMaxQueueDepth sets the maximum number of tasks that may be
+// queued awaiting their first attempt. Once the limit is reached,
+// newly submitted tasks are rejected: their callbacks are invoked
+// immediately with ErrQueueFull. Retries waiting for their next
+// attempt do not count against the limit. Values less than 1 mean
+// the queue depth is unlimited, which is the default.
+func WithMaxQueueDepth(depth int) Option {
+	if depth < 0 {
+		depth = 0
+	}
+
+	return func(e *commonExec) {
+		e.maxQueueDepth = depth
+	}
+}
+
+// WithRetryBudget sets the maximum number of tasks that may be
+// waiting to retry at once. Once the budget is exhausted, failed
+// attempts are not retried and the task fails with its most recent
+// error. Limiting retries prevents a failing dependency from being
+// overwhelmed by retry storms. Values less than 1 mean retries are
+// limited only by the maximum number of attempts, which is the
+// default.
+func WithRetryBudget(budget int) Option {
+	if budget < 0 {
+		budget = 0
+	}
+
+	return func(e *commonExec) {
+		e.retryBudget = budget
+	}
+}
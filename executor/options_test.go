@@ -86,3 +86,23 @@ func TestWithTimeout(t *testing.T) {
 	WithTimeout(-100 * time.Millisecond)(exec)
 	assert.Equal(t, exec.timeout, 0*time.Second)
 }
+
+func TestWithMaxQueueDepth(t *testing.T) {
+	exec := &commonExec{}
+
+	WithMaxQueueDepth(10)(exec)
+	assert.Equal(t, exec.maxQueueDepth, 10)
+
+	WithMaxQueueDepth(-1)(exec)
+	assert.Equal(t, exec.maxQueueDepth, 0)
+}
+
+func TestWithRetryBudget(t *testing.T) {
+	exec := &commonExec{}
+
+	WithRetryBudget(5)(exec)
+	assert.Equal(t, exec.retryBudget, 5)
+
+	WithRetryBudget(-1)(exec)
+	assert.Equal(t, exec.retryBudget, 0)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import "fmt"
+
+// PanicPolicy determines how an Executor handles a panic in a Func.
+type PanicPolicy int
+
+const (
+	// RescuePanics recovers panics and converts them into a
+	// failed attempt with a *PanicError. This is the default.
+	RescuePanics PanicPolicy = iota
+
+	// RepanicPanics does not recover panics. Typically, this
+	// causes the program to crash.
+	RepanicPanics
+
+	// HandlePanics recovers panics, passes the *PanicError to a
+	// PanicHandler, and then proceeds as with RescuePanics.
+	HandlePanics
+)
+
+// PanicHandler is invoked with rescued panics when the HandlePanics
+// PanicPolicy is in effect. It is invoked before the failed attempt
+// is retried or the task's callback is invoked.
+type PanicHandler func(*PanicError)
+
+// PanicError is the error produced when a panic in a Func is
+// rescued.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Stack is the stack trace of the goroutine that panicked.
+	Stack []byte
+}
+
+// Error returns a description of the panic value. Strings, errors,
+// and fmt.Stringers are represented as such. Other values are
+// formatted with "%#v".
+func (e *PanicError) Error() string {
+	switch v := e.Value.(type) {
+	case string:
+		return v
+
+	case error:
+		return v.Error()
+
+	case fmt.Stringer:
+		return v.String()
+
+	default:
+		return fmt.Sprintf("%#v", v)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	tbntime "github.com/turbinelabs/nonstdlib/time"
+	"github.com/turbinelabs/test/assert"
+)
+
+func panicFunc(i interface{}) Func {
+	return func(_ context.Context) (interface{}, error) {
+		panic(i)
+	}
+}
+
+func TestPanicErrorError(t *testing.T) {
+	assert.Equal(t, (&PanicError{Value: "p1 panic"}).Error(), "p1 panic")
+	assert.Equal(t, (&PanicError{Value: errors.New("p2 panic")}).Error(), "p2 panic")
+	assert.Equal(t, (&PanicError{Value: stringer("p3 panic")}).Error(), "p3 panic")
+
+	p4panic := panicStruct{"p4 panic"}
+	assert.Equal(t, (&PanicError{Value: p4panic}).Error(), fmt.Sprintf("%#v", p4panic))
+}
+
+func TestRescuedPanicsArePanicErrors(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs, WithMaxAttempts(2))
+		defer e.Stop()
+
+		var result Try
+		e.Exec(panicFunc("boom"), func(try Try) { result = try })
+		assert.Equal(t, e.RunUntilIdle(), 2)
+
+		assert.NonNil(t, result)
+		pe, ok := result.Error().(*PanicError)
+		assert.True(t, ok)
+		assert.Equal(t, pe.Value, "boom")
+		assert.True(t, strings.Contains(string(pe.Stack), "panicFunc"))
+
+		attempts := e.Attempts()
+		assert.Equal(t, len(attempts), 2)
+		assert.Equal(t, attempts[0].Result, AttemptPanic)
+		assert.Equal(t, attempts[1].Result, AttemptPanic)
+	})
+}
+
+func TestPanicRetriesDisabled(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs, WithMaxAttempts(3), WithPanicRetries(false))
+		defer e.Stop()
+
+		var result Try
+		e.Exec(panicFunc("boom"), func(try Try) { result = try })
+		assert.Equal(t, e.RunUntilIdle(), 1)
+		assert.NonNil(t, result)
+		assert.Equal(t, result.Error().Error(), "boom")
+
+		// errors are still retried
+		data := &testData{id: "a", fails: 1}
+		e.Exec(data.mkFunc(&testRun{}), func(try Try) { result = try })
+		assert.Equal(t, e.RunUntilIdle(), 2)
+		assert.True(t, result.IsReturn())
+	})
+}
+
+func TestPanicHandler(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		handled := []*PanicError{}
+
+		e := NewTestExecutor(
+			cs,
+			WithMaxAttempts(2),
+			WithPanicHandler(func(pe *PanicError) { handled = append(handled, pe) }),
+		)
+		defer e.Stop()
+
+		var result Try
+		e.Exec(panicFunc("boom"), func(try Try) {
+			assert.Equal(t, len(handled), 2)
+			result = try
+		})
+		assert.Equal(t, e.RunUntilIdle(), 2)
+
+		assert.NonNil(t, result)
+		assert.Equal(t, len(handled), 2)
+		assert.SameInstance(t, result.Error(), handled[1])
+	})
+}
+
+func TestRepanicPanics(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs, WithPanicPolicy(RepanicPanics))
+		defer e.Stop()
+
+		e.Exec(panicFunc("boom"), func(try Try) {
+			assert.Tracing(t).Errorf("unexpected callback: %+v", try)
+		})
+
+		defer func() {
+			assert.Equal(t, recover(), "boom")
+		}()
+
+		e.RunNext()
+		assert.Tracing(t).Errorf("expected panic")
+	})
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package test provides a load and soak test harness for
+github.com/turbinelabs/nonstdlib/executor. It may be used as a
+library, via Harness, or as a command line tool, via BakeTestCLI
+(see the bake-test directory).
+
+A Scenario describes the load: how long to generate jobs, the
+distribution of job arrivals, a weighted mix of job profiles (each
+with its own latency distribution, failure counts, and timeout), and
+SLO assertions. Scenarios may be loaded from JSON files:
+
+	{
+	  "name": "mixed",
+	  "duration": "5m",
+	  "generators": 4,
+	  "arrival": {"distribution": "poisson", "rate": 50},
+	  "jobs": [
+	    {
+	      "name": "fast",
+	      "weight": 9,
+	      "latency": {"distribution": "uniform", "min": "5ms", "max": "20ms"},
+	      "failures": {"min": 0, "max": 1}
+	    },
+	    {
+	      "name": "slow",
+	      "weight": 1,
+	      "latency": {"distribution": "exponential", "min": "50ms", "mean": "200ms", "max": "2s"},
+	      "timeout": "500ms"
+	    }
+	  ],
+	  "slo": {
+	    "min_success_rate": 0.99,
+	    "max_timeout_rate": 0.05,
+	    "latency": {"p50": "50ms", "p99": "1s"}
+	  }
+	}
+
+Results include job counts, latency percentiles, and the outcome of
+each SLO assertion. They can be written as JSON for consumption by
+CI, which can fail the build when Results.Passed is false.
+*/
+package test
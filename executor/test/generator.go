@@ -17,31 +17,38 @@ limitations under the License.
 package test
 
 import (
-	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/turbinelabs/nonstdlib/executor"
-	tbnmath "github.com/turbinelabs/nonstdlib/math"
 )
 
 type generator struct {
-	exec executor.Executor
+	exec     executor.Executor
+	scenario *Scenario
 
-	id          int32
-	rate        float64
-	minDelay    time.Duration
-	maxDelay    time.Duration
-	minFailures int
-	maxFailures int
+	id   int32
+	rate float64
+
+	rng       *rand.Rand
+	intervals intervalFunc
 
 	quitChan chan struct{}
 	doneChan chan struct{}
 }
 
-func (g *generator) init() {
+func (g *generator) init() error {
+	g.rng = rand.New(rand.NewSource(time.Now().UnixNano() + int64(g.id)))
+
+	intervals, err := g.scenario.Arrival.intervals(g.rate, g.rng)
+	if err != nil {
+		return err
+	}
+
+	g.intervals = intervals
 	g.quitChan = make(chan struct{})
 	g.doneChan = make(chan struct{})
+	return nil
 }
 
 func (g *generator) start(r *recorder) {
@@ -53,58 +60,46 @@ func (g *generator) stop() {
 	<-g.doneChan
 }
 
-func (g *generator) mkDelays(rng *rand.Rand, n int) []time.Duration {
-	delays := make([]time.Duration, 0, n)
+func (g *generator) mkJob(jobID int32, r *recorder) *job {
+	profile := g.scenario.chooseProfile(g.rng)
 
-	delayRange := int64(g.maxDelay - g.minDelay)
-	for ; n > 0; n-- {
-		delayNanos := int64(g.minDelay)
-		if delayRange > 0 {
-			delayNanos += rng.Int63n(int64(delayRange + 1))
-		}
-		delays = append(delays, time.Duration(delayNanos))
+	numFailures := profile.Failures.next(g.rng)
+	delays := make([]time.Duration, numFailures+1)
+	for i := range delays {
+		delays[i] = profile.Latency.next(g.rng)
+	}
+
+	return &job{
+		id:          (int64(g.id) << 32) | int64(jobID),
+		profile:     profile.Name,
+		numFailures: numFailures,
+		delays:      delays,
+		timeout:     time.Duration(profile.Timeout),
+		recorder:    r,
 	}
-	return delays
 }
 
 func (g *generator) run(r *recorder) {
 	defer close(g.doneChan)
-	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-	delayGen, err := tbnmath.NewPoissonDistributionWithRand(g.rate, rng)
-	if err != nil {
-		fmt.Printf("generator error: %s\n", err.Error())
-		return
-	}
 	timer := time.NewTimer(time.Hour)
 
 	jobID := int32(0)
 
-	failureRange := g.maxFailures - g.minFailures
-
 	for {
-		delay := delayGen.Next()
-		timer.Reset(delay)
+		timer.Reset(g.intervals())
 
 		select {
 		case <-g.quitChan:
+			timer.Stop()
 			return
 		case <-timer.C:
 			// do a thing
 		}
 
-		numFailures := g.minFailures
-		if failureRange != 0 {
-			numFailures += rng.Intn(failureRange + 1)
-		}
-		job := &job{
-			id:          (int64(g.id) << 32) | int64(jobID),
-			numFailures: numFailures,
-			delays:      g.mkDelays(rng, numFailures+1),
-			recorder:    r,
-		}
+		job := g.mkJob(jobID, r)
 		jobID++
 
-		r.jobIDs <- job.id
+		r.submitted(job)
 
 		g.exec.Exec(job.Go, job.Callback)
 	}
@@ -0,0 +1,137 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/turbinelabs/nonstdlib/executor"
+)
+
+// Harness runs Scenarios against an Executor.
+type Harness struct {
+	// Executor receives the generated jobs. The Harness does not
+	// stop it.
+	Executor executor.Executor
+
+	// Quiesce is how long the Harness waits for activity to stop
+	// after job generation ends. Jobs that have not completed by
+	// then are counted as incomplete. Defaults to one second.
+	Quiesce time.Duration
+
+	// Progress, if non-nil, receives human-readable progress
+	// messages.
+	Progress io.Writer
+}
+
+// Run generates jobs as described by the Scenario, waits for them to
+// complete, and returns Results with the Scenario's SLO checked.
+func (h *Harness) Run(s *Scenario) (*Results, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	quiesce := h.Quiesce
+	if quiesce <= 0 {
+		quiesce = time.Second
+	}
+
+	recorder := newRecorder()
+
+	generators := make([]*generator, s.Generators)
+	for i := range generators {
+		generators[i] = &generator{
+			exec:     h.Executor,
+			scenario: s,
+			id:       int32(i),
+			rate:     s.Arrival.Rate / float64(s.Generators),
+		}
+
+		if err := generators[i].init(); err != nil {
+			return nil, err
+		}
+	}
+
+	start := time.Now()
+	for _, g := range generators {
+		g.start(recorder)
+	}
+
+	h.wait(time.Duration(s.Duration))
+
+	h.progressf("Stopping generator(s)...\n")
+	for _, g := range generators {
+		g.stop()
+	}
+
+	h.progressf("Waiting for jobs to complete...\n")
+	recorder.quiesce(quiesce)
+
+	if debug {
+		recorder.dump()
+	}
+
+	return recorder.results(s, time.Since(start)), nil
+}
+
+// wait sleeps for the given duration, periodically reporting
+// progress.
+func (h *Harness) wait(d time.Duration) {
+	if h.Progress == nil {
+		time.Sleep(d)
+		return
+	}
+
+	tickerStep := 1 * time.Second
+	if d > 10*time.Second {
+		tickerStep = 5 * time.Second
+	}
+	if d > 2*time.Minute {
+		tickerStep = 30 * time.Second
+	}
+	if d > 10*time.Minute {
+		tickerStep = 5 * time.Minute
+	}
+	if d > 1*time.Hour {
+		tickerStep = 15 * time.Minute
+	}
+
+	ticker := time.NewTicker(tickerStep)
+	defer ticker.Stop()
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	left := d
+	for {
+		select {
+		case <-ticker.C:
+			left -= tickerStep
+			h.progressf("(%s remaining)\n", left.String())
+		case <-timer.C:
+			return
+		}
+	}
+}
+
+func (h *Harness) progressf(f string, args ...interface{}) {
+	if h.Progress != nil {
+		fmt.Fprintf(h.Progress, f, args...)
+	}
+}
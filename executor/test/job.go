@@ -33,33 +33,44 @@ var (
 
 type job struct {
 	id          int64
+	profile     string
 	exec        int
+	failed      int
+	succeeded   bool
 	numFailures int
 	delays      []time.Duration
+	timeout     time.Duration
+	submitted   time.Time
 	recorder    *recorder
 }
 
 func (j *job) String() string {
-	return fmt.Sprintf("job %x: %d (%v)", j.id, j.numFailures, j.delays)
+	return fmt.Sprintf("job %x (%s): %d (%v)", j.id, j.profile, j.numFailures, j.delays)
 }
 
+// Go makes an attempt. Attempts that are canceled or time out do not
+// count towards the job's planned failures.
 func (j *job) Go(ctxt context.Context) (interface{}, error) {
-	dprintf("\tjob %x: running (%d of %d)\n", j.id, j.exec+1, j.numFailures+1)
-	attemptResult := &attempt{job: j}
-	defer func() {
-		j.recorder.attempts <- attemptResult
-	}()
+	dprintf("\tjob %x: running (%d of %d)\n", j.id, j.failed+1, j.numFailures+1)
+	defer j.recorder.attempted()
+	j.exec++
 
-	if j.exec >= len(j.delays) {
+	if j.succeeded || j.failed >= len(j.delays) {
 		dprintf("\tjob %x: too many\n", j.id)
 		return nil, errJobExecutedTooManyTimes
 	}
 
-	delay := j.delays[j.exec]
-	j.exec++
+	if j.timeout > 0 {
+		var cancel context.CancelFunc
+		ctxt, cancel = context.WithTimeout(ctxt, j.timeout)
+		defer cancel()
+	}
+
+	delay := j.delays[j.failed]
 	if delay > 0 {
 		dprintf("\tjob %x: delay %s\n", j.id, delay.String())
 		timer := time.NewTimer(delay)
+		defer timer.Stop()
 		select {
 		case <-ctxt.Done():
 			return nil, ctxt.Err()
@@ -68,12 +79,13 @@ func (j *job) Go(ctxt context.Context) (interface{}, error) {
 		}
 	}
 
-	if j.exec > j.numFailures {
+	if j.failed >= j.numFailures {
 		dprintf("\tjob %x: succeed\n", j.id)
-		attemptResult.success = true
+		j.succeeded = true
 		return j.id, nil
 	}
 
+	j.failed++
 	dprintf("\tjob %x: fail\n", j.id)
 	return nil, errJobFailed
 }
@@ -85,7 +97,7 @@ func (j *job) Callback(try executor.Try) {
 		err := try.Error()
 		if err == errJobFailed {
 			result = failureResult
-		} else if strings.Contains(err.Error(), "timeout") {
+		} else if err == context.DeadlineExceeded || strings.Contains(err.Error(), "timeout") {
 			result = timeoutResult
 		}
 	} else {
@@ -100,5 +112,5 @@ func (j *job) Callback(try executor.Try) {
 		}
 	}
 
-	j.recorder.callbacks <- &callback{jobID: j.id, result: result}
+	j.recorder.completed(j, result, time.Since(j.submitted))
 }
@@ -19,6 +19,7 @@ package test
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -36,24 +37,31 @@ const (
 
 var (
 	debug = false
+
+	// out receives progress, debug, warning, and error output. It
+	// is redirected to stderr when JSON results are written to
+	// stdout.
+	out io.Writer = os.Stdout
 )
 
 func dprintf(f string, args ...interface{}) {
 	if debug {
-		fmt.Printf(f, args...)
+		fmt.Fprintf(out, f, args...)
 	}
 }
 
 func dprintln(args ...interface{}) {
 	if debug {
-		fmt.Println(args...)
+		fmt.Fprintln(out, args...)
 	}
 }
 
 // BakeTestCLI configures and parses command line flags and runs a
-// bake test of the nonstdlib/executor.
+// bake test of the nonstdlib/executor. The process exits with a
+// non-zero status if the scenario cannot be run or if any SLO
+// assertion fails.
 func BakeTestCLI() {
-	ff, err := newFromFlags()
+	ff, err := newFromFlags(os.Args[1:])
 	if err != nil {
 		if err == flag.ErrHelp {
 			os.Exit(0)
@@ -61,103 +69,135 @@ func BakeTestCLI() {
 		os.Exit(1)
 	}
 
+	// With JSON on stdout, all other output goes to stderr.
+	if ff.config.output == "-" {
+		out = os.Stderr
+	}
+
 	if err := ff.Validate(); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 
 	config := ff.Make()
 	exec := config.exec
 	debug = config.debug
-	defer exec.Stop()
-
-	recorder := newRecorder()
-	recorder.start()
-
-	generators := make([]*generator, config.numJobGenerators)
-	for i := range generators {
-		generators[i] = &generator{
-			exec:        exec,
-			id:          int32(i),
-			rate:        config.rate / float64(config.numJobGenerators),
-			minDelay:    config.minDelay,
-			maxDelay:    config.maxDelay,
-			minFailures: config.minFailures,
-			maxFailures: config.maxFailures,
-		}
 
-		generators[i].init()
-		generators[i].start(recorder)
+	harness := &Harness{
+		Executor: exec,
+		Quiesce:  config.quiesce(),
+		Progress: out,
 	}
 
-	tickerStep := 1 * time.Second
-	if config.stopAfter > 10*time.Second {
-		tickerStep = 5 * time.Second
+	results, err := harness.Run(config.scenario)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	if config.stopAfter > 2*time.Minute {
-		tickerStep = 30 * time.Second
+
+	fmt.Fprintln(out, "Stopping executor...")
+	exec.Stop()
+
+	results.WriteText(out)
+
+	if err := writeResults(config.output, results); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
 	}
-	if config.stopAfter > 10*time.Minute {
-		tickerStep = 5 * time.Minute
+
+	if !results.Passed {
+		fmt.Fprintln(os.Stderr, "SLO assertions failed")
+		os.Exit(1)
 	}
-	if config.stopAfter > 1*time.Hour {
-		tickerStep = 15 * time.Minute
+}
+
+func writeResults(output string, results *Results) error {
+	switch output {
+	case "":
+		return nil
+	case "-":
+		return results.WriteJSON(os.Stdout)
 	}
-	ticker := time.NewTicker(tickerStep)
-	go func() {
-		left := config.stopAfter
-		for range ticker.C {
-			left -= tickerStep
-			fmt.Printf("(%s remaining)\n", left.String())
-		}
-	}()
-	time.Sleep(config.stopAfter)
-	ticker.Stop()
 
-	fmt.Println("Stopping generator(s)...")
-	for _, g := range generators {
-		g.stop()
+	f, err := os.Create(output)
+	if err != nil {
+		return err
 	}
 
-	fmt.Println("Stopping recorder...")
-	delay := config.timeout
-	if delay == 0 {
-		delay = config.attemptTimeout * time.Duration(config.maxAttempts)
-		if delay == 0 {
-			delay = config.maxDelay * time.Duration(config.maxFailures+1)
-		}
+	if err := results.WriteJSON(f); err != nil {
+		f.Close()
+		return err
 	}
-	recorder.stop(delay)
 
-	fmt.Println("Stopping executor...")
-	exec.Stop()
+	return f.Close()
 }
 
 type bakeTestConfig struct {
-	debug bool
+	debug  bool
+	output string
 
-	numJobGenerators int
-	rate             float64
-	stopAfter        time.Duration
-	minDelay         time.Duration
-	maxDelay         time.Duration
-	minFailures      int
-	maxFailures      int
+	scenario *Scenario
 
 	exec           executor.Executor
 	attemptTimeout time.Duration
 	timeout        time.Duration
+	retryDelay     time.Duration
 	maxAttempts    int
 }
 
+// quiesce returns how long to wait without activity for jobs to
+// complete after generation stops. It is never less than the longest
+// retry delay.
+func (c *bakeTestConfig) quiesce() time.Duration {
+	d := c.timeout
+	if d <= 0 {
+		d = c.attemptTimeout * time.Duration(c.maxAttempts)
+	}
+	if d <= 0 {
+		d = c.maxJobDuration()
+	}
+
+	if d < c.retryDelay {
+		d = c.retryDelay
+	}
+
+	return d
+}
+
+// maxJobDuration estimates the longest time a job spends executing
+// its attempts.
+func (c *bakeTestConfig) maxJobDuration() time.Duration {
+	var d time.Duration
+	for _, p := range c.scenario.Jobs {
+		jobMax := time.Duration(p.Latency.Max)
+		if jobMax < time.Duration(p.Latency.Min) {
+			jobMax = time.Duration(p.Latency.Min)
+		}
+		if jobMax*time.Duration(p.Failures.Max+1) > d {
+			d = jobMax * time.Duration(p.Failures.Max+1)
+		}
+	}
+
+	return d
+}
+
 type fromFlags struct {
 	flagSet       *flag.FlagSet
 	execFromFlags executor.FromFlags
 
+	scenarioFile     string
+	numJobGenerators int
+	rate             float64
+	stopAfter        time.Duration
+	minDelay         time.Duration
+	maxDelay         time.Duration
+	minFailures      int
+	maxFailures      int
+
 	config bakeTestConfig
 }
 
-func newFromFlags() (*fromFlags, error) {
+func newFromFlags(args []string) (*fromFlags, error) {
 	ff := &fromFlags{
 		flagSet: flag.NewFlagSet("bake-test", flag.ContinueOnError),
 	}
@@ -170,44 +210,58 @@ func newFromFlags() (*fromFlags, error) {
 		"Enable debug output",
 	)
 
+	tbnFlagSet.StringVar(
+		&ff.scenarioFile,
+		"scenario",
+		"",
+		"Path to a JSON scenario file. If set, the job generation flags are ignored.",
+	)
+
+	tbnFlagSet.StringVar(
+		&ff.config.output,
+		"output",
+		"",
+		`Path to write JSON results. Use "-" for stdout.`,
+	)
+
 	tbnFlagSet.IntVar(
-		&ff.config.numJobGenerators,
+		&ff.numJobGenerators,
 		"num-gen",
 		4,
 		"Number of job generating goroutines.",
 	)
 	tbnFlagSet.Float64Var(
-		&ff.config.rate,
+		&ff.rate,
 		"rate",
 		1.0,
 		"Number of jobs/second",
 	)
 	tbnFlagSet.DurationVar(
-		&ff.config.stopAfter,
+		&ff.stopAfter,
 		"stop-after",
 		time.Minute,
 		"Sets the test run time.",
 	)
 	tbnFlagSet.DurationVar(
-		&ff.config.minDelay,
+		&ff.minDelay,
 		"min-delay",
 		defaultMinDelay,
 		"Minimum attempt delay.",
 	)
 	tbnFlagSet.DurationVar(
-		&ff.config.maxDelay,
+		&ff.maxDelay,
 		"max-delay",
 		defaultMaxDelay,
 		"Maximum attempt delay.",
 	)
 	tbnFlagSet.IntVar(
-		&ff.config.minFailures,
+		&ff.minFailures,
 		"min-failures",
 		defaultMinFailures,
 		"Minimum attempt failures (per job).",
 	)
 	tbnFlagSet.IntVar(
-		&ff.config.maxFailures,
+		&ff.maxFailures,
 		"max-failures",
 		defaultMaxFailures,
 		"Maximum attempt failures (per job).",
@@ -215,13 +269,50 @@ func newFromFlags() (*fromFlags, error) {
 
 	ff.execFromFlags = executor.NewFromFlags(tbnFlagSet.Scope("exec", "Executor"))
 
-	if err := ff.flagSet.Parse(os.Args[1:]); err != nil {
+	if err := ff.flagSet.Parse(args); err != nil {
 		return nil, err
 	}
 
 	return ff, nil
 }
 
+// scenario returns the Scenario named by the scenario flag, or one
+// constructed from the job generation flags.
+func (ff *fromFlags) scenario() (*Scenario, error) {
+	if ff.scenarioFile != "" {
+		return LoadScenario(ff.scenarioFile)
+	}
+
+	s := &Scenario{
+		Name:       "flags",
+		Duration:   Duration(ff.stopAfter),
+		Generators: ff.numJobGenerators,
+		Arrival: Arrival{
+			Distribution: PoissonDistribution,
+			Rate:         ff.rate,
+		},
+		Jobs: []JobProfile{
+			{
+				Latency: Latency{
+					Distribution: UniformDistribution,
+					Min:          Duration(ff.minDelay),
+					Max:          Duration(ff.maxDelay),
+				},
+				Failures: Failures{
+					Min: ff.minFailures,
+					Max: ff.maxFailures,
+				},
+			},
+		},
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
 func (ff *fromFlags) Validate() error {
 	durationFlags := map[string]time.Duration{}
 	intFlags := map[string]int{}
@@ -246,36 +337,54 @@ func (ff *fromFlags) Validate() error {
 	ff.config.timeout = durationFlags["exec.timeout"]
 	ff.config.maxAttempts = intFlags["exec.max-attempts"]
 
-	if ff.config.maxDelay < ff.config.minDelay {
-		ff.config.minDelay, ff.config.maxDelay = ff.config.maxDelay, ff.config.minDelay
+	ff.config.retryDelay = durationFlags["exec.delay"]
+	if ff.config.retryDelay < durationFlags["exec.max-delay"] {
+		ff.config.retryDelay = durationFlags["exec.max-delay"]
 	}
 
-	if ff.config.attemptTimeout > 0 && ff.config.minDelay > ff.config.attemptTimeout {
-		fmt.Printf(
-			"WARNING: all jobs will timeout (min delay %s > attempt timeout %s)\n",
-			ff.config.minDelay,
-			ff.config.attemptTimeout,
-		)
+	s, err := ff.scenario()
+	if err != nil {
+		return err
 	}
+	ff.config.scenario = s
 
-	if ff.config.timeout > 0 && ff.config.minDelay > ff.config.timeout {
-		fmt.Printf(
-			"WARNING: all jobs will timeout (min delay %s > global timeout %s)\n",
-			ff.config.minDelay,
-			ff.config.timeout,
-		)
-	}
+	for _, p := range s.Jobs {
+		name := p.Name
+		if name == "" {
+			name = "jobs"
+		}
 
-	if ff.config.maxFailures < ff.config.minFailures {
-		ff.config.minFailures, ff.config.maxFailures = ff.config.maxFailures, ff.config.minFailures
-	}
+		minDelay := time.Duration(p.Latency.Min)
+
+		if ff.config.attemptTimeout > 0 && minDelay > ff.config.attemptTimeout {
+			fmt.Fprintf(
+				out,
+				"WARNING: all %s will timeout (min delay %s > attempt timeout %s)\n",
+				name,
+				minDelay,
+				ff.config.attemptTimeout,
+			)
+		}
+
+		if ff.config.timeout > 0 && minDelay > ff.config.timeout {
+			fmt.Fprintf(
+				out,
+				"WARNING: all %s will timeout (min delay %s > global timeout %s)\n",
+				name,
+				minDelay,
+				ff.config.timeout,
+			)
+		}
 
-	if ff.config.minFailures > ff.config.maxAttempts {
-		fmt.Printf(
-			"WARNING: all jobs will fail (min failures %d > max attempts %d)\n",
-			ff.config.minFailures,
-			ff.config.maxAttempts,
-		)
+		if p.Failures.Min > ff.config.maxAttempts {
+			fmt.Fprintf(
+				out,
+				"WARNING: all %s will fail (min failures %d > max attempts %d)\n",
+				name,
+				p.Failures.Min,
+				ff.config.maxAttempts,
+			)
+		}
 	}
 
 	return nil
@@ -19,6 +19,7 @@ package test
 import (
 	"fmt"
 	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -56,31 +57,19 @@ func (r resultType) String() string {
 	panic(fmt.Sprintf("missing resultType (%d)", r))
 }
 
-type attempt struct {
-	*job
-	success bool
-}
-
-type callback struct {
-	jobID  int64
-	result resultType
-}
-
+// recorder tracks submitted jobs, attempts and callbacks. It is
+// safe for concurrent use.
 type recorder struct {
-	jobIDs    chan int64
-	attempts  chan *attempt
-	callbacks chan *callback
+	updates int64
 
-	quitChan chan struct{}
-	doneChan chan struct{}
-	updates  int64
+	mutex         sync.Mutex
+	inflightJobs  map[int64]*job
+	completedJobs map[int64]struct{}
+	latencies     []time.Duration
 
-	inflightJobs      map[int64]struct{}
-	completedAttempts map[int64][]*attempt
-	completedJobs     map[int64]*callback
-
-	success       int64
+	jobs          int64
 	totalAttempts int64
+	success       int64
 	failures      int64
 	timeouts      int64
 	invalid       int64
@@ -88,25 +77,17 @@ type recorder struct {
 
 func newRecorder() *recorder {
 	return &recorder{
-		jobIDs:            make(chan int64, 100),
-		attempts:          make(chan *attempt, 100),
-		callbacks:         make(chan *callback, 100),
-		quitChan:          make(chan struct{}),
-		doneChan:          make(chan struct{}),
-		inflightJobs:      map[int64]struct{}{},
-		completedAttempts: map[int64][]*attempt{},
-		completedJobs:     map[int64]*callback{},
+		inflightJobs:  map[int64]*job{},
+		completedJobs: map[int64]struct{}{},
 	}
 }
 
-func (r *recorder) start() {
-	go r.run()
-}
-
-func (r *recorder) stop(d time.Duration) {
+// quiesce blocks until no updates have been recorded for the given
+// duration.
+func (r *recorder) quiesce(d time.Duration) {
 	timer := time.NewTimer(d)
 	for {
-		fmt.Println("waiting for quiesence")
+		dprintln("waiting for quiesence")
 		n := atomic.LoadInt64(&r.updates)
 		<-timer.C
 		afterN := atomic.LoadInt64(&r.updates)
@@ -115,168 +96,100 @@ func (r *recorder) stop(d time.Duration) {
 		}
 		timer.Reset(d)
 	}
-
-	close(r.quitChan)
-	<-r.doneChan
 }
 
-func (r *recorder) run() {
-	defer close(r.doneChan)
-
-LOOP:
-	for {
-		var jobID int64
-		select {
-		case <-r.quitChan:
-			break LOOP
-
-		case callback := <-r.callbacks:
-			jobID = callback.jobID
-			if _, ok := r.completedJobs[jobID]; ok {
-				fmt.Printf("ERROR: job completed more than once %d", jobID)
-			}
-			r.completedJobs[jobID] = callback
-
-		case a := <-r.attempts:
-			jobID = a.job.id
-			if attempts, ok := r.completedAttempts[jobID]; ok {
-				r.completedAttempts[jobID] = append(attempts, a)
-			} else {
-				attempts := make([]*attempt, 1, a.job.numFailures+1)
-				attempts[0] = a
-				r.completedAttempts[jobID] = attempts
-			}
-
-		case id := <-r.jobIDs:
-			jobID = id
-			if _, ok := r.inflightJobs[jobID]; ok {
-				fmt.Printf("ERROR: restarted job %d", jobID)
-			}
-			r.inflightJobs[id] = struct{}{}
-		}
+func (r *recorder) submitted(j *job) {
+	atomic.AddInt64(&r.updates, 1)
+	j.submitted = time.Now()
 
-		atomic.AddInt64(&r.updates, 1)
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
-		r.checkJob(jobID)
+	if _, ok := r.inflightJobs[j.id]; ok {
+		fmt.Fprintf(out, "ERROR: restarted job %x\n", j.id)
 	}
+	r.inflightJobs[j.id] = j
+	r.jobs++
+}
 
-	for jobID := range r.inflightJobs {
-		r.checkJob(jobID)
-	}
-	r.dump()
+func (r *recorder) attempted() {
+	atomic.AddInt64(&r.updates, 1)
+	atomic.AddInt64(&r.totalAttempts, 1)
 }
 
-func (r *recorder) checkJob(jobID int64) {
-	_, isInflight := r.inflightJobs[jobID]
-	attempts, haveAttempts := r.completedAttempts[jobID]
-	callback, hasCallback := r.completedJobs[jobID]
-
-	dprintf(
-		"\t\tjob %x: inflight? %t hasAttempts? %t, completed? %t\n",
-		jobID,
-		isInflight,
-		haveAttempts,
-		hasCallback,
-	)
-
-	if !isInflight || !haveAttempts || !hasCallback {
-		return
-	}
+func (r *recorder) completed(j *job, result resultType, latency time.Duration) {
+	atomic.AddInt64(&r.updates, 1)
 
-	//	fmt.Println(attempts)
-	expectedAttempts := attempts[0].job.numFailures + 1
-	if len(attempts) != expectedAttempts {
-		dprintf(
-			"\t\tjob %x: expected %d attempts, saw %d\n",
-			jobID,
-			expectedAttempts,
-			len(attempts),
-		)
-		return
-	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
 
-	r.totalAttempts += int64(len(attempts))
+	if _, ok := r.completedJobs[j.id]; ok {
+		result = tooManyCallsResult
+	} else {
+		r.completedJobs[j.id] = struct{}{}
+		delete(r.inflightJobs, j.id)
+		r.latencies = append(r.latencies, latency)
+	}
 
-	switch callback.result {
+	switch result {
 	case successResult:
 		r.success++
 
 	case failureResult:
-		// TODO: should we have failed?
 		r.failures++
 
 	case timeoutResult:
-		// TODO: should we have timed out
 		r.timeouts++
 
 	case tooManyCallsResult, badResultType, wrongJobResult, unknownResult:
 		r.invalid++
-		fmt.Printf("ERROR: %s\n", callback.result.String())
+		fmt.Fprintf(out, "ERROR: job %x: %s\n", j.id, result.String())
 	}
 
-	dprintf("\t\tjob %x: done\n", jobID)
-
-	delete(r.inflightJobs, jobID)
-	delete(r.completedAttempts, jobID)
-	delete(r.completedJobs, jobID)
+	dprintf("\t\tjob %x: done (%s, %s)\n", j.id, result.String(), latency)
 }
 
-func (r *recorder) dump() {
-	inflight := make([]int64, 0, len(r.inflightJobs))
-	for jobID := range r.inflightJobs {
-		inflight = append(inflight, jobID)
-	}
-	sort.Slice(inflight, func(i, j int) bool { return inflight[i] < inflight[j] })
-	if len(inflight) > 0 {
-		fmt.Println("\nInflight jobs:")
-		for _, jobID := range inflight {
-			fmt.Printf("  %d\n", jobID)
-		}
+// results produces Results for the Scenario and checks its SLO.
+func (r *recorder) results(s *Scenario, elapsed time.Duration) *Results {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	results := &Results{
+		Scenario:   s.Name,
+		Duration:   Duration(elapsed),
+		Jobs:       r.jobs,
+		Attempts:   atomic.LoadInt64(&r.totalAttempts),
+		Succeeded:  r.success,
+		Failed:     r.failures,
+		TimedOut:   r.timeouts,
+		Invalid:    r.invalid,
+		Incomplete: int64(len(r.inflightJobs)),
+		Latency:    summarizeLatencies(r.latencies),
 	}
 
-	attempted := []struct {
-		jobID       int64
-		numAttempts int
-	}{}
-	for jobID, attempts := range r.completedAttempts {
-		attempted = append(
-			attempted,
-			struct {
-				jobID       int64
-				numAttempts int
-			}{
-				jobID:       jobID,
-				numAttempts: len(attempts),
-			},
-		)
-	}
-	sort.Slice(
-		attempted,
-		func(i, j int) bool { return attempted[i].jobID < attempted[j].jobID },
-	)
-	if len(attempted) > 0 {
-		fmt.Println("\nAttempted jobs:")
-		for _, a := range attempted {
-			fmt.Printf("  %d (%d times)", a.jobID, a.numAttempts)
-		}
+	if completed := len(r.latencies); completed > 0 {
+		results.SuccessRate = float64(r.success) / float64(completed)
+		results.TimeoutRate = float64(r.timeouts) / float64(completed)
 	}
 
-	completed := make([]int64, 0, len(r.completedJobs))
-	for jobID := range r.completedJobs {
-		completed = append(completed, jobID)
+	s.SLO.check(results, r.latencies)
+
+	return results
+}
+
+func (r *recorder) dump() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	inflight := make([]*job, 0, len(r.inflightJobs))
+	for _, j := range r.inflightJobs {
+		inflight = append(inflight, j)
 	}
-	sort.Slice(completed, func(i, j int) bool { return completed[i] < completed[j] })
-	if len(completed) > 0 {
-		fmt.Println("\nCompleted jobs:")
-		for _, jobID := range completed {
-			fmt.Printf("  %d", jobID)
+	sort.Slice(inflight, func(i, j int) bool { return inflight[i].id < inflight[j].id })
+	if len(inflight) > 0 {
+		fmt.Fprintln(out, "\nInflight jobs:")
+		for _, j := range inflight {
+			fmt.Fprintf(out, "  %s (%d attempts)\n", j.String(), j.exec)
 		}
 	}
-
-	fmt.Println("\nResults:")
-	fmt.Printf("  Successful runs: %d\n", r.success)
-	fmt.Printf("  Total attempts: %d\n", r.totalAttempts)
-	fmt.Printf("  Failed jobs: %d\n", r.failures)
-	fmt.Printf("  Timed out jobs: %d\n", r.timeouts)
-	fmt.Printf("  Invalid results: %d\n", r.invalid)
 }
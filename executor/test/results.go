@@ -0,0 +1,262 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Results summarizes a bake test run.
+type Results struct {
+	Scenario string   `json:"scenario"`
+	Duration Duration `json:"duration"`
+
+	// Jobs is the number of jobs submitted.
+	Jobs int64 `json:"jobs"`
+
+	// Attempts is the number of attempts observed.
+	Attempts int64 `json:"attempts"`
+
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	TimedOut  int64 `json:"timed_out"`
+
+	// Invalid counts jobs with unexpected results, such as the
+	// result of a different job or more than one callback.
+	Invalid int64 `json:"invalid"`
+
+	// Incomplete counts jobs that never invoked their callback.
+	Incomplete int64 `json:"incomplete"`
+
+	// SuccessRate and TimeoutRate are fractions of completed
+	// jobs.
+	SuccessRate float64 `json:"success_rate"`
+	TimeoutRate float64 `json:"timeout_rate"`
+
+	// Latency summarizes the time between submitting each
+	// completed job and its callback.
+	Latency LatencySummary `json:"latency"`
+
+	// SLO contains the result of each SLO assertion.
+	SLO []SLOCheck `json:"slo"`
+
+	// Passed is true if every SLO assertion passed.
+	Passed bool `json:"passed"`
+}
+
+// LatencySummary contains latency statistics.
+type LatencySummary struct {
+	Min  Duration `json:"min"`
+	Mean Duration `json:"mean"`
+	P50  Duration `json:"p50"`
+	P90  Duration `json:"p90"`
+	P95  Duration `json:"p95"`
+	P99  Duration `json:"p99"`
+	Max  Duration `json:"max"`
+}
+
+// SLOCheck is the result of a single SLO assertion.
+type SLOCheck struct {
+	Name   string `json:"name"`
+	Limit  string `json:"limit"`
+	Actual string `json:"actual"`
+	Passed bool   `json:"passed"`
+}
+
+// WriteJSON writes the Results to w as indented JSON.
+func (r *Results) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// WriteText writes a human-readable summary of the Results to w.
+func (r *Results) WriteText(w io.Writer) {
+	fmt.Fprintln(w, "\nResults:")
+	fmt.Fprintf(w, "  Jobs submitted: %d\n", r.Jobs)
+	fmt.Fprintf(w, "  Successful runs: %d\n", r.Succeeded)
+	fmt.Fprintf(w, "  Total attempts: %d\n", r.Attempts)
+	fmt.Fprintf(w, "  Failed jobs: %d\n", r.Failed)
+	fmt.Fprintf(w, "  Timed out jobs: %d\n", r.TimedOut)
+	fmt.Fprintf(w, "  Invalid results: %d\n", r.Invalid)
+	fmt.Fprintf(w, "  Incomplete jobs: %d\n", r.Incomplete)
+
+	l := r.Latency
+	fmt.Fprintf(
+		w,
+		"  Latency: min %s, mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		time.Duration(l.Min),
+		time.Duration(l.Mean),
+		time.Duration(l.P50),
+		time.Duration(l.P90),
+		time.Duration(l.P95),
+		time.Duration(l.P99),
+		time.Duration(l.Max),
+	)
+
+	if len(r.SLO) > 0 {
+		fmt.Fprintln(w, "\nSLO:")
+		for _, c := range r.SLO {
+			status := "ok"
+			if !c.Passed {
+				status = "FAILED"
+			}
+			fmt.Fprintf(w, "  %s: %s (limit %s) %s\n", c.Name, c.Actual, c.Limit, status)
+		}
+	}
+}
+
+// parsePercentile converts "max" or a percentile such as "p99" or
+// "p99.9" into a fraction in (0, 1].
+func parsePercentile(name string) (float64, error) {
+	if name == "max" {
+		return 1.0, nil
+	}
+
+	if !strings.HasPrefix(name, "p") {
+		return 0, errors.New(`must be "max" or of the form "p99"`)
+	}
+
+	p, err := strconv.ParseFloat(name[1:], 64)
+	if err != nil || p <= 0 || p > 100 {
+		return 0, errors.New("percentile must be in the range (0, 100]")
+	}
+
+	return p / 100.0, nil
+}
+
+// percentile returns the nearest-rank percentile of the sorted
+// latencies. The fraction p must be in (0, 1].
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+
+	return sorted[rank]
+}
+
+func summarizeLatencies(latencies []time.Duration) LatencySummary {
+	if len(latencies) == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := make([]time.Duration, len(latencies))
+	copy(sorted, latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+
+	return LatencySummary{
+		Min:  Duration(sorted[0]),
+		Mean: Duration(total / time.Duration(len(sorted))),
+		P50:  Duration(percentile(sorted, 0.50)),
+		P90:  Duration(percentile(sorted, 0.90)),
+		P95:  Duration(percentile(sorted, 0.95)),
+		P99:  Duration(percentile(sorted, 0.99)),
+		Max:  Duration(sorted[len(sorted)-1]),
+	}
+}
+
+// check evaluates the SLO against the Results and the latencies of
+// completed jobs, recording each assertion in the Results.
+func (slo SLO) check(r *Results, latencies []time.Duration) {
+	add := func(name, limit, actual string, passed bool) {
+		r.SLO = append(r.SLO, SLOCheck{name, limit, actual, passed})
+	}
+
+	add("invalid", "0", strconv.FormatInt(r.Invalid, 10), r.Invalid == 0)
+
+	if slo.MinSuccessRate > 0 {
+		add(
+			"success_rate",
+			formatRate(slo.MinSuccessRate),
+			formatRate(r.SuccessRate),
+			r.SuccessRate >= slo.MinSuccessRate,
+		)
+	}
+
+	if slo.MaxTimeoutRate != nil {
+		add(
+			"timeout_rate",
+			formatRate(*slo.MaxTimeoutRate),
+			formatRate(r.TimeoutRate),
+			r.TimeoutRate <= *slo.MaxTimeoutRate,
+		)
+	}
+
+	if slo.MaxIncomplete != nil {
+		add(
+			"incomplete",
+			strconv.FormatInt(*slo.MaxIncomplete, 10),
+			strconv.FormatInt(r.Incomplete, 10),
+			r.Incomplete <= *slo.MaxIncomplete,
+		)
+	}
+
+	if len(slo.Latency) > 0 {
+		sorted := make([]time.Duration, len(latencies))
+		copy(sorted, latencies)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		names := make([]string, 0, len(slo.Latency))
+		for name := range slo.Latency {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			limit := time.Duration(slo.Latency[name])
+			p, err := parsePercentile(name)
+			if err != nil {
+				add("latency_"+name, limit.String(), err.Error(), false)
+				continue
+			}
+
+			actual := percentile(sorted, p)
+			add("latency_"+name, limit.String(), actual.String(), actual <= limit)
+		}
+	}
+
+	r.Passed = true
+	for _, c := range r.SLO {
+		r.Passed = r.Passed && c.Passed
+	}
+}
+
+func formatRate(f float64) string {
+	return strconv.FormatFloat(f, 'f', 4, 64)
+}
@@ -0,0 +1,366 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	tbnmath "github.com/turbinelabs/nonstdlib/math"
+)
+
+const (
+	// PoissonDistribution produces exponentially distributed
+	// intervals between arrivals.
+	PoissonDistribution = "poisson"
+
+	// ConstantDistribution produces a fixed interval or latency.
+	ConstantDistribution = "constant"
+
+	// UniformDistribution produces intervals or latencies chosen
+	// uniformly from a range.
+	UniformDistribution = "uniform"
+
+	// ExponentialDistribution produces exponentially distributed
+	// latencies.
+	ExponentialDistribution = "exponential"
+)
+
+// Duration is a time.Duration that is encoded in JSON as a string
+// accepted by time.ParseDuration.
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string: %s", string(b))
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// Scenario describes the load applied to an Executor during a bake
+// test.
+type Scenario struct {
+	// Name identifies the Scenario in Results.
+	Name string `json:"name"`
+
+	// Duration is how long jobs are generated.
+	Duration Duration `json:"duration"`
+
+	// Generators is the number of goroutines generating jobs.
+	// The arrival rate is divided evenly between them.
+	Generators int `json:"generators"`
+
+	// Arrival controls when jobs are submitted.
+	Arrival Arrival `json:"arrival"`
+
+	// Jobs is a weighted mix of job profiles. Each generated job
+	// chooses a profile at random, according to weight.
+	Jobs []JobProfile `json:"jobs"`
+
+	// SLO contains assertions checked against the Results.
+	SLO SLO `json:"slo"`
+}
+
+// Arrival describes the distribution of job arrivals.
+type Arrival struct {
+	// Distribution is one of "poisson" (the default), "constant",
+	// or "uniform".
+	Distribution string `json:"distribution"`
+
+	// Rate is the average number of jobs per second.
+	Rate float64 `json:"rate"`
+}
+
+// JobProfile describes the behavior of a class of jobs.
+type JobProfile struct {
+	// Name identifies the profile in debug output.
+	Name string `json:"name"`
+
+	// Weight is the relative frequency of this profile. Values
+	// less than or equal to zero are treated as 1.
+	Weight float64 `json:"weight"`
+
+	// Latency controls how long each attempt takes.
+	Latency Latency `json:"latency"`
+
+	// Failures controls how many attempts fail before the job
+	// succeeds.
+	Failures Failures `json:"failures"`
+
+	// Timeout, if positive, bounds each attempt from within the
+	// job itself, independent of any Executor timeouts.
+	Timeout Duration `json:"timeout"`
+}
+
+// Latency describes the distribution of attempt latencies.
+type Latency struct {
+	// Distribution is one of "uniform" (the default), "constant",
+	// or "exponential".
+	Distribution string `json:"distribution"`
+
+	// Min is the minimum latency. It is the latency of constant
+	// distributions.
+	Min Duration `json:"min"`
+
+	// Max is the maximum latency. For exponential distributions,
+	// a zero Max means latencies are not capped.
+	Max Duration `json:"max"`
+
+	// Mean is the mean latency above Min for exponential
+	// distributions.
+	Mean Duration `json:"mean"`
+}
+
+// Failures describes how many attempts of a job fail before it
+// succeeds. The number is chosen uniformly from [Min, Max].
+type Failures struct {
+	Min int `json:"min"`
+	Max int `json:"max"`
+}
+
+// SLO contains assertions about Results. Zero values disable the
+// corresponding assertion, except that invalid results always fail.
+type SLO struct {
+	// MinSuccessRate is the minimum fraction of completed jobs
+	// that must succeed.
+	MinSuccessRate float64 `json:"min_success_rate"`
+
+	// MaxTimeoutRate is the maximum fraction of completed jobs
+	// that may time out. Nil disables the check.
+	MaxTimeoutRate *float64 `json:"max_timeout_rate"`
+
+	// MaxIncomplete is the maximum number of jobs that may not
+	// complete before the test ends. Nil disables the check.
+	MaxIncomplete *int64 `json:"max_incomplete"`
+
+	// Latency maps a percentile ("p50", "p99", "p99.9") or "max"
+	// to the maximum allowed job latency.
+	Latency map[string]Duration `json:"latency"`
+}
+
+// LoadScenario reads a JSON-encoded Scenario from the named file.
+func LoadScenario(path string) (*Scenario, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	s, err := ParseScenario(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+
+	return s, nil
+}
+
+// ParseScenario decodes a JSON-encoded Scenario and validates it.
+// Unknown fields are rejected.
+func ParseScenario(r io.Reader) (*Scenario, error) {
+	s := &Scenario{}
+
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(s); err != nil {
+		return nil, err
+	}
+
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate fills in defaults and checks that the Scenario is
+// well-formed.
+func (s *Scenario) Validate() error {
+	errs := []string{}
+	addErr := func(f string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(f, args...))
+	}
+
+	if s.Duration <= 0 {
+		addErr("duration must be positive")
+	}
+
+	if s.Generators < 1 {
+		s.Generators = 1
+	}
+
+	switch s.Arrival.Distribution {
+	case "":
+		s.Arrival.Distribution = PoissonDistribution
+	case PoissonDistribution, ConstantDistribution, UniformDistribution:
+	default:
+		addErr("unknown arrival distribution %q", s.Arrival.Distribution)
+	}
+
+	if s.Arrival.Rate <= 0 {
+		addErr("arrival rate must be positive")
+	}
+
+	if len(s.Jobs) == 0 {
+		addErr("at least one job profile is required")
+	}
+
+	for i := range s.Jobs {
+		if err := s.Jobs[i].validate(); err != nil {
+			addErr("job %d: %s", i, err.Error())
+		}
+	}
+
+	for name := range s.SLO.Latency {
+		if _, err := parsePercentile(name); err != nil {
+			addErr("slo latency %q: %s", name, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+func (p *JobProfile) validate() error {
+	if p.Weight <= 0 {
+		p.Weight = 1
+	}
+
+	l := &p.Latency
+	switch l.Distribution {
+	case "":
+		l.Distribution = UniformDistribution
+	case UniformDistribution, ConstantDistribution, ExponentialDistribution:
+	default:
+		return fmt.Errorf("unknown latency distribution %q", l.Distribution)
+	}
+
+	if l.Min < 0 || l.Max < 0 || l.Mean < 0 {
+		return errors.New("latencies may not be negative")
+	}
+
+	if l.Distribution == UniformDistribution && l.Max < l.Min {
+		l.Min, l.Max = l.Max, l.Min
+	}
+
+	if l.Distribution == ExponentialDistribution && l.Mean == 0 {
+		return errors.New("exponential latency requires a mean")
+	}
+
+	if p.Failures.Min < 0 || p.Failures.Max < 0 {
+		return errors.New("failures may not be negative")
+	}
+
+	if p.Failures.Max < p.Failures.Min {
+		p.Failures.Min, p.Failures.Max = p.Failures.Max, p.Failures.Min
+	}
+
+	return nil
+}
+
+// intervalFunc produces successive delays between job arrivals.
+type intervalFunc func() time.Duration
+
+func (a Arrival) intervals(rate float64, rng *rand.Rand) (intervalFunc, error) {
+	mean := time.Duration(float64(time.Second) / rate)
+
+	switch a.Distribution {
+	case ConstantDistribution:
+		return func() time.Duration { return mean }, nil
+
+	case UniformDistribution:
+		return func() time.Duration {
+			return time.Duration(rng.Int63n(int64(2*mean) + 1))
+		}, nil
+
+	default:
+		dist, err := tbnmath.NewPoissonDistributionWithRand(rate, rng)
+		if err != nil {
+			return nil, err
+		}
+		return dist.Next, nil
+	}
+}
+
+func (l Latency) next(rng *rand.Rand) time.Duration {
+	switch l.Distribution {
+	case ConstantDistribution:
+		return time.Duration(l.Min)
+
+	case ExponentialDistribution:
+		d := time.Duration(l.Min) + time.Duration(rng.ExpFloat64()*float64(l.Mean))
+		if l.Max > 0 && d > time.Duration(l.Max) {
+			d = time.Duration(l.Max)
+		}
+		return d
+
+	default:
+		d := time.Duration(l.Min)
+		if r := int64(l.Max - l.Min); r > 0 {
+			d += time.Duration(rng.Int63n(r + 1))
+		}
+		return d
+	}
+}
+
+func (f Failures) next(rng *rand.Rand) int {
+	n := f.Min
+	if r := f.Max - f.Min; r > 0 {
+		n += rng.Intn(r + 1)
+	}
+	return n
+}
+
+// chooseProfile picks a JobProfile at random, according to weight.
+func (s *Scenario) chooseProfile(rng *rand.Rand) *JobProfile {
+	total := 0.0
+	for i := range s.Jobs {
+		total += s.Jobs[i].Weight
+	}
+
+	x := rng.Float64() * total
+	for i := range s.Jobs {
+		x -= s.Jobs[i].Weight
+		if x < 0 {
+			return &s.Jobs[i]
+		}
+	}
+
+	return &s.Jobs[len(s.Jobs)-1]
+}
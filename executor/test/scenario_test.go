@@ -0,0 +1,203 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+const testScenario = `{
+  "name": "mixed",
+  "duration": "5m",
+  "generators": 2,
+  "arrival": {"rate": 10},
+  "jobs": [
+    {"name": "fast", "weight": 3, "latency": {"min": "20ms", "max": "10ms"}},
+    {"name": "slow", "latency": {"distribution": "exponential", "mean": "1s", "max": "2s"}, "timeout": "500ms"}
+  ],
+  "slo": {"min_success_rate": 0.9, "latency": {"p99": "1s", "max": "2s"}}
+}`
+
+func TestParseScenario(t *testing.T) {
+	s, err := ParseScenario(strings.NewReader(testScenario))
+	assert.Nil(t, err)
+	assert.Equal(t, s.Name, "mixed")
+	assert.Equal(t, s.Duration, Duration(5*time.Minute))
+	assert.Equal(t, s.Generators, 2)
+	assert.Equal(t, s.Arrival.Distribution, PoissonDistribution)
+	assert.Equal(t, s.Arrival.Rate, 10.0)
+	assert.Equal(t, len(s.Jobs), 2)
+
+	fast := s.Jobs[0]
+	assert.Equal(t, fast.Weight, 3.0)
+	assert.Equal(t, fast.Latency.Distribution, UniformDistribution)
+	assert.Equal(t, fast.Latency.Min, Duration(10*time.Millisecond))
+	assert.Equal(t, fast.Latency.Max, Duration(20*time.Millisecond))
+
+	slow := s.Jobs[1]
+	assert.Equal(t, slow.Weight, 1.0)
+	assert.Equal(t, slow.Timeout, Duration(500*time.Millisecond))
+
+	assert.Equal(t, s.SLO.MinSuccessRate, 0.9)
+	assert.Nil(t, s.SLO.MaxTimeoutRate)
+	assert.DeepEqual(
+		t,
+		s.SLO.Latency,
+		map[string]Duration{"p99": Duration(time.Second), "max": Duration(2 * time.Second)},
+	)
+}
+
+func TestParseScenarioErrors(t *testing.T) {
+	_, err := ParseScenario(strings.NewReader(`{"duration": "1m", "bogus": 1}`))
+	assert.ErrorContains(t, err, "bogus")
+
+	_, err = ParseScenario(strings.NewReader(`{"duration": 60}`))
+	assert.ErrorContains(t, err, "duration must be a string")
+
+	_, err = ParseScenario(strings.NewReader(`{
+          "arrival": {"distribution": "bursty"},
+          "jobs": [{"latency": {"distribution": "exponential"}}],
+          "slo": {"latency": {"p101": "1s"}}
+        }`))
+	assert.ErrorContains(t, err, "duration must be positive")
+	assert.ErrorContains(t, err, `unknown arrival distribution "bursty"`)
+	assert.ErrorContains(t, err, "arrival rate must be positive")
+	assert.ErrorContains(t, err, "job 0: exponential latency requires a mean")
+	assert.ErrorContains(t, err, `slo latency "p101"`)
+}
+
+func TestLatencyNext(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	constant := Latency{Distribution: ConstantDistribution, Min: Duration(time.Second)}
+	assert.Equal(t, constant.next(rng), time.Second)
+
+	uniform := Latency{
+		Distribution: UniformDistribution,
+		Min:          Duration(time.Second),
+		Max:          Duration(2 * time.Second),
+	}
+	exp := Latency{
+		Distribution: ExponentialDistribution,
+		Min:          Duration(time.Second),
+		Mean:         Duration(time.Second),
+		Max:          Duration(3 * time.Second),
+	}
+	for i := 0; i < 100; i++ {
+		d := uniform.next(rng)
+		assert.True(t, d >= time.Second && d <= 2*time.Second)
+
+		d = exp.next(rng)
+		assert.True(t, d >= time.Second && d <= 3*time.Second)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	assert.Equal(t, percentile(sorted, 0.5), 50*time.Millisecond)
+	assert.Equal(t, percentile(sorted, 0.99), 99*time.Millisecond)
+	assert.Equal(t, percentile(sorted, 0.999), 100*time.Millisecond)
+	assert.Equal(t, percentile(sorted, 1.0), 100*time.Millisecond)
+	assert.Equal(t, percentile(nil, 0.5), time.Duration(0))
+
+	p, err := parsePercentile("p12.5")
+	assert.Nil(t, err)
+	assert.Equal(t, p, 0.125)
+
+	p, err = parsePercentile("max")
+	assert.Nil(t, err)
+	assert.Equal(t, p, 1.0)
+
+	_, err = parsePercentile("p0")
+	assert.NonNil(t, err)
+	_, err = parsePercentile("99")
+	assert.NonNil(t, err)
+}
+
+func TestSLOCheck(t *testing.T) {
+	latencies := []time.Duration{
+		3 * time.Millisecond,
+		1 * time.Millisecond,
+		2 * time.Millisecond,
+		10 * time.Millisecond,
+	}
+
+	maxTimeoutRate := 0.1
+	slo := SLO{
+		MinSuccessRate: 0.75,
+		MaxTimeoutRate: &maxTimeoutRate,
+		Latency: map[string]Duration{
+			"p50": Duration(2 * time.Millisecond),
+			"max": Duration(5 * time.Millisecond),
+		},
+	}
+
+	r := &Results{SuccessRate: 0.75, TimeoutRate: 0.25}
+	slo.check(r, latencies)
+
+	assert.False(t, r.Passed)
+	assert.ArrayEqual(
+		t,
+		r.SLO,
+		[]SLOCheck{
+			{"invalid", "0", "0", true},
+			{"success_rate", "0.7500", "0.7500", true},
+			{"timeout_rate", "0.1000", "0.2500", false},
+			{"latency_max", "5ms", "10ms", false},
+			{"latency_p50", "2ms", "2ms", true},
+		},
+	)
+
+	r = &Results{SuccessRate: 1.0}
+	SLO{}.check(r, latencies)
+	assert.True(t, r.Passed)
+	assert.Equal(t, len(r.SLO), 1)
+}
+
+func TestResultsWriteJSON(t *testing.T) {
+	r := &Results{
+		Scenario: "x",
+		Jobs:     2,
+		Latency:  summarizeLatencies([]time.Duration{time.Second, 3 * time.Second}),
+		Passed:   true,
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, r.WriteJSON(buf))
+
+	decoded := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, decoded["scenario"], "x")
+	assert.Equal(t, decoded["jobs"], 2.0)
+	assert.Equal(t, decoded["passed"], true)
+
+	latency := decoded["latency"].(map[string]interface{})
+	assert.Equal(t, latency["mean"], "2s")
+	assert.Equal(t, latency["p50"], "1s")
+	assert.Equal(t, latency["max"], "3s")
+}
@@ -0,0 +1,267 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	tbntime "github.com/turbinelabs/nonstdlib/time"
+)
+
+// TestAttempt records a single attempt made by a TestExecutor.
+type TestAttempt struct {
+	// Task identifies the task. Tasks are numbered from 0 in the
+	// order they were submitted. Each Func passed to ExecMany,
+	// ExecGathered, or ExecGatheredWithPolicy is a separate task.
+	Task int
+
+	// Attempt is the attempt number for the task, starting at 1.
+	Attempt int
+
+	// Time is the time at which the attempt started.
+	Time time.Time
+
+	// Result is the AttemptResult of the attempt.
+	Result AttemptResult
+
+	// Try is the result of the attempt.
+	Try Try
+}
+
+// TestExecutor is an Executor for tests. Tasks are never run in the
+// background. Instead, attempts are made synchronously by the
+// caller, using the step functions below. Retries are scheduled
+// using the tbntime.ControlledSource passed to NewTestExecutor, and
+// become ready to run when the source's time reaches their next
+// attempt time. As with other Executors, the callbacks passed to
+// ExecGathered and ExecGatheredWithPolicy are invoked from a separate
+// goroutine once the gathered attempts complete.
+type TestExecutor interface {
+	Executor
+
+	// RunNext makes the next ready attempt, in the order attempts
+	// became ready. Returns false if no attempt was ready.
+	RunNext() bool
+
+	// RunReady makes attempts until none are ready, including
+	// any attempts that become ready as a result of running
+	// others. Returns the number of attempts made.
+	RunReady() int
+
+	// AdvanceToNextRetry sets the ControlledSource's time to the
+	// earliest time at which a retry is scheduled, making it and
+	// any other retries scheduled for that time ready. Returns
+	// false if no retries are scheduled.
+	AdvanceToNextRetry() bool
+
+	// RunUntilIdle alternates between RunReady and
+	// AdvanceToNextRetry until no attempts are ready and no
+	// retries are scheduled. Returns the number of attempts made.
+	RunUntilIdle() int
+
+	// Ready returns the number of attempts ready to run.
+	Ready() int
+
+	// Waiting returns the number of retries scheduled for the
+	// future.
+	Waiting() int
+
+	// Attempts returns a record of every attempt made, in order.
+	Attempts() []TestAttempt
+}
+
+// NewTestExecutor constructs a new TestExecutor. The given
+// ControlledSource overrides any WithTimeSource Option. The
+// parallelism Option is ignored. Otherwise, the defaults are the same
+// as NewGoroutineExecutor.
+func NewTestExecutor(src tbntime.ControlledSource, options ...Option) TestExecutor {
+	impl := &testExecImpl{
+		src:   src,
+		tasks: map[*retry]*testTask{},
+	}
+
+	options = append(options, WithTimeSource(src))
+	impl.commonExec = newCommonExec(impl, options)
+
+	return impl
+}
+
+type testTask struct {
+	id       int
+	attempts int
+}
+
+type testExecImpl struct {
+	*commonExec
+
+	src tbntime.ControlledSource
+
+	mutex    sync.Mutex
+	stopped  bool
+	nextID   int
+	tasks    map[*retry]*testTask
+	ready    []*retry
+	waiting  []*retry
+	attempts []TestAttempt
+}
+
+var _ TestExecutor = &testExecImpl{}
+
+func (e *testExecImpl) add(_ *commonExec, r *retry) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if e.stopped {
+		return
+	}
+
+	e.tasks[r] = &testTask{id: e.nextID}
+	e.nextID++
+	e.ready = append(e.ready, r)
+}
+
+func (e *testExecImpl) retry(c *commonExec, _ time.Duration, r *retry) bool {
+	if r.attempts >= c.maxAttempts {
+		return false
+	}
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if !e.stopped {
+		e.waiting = append(e.waiting, r)
+		sort.SliceStable(e.waiting, func(i, j int) bool {
+			return e.waiting[i].nextAttempt.Before(e.waiting[j].nextAttempt)
+		})
+	}
+
+	// Even if stopped, the retry is consumed so the callback is
+	// not invoked.
+	return true
+}
+
+func (e *testExecImpl) stop(_ *commonExec) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.stopped = true
+	e.ready = nil
+	e.waiting = nil
+}
+
+// promote moves waiting retries whose next attempt time has been
+// reached to the ready queue. Must be called with the mutex held.
+func (e *testExecImpl) promote() {
+	now := e.src.Now()
+
+	i := 0
+	for ; i < len(e.waiting); i++ {
+		if e.waiting[i].nextAttempt.After(now) {
+			break
+		}
+	}
+
+	e.ready = append(e.ready, e.waiting[:i]...)
+	e.waiting = e.waiting[i:]
+}
+
+func (e *testExecImpl) RunNext() bool {
+	e.mutex.Lock()
+	e.promote()
+	if len(e.ready) == 0 {
+		e.mutex.Unlock()
+		return false
+	}
+
+	r := e.ready[0]
+	e.ready = e.ready[1:]
+	task := e.tasks[r]
+	task.attempts++
+	attempt := TestAttempt{
+		Task:    task.id,
+		Attempt: task.attempts,
+		Time:    e.src.Now(),
+	}
+	e.mutex.Unlock()
+
+	attempt.Result, attempt.Try = e.attempt(r)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.attempts = append(e.attempts, attempt)
+	return true
+}
+
+func (e *testExecImpl) RunReady() int {
+	n := 0
+	for e.RunNext() {
+		n++
+	}
+	return n
+}
+
+func (e *testExecImpl) AdvanceToNextRetry() bool {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if len(e.waiting) == 0 {
+		return false
+	}
+
+	if next := e.waiting[0].nextAttempt; next.After(e.src.Now()) {
+		e.src.Set(next)
+	}
+
+	e.promote()
+	return true
+}
+
+func (e *testExecImpl) RunUntilIdle() int {
+	n := e.RunReady()
+	for e.AdvanceToNextRetry() {
+		n += e.RunReady()
+	}
+	return n
+}
+
+func (e *testExecImpl) Ready() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.promote()
+	return len(e.ready)
+}
+
+func (e *testExecImpl) Waiting() int {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	e.promote()
+	return len(e.waiting)
+}
+
+func (e *testExecImpl) Attempts() []TestAttempt {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	attempts := make([]TestAttempt, len(e.attempts))
+	copy(attempts, e.attempts)
+	return attempts
+}
@@ -0,0 +1,305 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package executor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	tbntime "github.com/turbinelabs/nonstdlib/time"
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestTestExecutorRetries(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		start := cs.Now()
+
+		e := NewTestExecutor(
+			cs,
+			WithRetryDelayFunc(NewConstantDelayFunc(time.Second)),
+			WithMaxAttempts(3),
+		)
+		defer e.Stop()
+
+		data := &testData{id: "a", fails: 2}
+		run := &testRun{}
+
+		var result Try
+		e.Exec(data.mkFunc(run), func(try Try) { result = try })
+
+		assert.Equal(t, e.Ready(), 1)
+		assert.Equal(t, e.Waiting(), 0)
+
+		assert.True(t, e.RunNext())
+		assert.Nil(t, result)
+		assert.Equal(t, e.Ready(), 0)
+		assert.Equal(t, e.Waiting(), 1)
+		assert.False(t, e.RunNext())
+
+		assert.True(t, e.AdvanceToNextRetry())
+		assert.Equal(t, cs.Now(), start.Add(time.Second))
+		assert.Equal(t, e.Ready(), 1)
+
+		assert.Equal(t, e.RunUntilIdle(), 2)
+		assert.Equal(t, cs.Now(), start.Add(2*time.Second))
+		assert.False(t, e.AdvanceToNextRetry())
+
+		assert.NonNil(t, result)
+		assert.True(t, result.IsReturn())
+		assert.Equal(t, result.Get(), "a")
+
+		attempts := e.Attempts()
+		assert.Equal(t, len(attempts), 3)
+		for i, a := range attempts {
+			assert.Equal(t, a.Task, 0)
+			assert.Equal(t, a.Attempt, i+1)
+			assert.Equal(t, a.Time, start.Add(time.Duration(i)*time.Second))
+		}
+		assert.Equal(t, attempts[0].Result, AttemptError)
+		assert.Equal(t, attempts[0].Try.Error().Error(), "failed")
+		assert.Equal(t, attempts[1].Result, AttemptError)
+		assert.Equal(t, attempts[2].Result, AttemptSuccess)
+		assert.Equal(t, attempts[2].Try.Get(), "a")
+	})
+}
+
+func TestTestExecutorMaxAttempts(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs, WithMaxAttempts(2))
+		defer e.Stop()
+
+		data := &testData{id: "a", fails: 5}
+
+		var result Try
+		e.Exec(data.mkFunc(&testRun{}), func(try Try) { result = try })
+
+		assert.Equal(t, e.RunUntilIdle(), 2)
+		assert.NonNil(t, result)
+		assert.True(t, result.IsError())
+		assert.Equal(t, len(e.Attempts()), 2)
+	})
+}
+
+func TestTestExecutorGlobalTimeout(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(
+			cs,
+			WithRetryDelayFunc(NewConstantDelayFunc(10*time.Second)),
+			WithMaxAttempts(3),
+			WithTimeout(5*time.Second),
+		)
+		defer e.Stop()
+
+		data := &testData{id: "a", fails: 5}
+
+		var result Try
+		e.Exec(data.mkFunc(&testRun{}), func(try Try) { result = try })
+
+		assert.Equal(t, e.RunUntilIdle(), 1)
+		assert.NonNil(t, result)
+		assert.ErrorContains(t, result.Error(), "failed action would timeout before next retry")
+
+		attempts := e.Attempts()
+		assert.Equal(t, len(attempts), 1)
+		assert.Equal(t, attempts[0].Result, AttemptError)
+	})
+}
+
+func TestTestExecutorExecGathered(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs)
+		defer e.Stop()
+
+		f1 := func(_ context.Context) (interface{}, error) { return "p1", nil }
+		f2 := func(_ context.Context) (interface{}, error) { return nil, errors.New("p2") }
+		f3 := func(_ context.Context) (interface{}, error) { return "p3", nil }
+
+		results := make(chan Try, 1)
+		e.ExecGathered([]Func{f1, f2, f3}, func(try Try) { results <- try })
+
+		assert.Equal(t, e.Ready(), 3)
+
+		assert.True(t, e.RunNext())
+		assert.Equal(t, len(results), 0)
+
+		assert.True(t, e.RunNext())
+		result := <-results
+		assert.True(t, result.IsError())
+		assert.Equal(t, result.Error().Error(), "p2")
+
+		// p3 is canceled
+		assert.True(t, e.RunNext())
+		attempts := e.Attempts()
+		assert.Equal(t, len(attempts), 3)
+		assert.Equal(t, attempts[0].Task, 0)
+		assert.Equal(t, attempts[1].Task, 1)
+		assert.Equal(t, attempts[2].Task, 2)
+		assert.Equal(t, attempts[2].Result, AttemptCancellation)
+	})
+}
+
+func TestTestExecutorExecGatheredWithPolicy(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs)
+		defer e.Stop()
+
+		f1 := func(_ context.Context) (interface{}, error) { return nil, errors.New("p1") }
+		f2 := func(_ context.Context) (interface{}, error) { return "p2", nil }
+		f3 := func(_ context.Context) (interface{}, error) { return "p3", nil }
+
+		results := make(chan *GatheredResults, 1)
+		e.ExecGatheredWithPolicy(
+			[]Func{f1, f2, f3},
+			NewQuorumGatherPolicy(2),
+			func(r *GatheredResults) { results <- r },
+		)
+
+		assert.Equal(t, e.RunReady(), 3)
+		result := <-results
+		assert.True(t, result.Satisfied)
+		assert.DeepEqual(t, result.Successes, map[int]interface{}{1: "p2", 2: "p3"})
+		assert.Equal(t, len(result.Failures), 1)
+		assert.Equal(t, result.Failures[0].Index, 0)
+	})
+}
+
+func TestTestExecutorStop(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(
+			cs,
+			WithRetryDelayFunc(NewConstantDelayFunc(time.Second)),
+			WithMaxAttempts(3),
+		)
+
+		data := &testData{id: "a", fails: 1}
+
+		e.Exec(data.mkFunc(&testRun{}), func(try Try) {
+			assert.Tracing(t).Errorf("unexpected callback: %+v", try)
+		})
+		e.Exec(data.mkFunc(&testRun{}), func(try Try) {
+			assert.Tracing(t).Errorf("unexpected callback: %+v", try)
+		})
+
+		assert.True(t, e.RunNext())
+		assert.Equal(t, e.Ready(), 1)
+		assert.Equal(t, e.Waiting(), 1)
+
+		e.Stop()
+		assert.Equal(t, e.Ready(), 0)
+		assert.Equal(t, e.Waiting(), 0)
+		assert.Equal(t, e.RunUntilIdle(), 0)
+
+		e.ExecAndForget(data.mkFunc(&testRun{}))
+		assert.Equal(t, e.Ready(), 0)
+	})
+}
+
+func TestTestExecutorMaxQueueDepth(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs, WithMaxQueueDepth(2))
+		defer e.Stop()
+
+		results := make([]Try, 3)
+		for i := range results {
+			idx := i
+			data := &testData{id: "a"}
+			e.Exec(data.mkFunc(&testRun{}), func(try Try) { results[idx] = try })
+		}
+
+		assert.Equal(t, e.Ready(), 2)
+		assert.Nil(t, results[0])
+		assert.Nil(t, results[1])
+		assert.NonNil(t, results[2])
+		assert.Equal(t, results[2].Error(), ErrQueueFull)
+
+		assert.True(t, e.RunNext())
+		assert.True(t, results[0].IsReturn())
+
+		// room for one more
+		var result Try
+		e.Exec((&testData{id: "b"}).mkFunc(&testRun{}), func(try Try) { result = try })
+		assert.Equal(t, e.Ready(), 2)
+
+		assert.Equal(t, e.RunUntilIdle(), 2)
+		assert.True(t, results[1].IsReturn())
+		assert.Equal(t, result.Get(), "b")
+	})
+}
+
+func TestTestExecutorMaxQueueDepthExecMany(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(cs, WithMaxQueueDepth(1))
+		defer e.Stop()
+
+		results := map[int]Try{}
+		e.ExecMany(
+			[]Func{
+				(&testData{id: "a"}).mkFunc(&testRun{}),
+				(&testData{id: "b"}).mkFunc(&testRun{}),
+			},
+			func(i int, try Try) { results[i] = try },
+		)
+
+		assert.Equal(t, len(results), 1)
+		assert.Equal(t, results[1].Error(), ErrQueueFull)
+
+		assert.Equal(t, e.RunUntilIdle(), 1)
+		assert.Equal(t, results[0].Get(), "a")
+	})
+}
+
+func TestTestExecutorRetryBudget(t *testing.T) {
+	tbntime.WithCurrentTimeFrozen(func(cs tbntime.ControlledSource) {
+		e := NewTestExecutor(
+			cs,
+			WithRetryDelayFunc(NewConstantDelayFunc(time.Second)),
+			WithMaxAttempts(3),
+			WithRetryBudget(1),
+		)
+		defer e.Stop()
+
+		var resultA, resultB Try
+		e.Exec(
+			(&testData{id: "a", fails: 1}).mkFunc(&testRun{}),
+			func(try Try) { resultA = try },
+		)
+		e.Exec(
+			(&testData{id: "b", fails: 1}).mkFunc(&testRun{}),
+			func(try Try) { resultB = try },
+		)
+
+		assert.Equal(t, e.RunReady(), 2)
+		assert.Equal(t, e.Waiting(), 1)
+		assert.Nil(t, resultA)
+		assert.NonNil(t, resultB)
+		assert.ErrorContains(t, resultB.Error(), "exceeded retry budget (1): failed")
+
+		assert.Equal(t, e.RunUntilIdle(), 1)
+		assert.Equal(t, resultA.Get(), "a")
+
+		// budget is available again
+		var resultC Try
+		e.Exec(
+			(&testData{id: "c", fails: 1}).mkFunc(&testRun{}),
+			func(try Try) { resultC = try },
+		)
+		assert.Equal(t, e.RunUntilIdle(), 2)
+		assert.Equal(t, resultC.Get(), "c")
+	})
+}
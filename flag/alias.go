@@ -0,0 +1,160 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+)
+
+var (
+	aliasLoggerMutex sync.Mutex
+	aliasLogger      = log.New(os.Stderr, "", log.LstdFlags)
+)
+
+// SetAliasLogger sets the logger used to warn that a flag alias has
+// been used (see FlagSet.Alias). By default, warnings are logged to
+// stderr. A nil logger disables the warnings.
+func SetAliasLogger(logger *log.Logger) {
+	aliasLoggerMutex.Lock()
+	defer aliasLoggerMutex.Unlock()
+
+	aliasLogger = logger
+}
+
+func logAliasUsed(old, new string) {
+	aliasLoggerMutex.Lock()
+	logger := aliasLogger
+	aliasLoggerMutex.Unlock()
+
+	if logger != nil {
+		logger.Printf("flag -%s is deprecated, use -%s instead", old, new)
+	}
+}
+
+// AliasTarget returns the name of the flag to which the given flag
+// forwards its values, if it was defined with FlagSet.Alias.
+func AliasTarget(f *flag.Flag) (string, bool) {
+	if a, ok := f.Value.(*aliasValue); ok {
+		return a.target, true
+	}
+	return "", false
+}
+
+// CheckAliases returns an error naming each alias that was set along
+// with the flag to which it refers. Setting the alias after the flag
+// is detected when the alias is set; CheckAliases detects the flag
+// being changed after the alias was set.
+func CheckAliases(fs *flag.FlagSet) error {
+	conflicts := []string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		a, ok := f.Value.(*aliasValue)
+		if !ok || !a.forwarded {
+			return
+		}
+
+		if fs.Lookup(a.target).Value.String() != a.forwardedValue {
+			conflicts = append(conflicts, fmt.Sprintf("-%s and -%s", a.name, a.target))
+		}
+	})
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting flag aliases: %s", strings.Join(conflicts, ", "))
+	}
+
+	return nil
+}
+
+func defineAlias(fs *flag.FlagSet, old, new string) {
+	target := fs.Lookup(new)
+	if target == nil {
+		panic(fmt.Sprintf("flag alias %s refers to undefined flag %s", old, new))
+	}
+
+	fs.Var(
+		&aliasValue{fs: fs, name: old, target: new},
+		old,
+		usage.Deprecated(fmt.Sprintf("Deprecated alias for -%s.", new)),
+	)
+	fs.Lookup(old).DefValue = target.DefValue
+}
+
+// aliasValue is a flag.Value that forwards its values to another flag
+// in the same flag.FlagSet.
+type aliasValue struct {
+	fs     *flag.FlagSet
+	name   string
+	target string
+
+	// forwarded is true once a value has been forwarded to the
+	// target, after which forwardedValue is the target's value.
+	forwarded      bool
+	forwardedValue string
+}
+
+var _ flag.Getter = &aliasValue{}
+
+func (a *aliasValue) targetFlag() *flag.Flag {
+	if a == nil || a.fs == nil {
+		return nil
+	}
+	return a.fs.Lookup(a.target)
+}
+
+func (a *aliasValue) String() string {
+	if f := a.targetFlag(); f != nil {
+		return f.Value.String()
+	}
+	return ""
+}
+
+func (a *aliasValue) Set(value string) error {
+	if !a.forwarded && IsSet(a.fs, a.target) {
+		return fmt.Errorf("cannot set both -%s and -%s", a.name, a.target)
+	}
+
+	logAliasUsed(a.name, a.target)
+
+	if err := a.fs.Set(a.target, value); err != nil {
+		return err
+	}
+
+	a.forwarded = true
+	a.forwardedValue = a.fs.Lookup(a.target).Value.String()
+	return nil
+}
+
+func (a *aliasValue) Get() interface{} {
+	return getFlagValue(a.targetFlag())
+}
+
+// IsBoolFlag allows an alias of a boolean flag to be given without a
+// value.
+func (a *aliasValue) IsBoolFlag() bool {
+	if f := a.targetFlag(); f != nil {
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok {
+			return b.IsBoolFlag()
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+	"github.com/turbinelabs/test/assert"
+)
+
+func captureAliasLogs() (*bytes.Buffer, func()) {
+	buf := &bytes.Buffer{}
+	SetAliasLogger(log.New(buf, "", 0))
+	return buf, func() { SetAliasLogger(log.New(os.Stderr, "", log.LstdFlags)) }
+}
+
+func TestAlias(t *testing.T) {
+	logs, restore := captureAliasLogs()
+	defer restore()
+
+	fs := NewTestFlagSet()
+	timeout := fs.Duration("timeout", 0, usage.Required("timeout"))
+	fs.Alias("deadline", "timeout")
+
+	f := fs.Unwrap().Lookup("deadline")
+	assert.True(t, usage.IsDeprecated(f))
+	assert.Equal(t, f.DefValue, "0s")
+
+	target, ok := AliasTarget(f)
+	assert.True(t, ok)
+	assert.Equal(t, target, "timeout")
+	_, ok = AliasTarget(fs.Unwrap().Lookup("timeout"))
+	assert.False(t, ok)
+
+	assert.Nil(t, fs.Parse([]string{"-deadline=5s"}))
+	assert.Equal(t, timeout.String(), "5s")
+	assert.Equal(t, f.Value.String(), "5s")
+	assert.Equal(t, f.Value.(flag.Getter).Get(), *timeout)
+	assert.True(t, IsSet(fs.Unwrap(), "timeout"))
+	assert.DeepEqual(t, usage.MissingRequired(fs.Unwrap()), []string{})
+	assert.Equal(t, logs.String(), "flag -deadline is deprecated, use -timeout instead\n")
+	assert.Nil(t, CheckAliases(fs.Unwrap()))
+}
+
+func TestAliasScoped(t *testing.T) {
+	_, restore := captureAliasLogs()
+	defer restore()
+
+	fs := NewTestFlagSet()
+	scoped := fs.Scope("exec", "")
+	verbose := scoped.Bool("verbose", false, "")
+	scoped.Alias("v", "verbose")
+
+	assert.Nil(t, fs.Parse([]string{"-exec.v"}))
+	assert.True(t, *verbose)
+}
+
+func TestAliasUndefinedTarget(t *testing.T) {
+	fs := NewTestFlagSet()
+	assert.Panic(t, func() { fs.Alias("old", "new") })
+}
+
+func TestAliasConflict(t *testing.T) {
+	_, restore := captureAliasLogs()
+	defer restore()
+
+	fs := NewTestFlagSet()
+	fs.Int("new", 0, "")
+	fs.Alias("old", "new")
+
+	fs.Unwrap().Init("test", flag.ContinueOnError)
+	fs.Unwrap().SetOutput(ioutil.Discard)
+	assert.ErrorContains(
+		t,
+		fs.Unwrap().Parse([]string{"-new=1", "-old=2"}),
+		"cannot set both -old and -new",
+	)
+
+	fs = NewTestFlagSet()
+	fs.Int("new", 0, "")
+	fs.Alias("old", "new")
+	assert.Nil(t, fs.Parse([]string{"-old=2", "-new=1"}))
+	assert.ErrorContains(t, CheckAliases(fs.Unwrap()), "conflicting flag aliases: -old and -new")
+}
+
+func TestAliasFromEnv(t *testing.T) {
+	_, restore := captureAliasLogs()
+	defer restore()
+
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+
+	fs := NewTestFlagSet()
+	n := fs.Int("new", 0, "")
+	fs.Alias("old", "new")
+	fs.Parse([]string{"-new=1"})
+
+	// the alias is ignored once its target is set
+	fe := NewFromEnv(fs.Unwrap(), "app").(fromEnv)
+	fe.os = mockOS
+	assert.Nil(t, fe.Fill())
+	assert.Equal(t, *n, 1)
+
+	mockOS.EXPECT().LookupEnv("APP_NEW").Return("", false)
+	mockOS.EXPECT().LookupEnv("APP_OLD").Return("2", true)
+
+	fs = NewTestFlagSet()
+	n = fs.Int("new", 0, "")
+	fs.Alias("old", "new")
+
+	fe = NewFromEnv(fs.Unwrap(), "app").(fromEnv)
+	fe.os = mockOS
+	assert.Nil(t, fe.Fill())
+	assert.Equal(t, *n, 2)
+	assert.DeepEqual(t, fe.Filled(), map[string]string{"APP_OLD": "2"})
+}
+
+func TestAliasHelp(t *testing.T) {
+	fs := NewTestFlagSet()
+	fs.Int("new", 0, "The value.")
+	fs.Alias("old", "new")
+	fs.Alias("older", "new")
+
+	h := NewHelp(fs, "app", "")
+	assert.Equal(t, len(h.Groups), 1)
+	assert.Equal(t, len(h.Groups[0].Flags), 1)
+	assert.ArrayEqual(t, h.Groups[0].Flags[0].Aliases, []string{"old", "older"})
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, h.WriteText(buf))
+	assert.Equal(t, buf.String(), `Usage: app [flags]
+
+Flags:
+  -new int
+        The value.
+        Default: 0
+        Deprecated aliases: -old, -older
+`)
+}
@@ -0,0 +1,277 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbinelabs/nonstdlib/arrays/indexof"
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+)
+
+var flagValueType = reflect.TypeOf((*flag.Value)(nil)).Elem()
+
+// Bind defines flags in the given FlagSet for the fields of the struct
+// referenced by ptr, using each field's current value as the flag's
+// default. Only fields with a "flag" tag are bound; the tag gives the
+// flag's name. The following additional tags are recognized:
+//
+//	usage:"..."         the flag's usage string
+//	required:"true"     marks the flag as required (see usage.Required)
+//	sensitive:"true"    marks the flag as sensitive (see usage.Sensitive)
+//	choice:"a,b,c"      limits a string field (or a Strings field) to the
+//	                    given values
+//
+// Fields may be bool, int, int64, uint, uint64, float64, string (or a
+// type derived from string), time.Duration, or any type whose pointer
+// implements flag.Value (for example HostPort, Bytes, or Strings).
+//
+// A tagged field whose type is a struct that does not implement
+// flag.Value is bound recursively within a scope (see FlagSet.Scope)
+// named by its flag tag. Its usage tag, if any, becomes the scope's
+// description. Untagged embedded structs are bound without a scope.
+//
+// For example:
+//
+//	type config struct {
+//	    Addr    HostPort      `flag:"addr" usage:"The listener address"`
+//	    Timeout time.Duration `flag:"timeout" usage:"The request timeout"`
+//	    Mode    string        `flag:"mode" choice:"fast,safe"`
+//	    Backend struct {
+//	        Key string `flag:"key" sensitive:"true" required:"true"`
+//	    } `flag:"backend" usage:"the backend"`
+//	}
+//
+//	cfg := config{Timeout: time.Second, Mode: "safe"}
+//	err := Bind(fs, &cfg)
+//
+// defines the flags addr, timeout, mode, and backend.key. An error is
+// returned if ptr is not a non-nil pointer to a struct, or if a field
+// or tag cannot be bound.
+func Bind(fs FlagSet, ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind flags to %T: expecting a pointer to a struct", ptr)
+	}
+
+	return bindStruct(fs, v.Elem())
+}
+
+func bindStruct(fs FlagSet, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldValue := v.Field(i)
+
+		name, tagged := field.Tag.Lookup("flag")
+		if !tagged {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct && !isFlagValue(fieldValue) {
+				if err := bindStruct(fs, fieldValue); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if name == "-" {
+			continue
+		}
+
+		if name == "" {
+			return fmt.Errorf("field %s.%s: empty flag name", t.Name(), field.Name)
+		}
+
+		if field.PkgPath != "" {
+			return fmt.Errorf("field %s.%s: cannot bind unexported field", t.Name(), field.Name)
+		}
+
+		if err := bindField(fs, name, field, fieldValue); err != nil {
+			return fmt.Errorf("field %s.%s: %s", t.Name(), field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func bindField(fs FlagSet, name string, field reflect.StructField, v reflect.Value) error {
+	usageStr := field.Tag.Get("usage")
+
+	if field.Type.Kind() == reflect.Struct && !isFlagValue(v) {
+		return bindStruct(fs.Scope(name, usageStr), v)
+	}
+
+	u := usage.New(usageStr)
+	encode := false
+	for _, tag := range []string{"required", "sensitive"} {
+		str, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return fmt.Errorf("invalid %s tag: %q", tag, str)
+		}
+
+		if b {
+			encode = true
+			if tag == "required" {
+				u.SetRequired()
+			} else {
+				u.SetSensitive()
+			}
+		}
+	}
+	if encode {
+		usageStr = u.String()
+	}
+
+	var choices []string
+	if str, ok := field.Tag.Lookup("choice"); ok {
+		for _, c := range strings.Split(str, ",") {
+			choices = append(choices, strings.TrimSpace(c))
+		}
+	}
+
+	if choices != nil {
+		switch {
+		case field.Type.Kind() == reflect.String:
+			fs.Var(&choiceField{field: v, allowedValues: choices}, name, usageStr)
+			return nil
+
+		case field.Type == reflect.TypeOf(Strings{}):
+			ssv := v.Addr().Interface().(*Strings)
+			ssv.AllowedValues = choices
+			if ssv.Delimiter == "" {
+				ssv.Delimiter = ","
+			}
+			fs.Var(ssv, name, usageStr)
+			return nil
+
+		default:
+			return errors.New("choice tag requires a string or Strings field")
+		}
+	}
+
+	if isFlagValue(v) {
+		fs.Var(v.Addr().Interface().(flag.Value), name, usageStr)
+		return nil
+	}
+
+	switch p := v.Addr().Interface().(type) {
+	case *bool:
+		fs.BoolVar(p, name, *p, usageStr)
+	case *time.Duration:
+		fs.DurationVar(p, name, *p, usageStr)
+	case *float64:
+		fs.Float64Var(p, name, *p, usageStr)
+	case *int:
+		fs.IntVar(p, name, *p, usageStr)
+	case *int64:
+		fs.Int64Var(p, name, *p, usageStr)
+	case *string:
+		fs.StringVar(p, name, *p, usageStr)
+	case *uint:
+		fs.UintVar(p, name, *p, usageStr)
+	case *uint64:
+		fs.Uint64Var(p, name, *p, usageStr)
+	default:
+		if field.Type.Kind() == reflect.String {
+			fs.Var(&stringField{field: v}, name, usageStr)
+			return nil
+		}
+		return fmt.Errorf("unsupported flag type %s", field.Type)
+	}
+
+	return nil
+}
+
+func isFlagValue(v reflect.Value) bool {
+	return v.CanAddr() && v.Addr().Type().Implements(flagValueType)
+}
+
+// stringField is a flag.Value that sets a field whose type is
+// derived from string.
+type stringField struct {
+	field reflect.Value
+}
+
+var _ flag.Getter = &stringField{}
+
+func (sf *stringField) String() string {
+	if sf == nil || !sf.field.IsValid() {
+		return ""
+	}
+	return sf.field.String()
+}
+
+func (sf *stringField) Set(value string) error {
+	sf.field.SetString(value)
+	return nil
+}
+
+func (sf *stringField) Get() interface{} {
+	return sf.field.Interface()
+}
+
+// choiceField is a ConstrainedValue that sets a field whose type is
+// derived from string to one of a set of allowed values.
+type choiceField struct {
+	field         reflect.Value
+	allowedValues []string
+}
+
+var _ flag.Getter = &choiceField{}
+var _ ConstrainedValue = &choiceField{}
+
+func (cf *choiceField) ValidValuesDescription() string {
+	return allowedValuesToDescription(cf.allowedValues)
+}
+
+func (cf *choiceField) completionValues() []string {
+	return cf.allowedValues
+}
+
+func (cf *choiceField) String() string {
+	if cf == nil || !cf.field.IsValid() {
+		return ""
+	}
+	return cf.field.String()
+}
+
+func (cf *choiceField) Set(value string) error {
+	if indexof.String(cf.allowedValues, value) == indexof.NotFound {
+		return fmt.Errorf(
+			"invalid flag value: %s, must be one of %s",
+			value,
+			strings.Join(cf.allowedValues, ", "),
+		)
+	}
+
+	cf.field.SetString(value)
+	return nil
+}
+
+func (cf *choiceField) Get() interface{} {
+	return cf.field.Interface()
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	"github.com/turbinelabs/test/assert"
+)
+
+type bindMode string
+
+type bindEmbedded struct {
+	Verbose bool `flag:"verbose" usage:"Be verbose"`
+}
+
+type bindBackend struct {
+	Key  string `flag:"key" usage:"The key for {{NAME}}" required:"true" sensitive:"true"`
+	Port uint   `flag:"port"`
+}
+
+type bindConfig struct {
+	bindEmbedded
+
+	Addr      HostPort      `flag:"addr" usage:"The listener address"`
+	Timeout   time.Duration `flag:"timeout" usage:"The request timeout"`
+	Attempts  int           `flag:"attempts"`
+	Offset    int64         `flag:"offset"`
+	Limit     uint64        `flag:"limit"`
+	Jitter    float64       `flag:"jitter"`
+	Name      string        `flag:"name"`
+	Mode      bindMode      `flag:"mode" choice:"fast, safe"`
+	Kind      bindMode      `flag:"kind"`
+	Tags      Strings       `flag:"tags" choice:"a,b,c"`
+	Size      Bytes         `flag:"size"`
+	Backend   bindBackend   `flag:"backend" usage:"the backend"`
+	Ignored   string        `flag:"-"`
+	Untagged  string
+	unexposed string
+}
+
+func TestBind(t *testing.T) {
+	fs := NewTestFlagSet()
+
+	cfg := bindConfig{
+		Addr:     NewHostPort("localhost:80"),
+		Timeout:  time.Second,
+		Attempts: 3,
+		Name:     "default",
+		Mode:     "safe",
+		Size:     NewBytes(1024),
+	}
+
+	assert.Nil(t, Bind(fs, &cfg))
+
+	defaults := map[string]string{
+		"verbose":      "false",
+		"addr":         "localhost:80",
+		"timeout":      "1s",
+		"attempts":     "3",
+		"offset":       "0",
+		"limit":        "0",
+		"jitter":       "0",
+		"name":         "default",
+		"mode":         "safe",
+		"kind":         "",
+		"tags":         "",
+		"size":         "1KiB",
+		"backend.key":  "",
+		"backend.port": "0",
+	}
+
+	actual := map[string]string{}
+	fs.Unwrap().VisitAll(func(f *flag.Flag) {
+		actual[f.Name] = f.DefValue
+	})
+	assert.DeepEqual(t, actual, defaults)
+
+	assert.Nil(t, fs.Parse([]string{
+		"-verbose",
+		"-addr=example.com:443",
+		"-timeout=5s",
+		"-attempts=5",
+		"-offset=-1",
+		"-limit=10",
+		"-jitter=0.5",
+		"-name=x",
+		"-mode=fast",
+		"-kind=anything",
+		"-tags=a,c",
+		"-size=2MiB",
+		"-backend.key=secret",
+		"-backend.port=8080",
+	}))
+
+	assert.True(t, cfg.Verbose)
+	assert.Equal(t, cfg.Addr.Addr(), "example.com:443")
+	assert.Equal(t, cfg.Timeout, 5*time.Second)
+	assert.Equal(t, cfg.Attempts, 5)
+	assert.Equal(t, cfg.Offset, int64(-1))
+	assert.Equal(t, cfg.Limit, uint64(10))
+	assert.Equal(t, cfg.Jitter, 0.5)
+	assert.Equal(t, cfg.Name, "x")
+	assert.Equal(t, cfg.Mode, bindMode("fast"))
+	assert.Equal(t, cfg.Kind, bindMode("anything"))
+	assert.ArrayEqual(t, cfg.Tags.Strings, []string{"a", "c"})
+	assert.Equal(t, cfg.Size.Value, uint64(2<<20))
+	assert.Equal(t, cfg.Backend.Key, "secret")
+	assert.Equal(t, cfg.Backend.Port, uint(8080))
+}
+
+func TestBindUsage(t *testing.T) {
+	fs := NewTestFlagSet()
+	cfg := bindConfig{}
+	assert.Nil(t, Bind(fs, &cfg))
+
+	f := fs.Unwrap().Lookup("timeout")
+	assert.Equal(t, f.Usage, "The request timeout")
+
+	f = fs.Unwrap().Lookup("backend.key")
+	assert.True(t, usage.IsRequired(f))
+	assert.True(t, usage.IsSensitive(f))
+	assert.Equal(t, usage.New(f.Usage).Usage(), "The key for the backend")
+
+	f = fs.Unwrap().Lookup("mode")
+	cv, ok := f.Value.(ConstrainedValue)
+	assert.True(t, ok)
+	assert.Equal(t, cv.ValidValuesDescription(), `"fast" or "safe"`)
+
+}
+
+func TestBindChoiceErrors(t *testing.T) {
+	fs := NewTestFlagSet()
+	cfg := bindConfig{Mode: "safe"}
+	assert.Nil(t, Bind(fs, &cfg))
+
+	assert.ErrorContains(
+		t,
+		fs.Unwrap().Set("mode", "slow"),
+		"invalid flag value: slow, must be one of fast, safe",
+	)
+	assert.Equal(t, cfg.Mode, bindMode("safe"))
+
+	assert.NonNil(t, fs.Unwrap().Set("tags", "d"))
+}
+
+func TestBindErrors(t *testing.T) {
+	fs := NewTestFlagSet()
+
+	cfg := bindConfig{}
+	assert.ErrorContains(t, Bind(fs, cfg), "expecting a pointer to a struct")
+	assert.ErrorContains(t, Bind(fs, (*bindConfig)(nil)), "expecting a pointer to a struct")
+
+	n := 1
+	assert.ErrorContains(t, Bind(fs, &n), "expecting a pointer to a struct")
+
+	unsupported := struct {
+		C chan int `flag:"c"`
+	}{}
+	assert.ErrorContains(t, Bind(fs, &unsupported), "unsupported flag type chan int")
+
+	badTag := struct {
+		S string `flag:"s" required:"maybe"`
+	}{}
+	assert.ErrorContains(t, Bind(fs, &badTag), `invalid required tag: "maybe"`)
+
+	badChoice := struct {
+		I int `flag:"i" choice:"1,2"`
+	}{}
+	assert.ErrorContains(t, Bind(fs, &badChoice), "choice tag requires a string or Strings field")
+
+	unexported := struct {
+		s string `flag:"s"`
+	}{}
+	assert.ErrorContains(t, Bind(fs, &unexported), "cannot bind unexported field")
+
+	emptyName := struct {
+		S string `flag:""`
+	}{}
+	assert.ErrorContains(t, Bind(fs, &emptyName), "empty flag name")
+}
@@ -0,0 +1,210 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type byteUnit struct {
+	name       string
+	multiplier uint64
+}
+
+// byteUnits are ordered by decreasing multiplier, so that the first
+// unit that evenly divides a value is the most compact representation.
+var byteUnits = []byteUnit{
+	{"PiB", 1 << 50},
+	{"PB", 1e15},
+	{"TiB", 1 << 40},
+	{"TB", 1e12},
+	{"GiB", 1 << 30},
+	{"GB", 1e9},
+	{"MiB", 1 << 20},
+	{"MB", 1e6},
+	{"KiB", 1 << 10},
+	{"KB", 1e3},
+	{"B", 1},
+}
+
+// Bytes conforms to the flag.Value, flag.Getter, and ConstrainedValue
+// interfaces. It can be used to populate a byte size from a command
+// line argument. It accepts a non-negative number, optionally
+// followed by a unit. Units are case insensitive: SI units (KB, MB,
+// GB, TB, PB) are powers of 1000 and IEC units (KiB, MiB, GiB, TiB,
+// PiB) are powers of 1024. A number without a unit, or with the unit
+// B, is a number of bytes. For example: "512KiB", "1.5GB", or "1024".
+//
+// Optional minimum and maximum bounds may be set with WithMin and
+// WithMax.
+type Bytes struct {
+	Value uint64
+
+	min, max       uint64
+	hasMin, hasMax bool
+}
+
+var _ ConstrainedValue = &Bytes{}
+var _ flag.Getter = &Bytes{}
+
+// NewBytes produces a Bytes with the given default value.
+func NewBytes(defaultValue uint64) Bytes {
+	return Bytes{Value: defaultValue}
+}
+
+// WithMin returns a copy of the Bytes that rejects values smaller
+// than min.
+func (b Bytes) WithMin(min uint64) Bytes {
+	b.min = min
+	b.hasMin = true
+	return b
+}
+
+// WithMax returns a copy of the Bytes that rejects values larger
+// than max.
+func (b Bytes) WithMax(max uint64) Bytes {
+	b.max = max
+	b.hasMax = true
+	return b
+}
+
+// ValidValuesDescription returns a string describing the allowed
+// values for this Bytes. For example: "a byte size between 1KiB and
+// 1GiB".
+func (b *Bytes) ValidValuesDescription() string {
+	return "a byte size" + boundsDescription(
+		b.hasMin,
+		b.hasMax,
+		formatBytes(b.min),
+		formatBytes(b.max),
+	)
+}
+
+// Get retrieves the current value of the Bytes as a uint64.
+func (b *Bytes) Get() interface{} {
+	return b.Value
+}
+
+// String returns the current value of the Bytes using the largest
+// unit that represents it exactly. For example: "512KiB".
+func (b *Bytes) String() string {
+	if b == nil {
+		return ""
+	}
+	return formatBytes(b.Value)
+}
+
+// Set sets the current value of the Bytes, returning an error if the
+// value cannot be parsed or is out of bounds.
+func (b *Bytes) Set(value string) error {
+	v, err := parseBytes(value)
+	if err != nil {
+		return err
+	}
+
+	if (b.hasMin && v < b.min) || (b.hasMax && v > b.max) {
+		return fmt.Errorf(
+			"invalid flag value: %s, must be %s",
+			value,
+			b.ValidValuesDescription(),
+		)
+	}
+
+	b.Value = v
+	return nil
+}
+
+func formatBytes(v uint64) string {
+	if v == 0 {
+		return "0B"
+	}
+
+	for _, u := range byteUnits {
+		if v%u.multiplier == 0 {
+			return strconv.FormatUint(v/u.multiplier, 10) + u.name
+		}
+	}
+
+	// unreachable: the last unit has multiplier 1
+	return strconv.FormatUint(v, 10) + "B"
+}
+
+func parseBytes(value string) (uint64, error) {
+	trimmed := strings.TrimSpace(value)
+	idx := strings.IndexFunc(trimmed, func(r rune) bool {
+		return r != '.' && !unicode.IsDigit(r)
+	})
+
+	num, unit := trimmed, ""
+	if idx >= 0 {
+		num, unit = trimmed[0:idx], strings.TrimSpace(trimmed[idx:])
+	}
+
+	multiplier := uint64(1)
+	if unit != "" {
+		found := false
+		for _, u := range byteUnits {
+			if strings.EqualFold(unit, u.name) {
+				multiplier = u.multiplier
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("invalid byte size: %s: unknown unit %q", value, unit)
+		}
+	}
+
+	if n, err := strconv.ParseUint(num, 10, 64); err == nil {
+		if n > math.MaxUint64/multiplier {
+			return 0, fmt.Errorf("invalid byte size: %s: out of range", value)
+		}
+		return n * multiplier, nil
+	}
+
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid byte size: %s", value)
+	}
+
+	result := math.Round(f * float64(multiplier))
+	if result >= math.MaxUint64 {
+		return 0, fmt.Errorf("invalid byte size: %s: out of range", value)
+	}
+
+	return uint64(result), nil
+}
+
+// boundsDescription produces a suffix for a ValidValuesDescription
+// describing optional minimum and maximum bounds.
+func boundsDescription(hasMin, hasMax bool, min, max string) string {
+	switch {
+	case hasMin && hasMax:
+		return fmt.Sprintf(" between %s and %s", min, max)
+	case hasMin:
+		return fmt.Sprintf(" of at least %s", min)
+	case hasMax:
+		return fmt.Sprintf(" of at most %s", max)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"testing"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestBytesSet(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected uint64
+	}{
+		{"0", 0},
+		{"1024", 1024},
+		{"10B", 10},
+		{"512KiB", 512 * 1024},
+		{"512kib", 512 * 1024},
+		{"1.5GB", 1500000000},
+		{"1.5 GiB", 1536 * 1024 * 1024},
+		{"2kB", 2000},
+		{"3TB", 3e12},
+		{"1PiB", 1 << 50},
+	}
+
+	for _, tc := range testCases {
+		assert.Group(tc.value, t, func(g *assert.G) {
+			b := NewBytes(99)
+			assert.Nil(g, b.Set(tc.value))
+			assert.Equal(g, b.Value, tc.expected)
+			assert.Equal(g, b.Get(), tc.expected)
+		})
+	}
+}
+
+func TestBytesSetErrors(t *testing.T) {
+	b := NewBytes(99)
+	assert.ErrorContains(t, b.Set(""), "invalid byte size")
+	assert.ErrorContains(t, b.Set("KiB"), "invalid byte size")
+	assert.ErrorContains(t, b.Set("-1"), "invalid byte size")
+	assert.ErrorContains(t, b.Set("1.2.3MB"), "invalid byte size")
+	assert.ErrorContains(t, b.Set("12XB"), `unknown unit "XB"`)
+	assert.ErrorContains(t, b.Set("20000PB"), "out of range")
+	assert.Equal(t, b.Value, uint64(99))
+}
+
+func TestBytesBounds(t *testing.T) {
+	b := NewBytes(4096).WithMin(1024).WithMax(1 << 30)
+	assert.Equal(t, b.ValidValuesDescription(), "a byte size between 1KiB and 1GiB")
+
+	assert.Nil(t, b.Set("1KiB"))
+	assert.Nil(t, b.Set("1GiB"))
+	assert.ErrorContains(
+		t,
+		b.Set("1000B"),
+		"invalid flag value: 1000B, must be a byte size between 1KiB and 1GiB",
+	)
+	assert.ErrorContains(t, b.Set("2GiB"), "must be a byte size between 1KiB and 1GiB")
+	assert.Equal(t, b.Value, uint64(1<<30))
+
+	b = NewBytes(0).WithMin(1000)
+	assert.Equal(t, b.ValidValuesDescription(), "a byte size of at least 1KB")
+
+	b = NewBytes(0).WithMax(1500)
+	assert.Equal(t, b.ValidValuesDescription(), "a byte size of at most 1500B")
+
+	b = NewBytes(0)
+	assert.Equal(t, b.ValidValuesDescription(), "a byte size")
+}
+
+func TestBytesString(t *testing.T) {
+	testCases := map[uint64]string{
+		0:                 "0B",
+		1:                 "1B",
+		1000:              "1KB",
+		1024:              "1KiB",
+		1500:              "1500B",
+		1536:              "1536B",
+		1500000000:        "1500MB",
+		3 * (1 << 30):     "3GiB",
+		2 * 1000000000000: "2TB",
+		1<<50 + 1<<40:     "1025TiB",
+	}
+
+	for value, expected := range testCases {
+		b := NewBytes(value)
+		assert.Equal(t, b.String(), expected)
+
+		roundTrip := NewBytes(0)
+		assert.Nil(t, roundTrip.Set(b.String()))
+		assert.Equal(t, roundTrip.Value, value)
+	}
+
+	var nilBytes *Bytes
+	assert.Equal(t, nilBytes.String(), "")
+}
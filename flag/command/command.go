@@ -0,0 +1,476 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package command provides a tree of subcommands, each with its own
+// flags, built on flag.FlagSet.
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	tbnflag "github.com/turbinelabs/nonstdlib/flag"
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+	tbntabwriter "github.com/turbinelabs/nonstdlib/text/tabwriter"
+)
+
+const (
+	// ExitSuccess is the exit code for a successful command.
+	ExitSuccess = 0
+
+	// ExitFailure is the exit code for a command that returns an
+	// error.
+	ExitFailure = 1
+
+	// ExitUsage is the exit code for invalid flags or arguments.
+	ExitUsage = 2
+)
+
+// RunFunc runs a command with the arguments remaining after its flags
+// are parsed.
+type RunFunc func(cmd *Command, args []string) error
+
+// UsageError indicates that a command was invoked incorrectly. When
+// returned by a RunFunc, the error and the command's help are written
+// to stderr and the exit code is ExitUsage.
+type UsageError struct {
+	Message string
+}
+
+func (e *UsageError) Error() string {
+	return e.Message
+}
+
+// UsageErrorf produces a UsageError with a formatted message.
+func UsageErrorf(format string, args ...interface{}) error {
+	return &UsageError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ExitError associates an error with a specific exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+// WithExitCode produces an ExitError, causing the command to exit with
+// the given code.
+func WithExitCode(code int, err error) error {
+	return &ExitError{Code: code, Err: err}
+}
+
+// Command is a node in a tree of commands. Each Command has its own
+// flags. Flags of a Command's ancestors are global to it: they may be
+// given before or after the Command's name on the command line. A
+// flag defined by a Command shadows any global flag of the same name.
+//
+// Flags not set on the command line are filled from environment
+// variables, using the names of the Command and its ancestors as the
+// scopes. For example, the flag "port" of the command "serve" in the
+// application "app" is filled from APP_SERVE_PORT. See
+// flag.EnvKey.
+type Command struct {
+	// Name of the command, as given on the command line.
+	Name string
+
+	// One-line summary of the command.
+	Summary string
+
+	// Run is invoked to run the command. If nil, the command
+	// requires a subcommand.
+	Run RunFunc
+
+	parent      *Command
+	subcommands []*Command
+	fs          *flag.FlagSet
+	flags       tbnflag.FlagSet
+}
+
+// New produces a root Command. The name is typically that of the
+// application.
+func New(name, summary string, run RunFunc) *Command {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	return &Command{
+		Name:    name,
+		Summary: summary,
+		Run:     run,
+		fs:      fs,
+		flags:   tbnflag.Wrap(fs),
+	}
+}
+
+// Add creates a subcommand of this Command. Panics if a subcommand of
+// the same name already exists.
+func (c *Command) Add(name, summary string, run RunFunc) *Command {
+	if c.Subcommand(name) != nil {
+		panic(fmt.Sprintf("command %s already has a subcommand named %s", c.FullName(), name))
+	}
+
+	sub := New(name, summary, run)
+	sub.parent = c
+	sub.fs = flag.NewFlagSet(c.FullName()+" "+name, flag.ContinueOnError)
+	sub.flags = tbnflag.Wrap(sub.fs)
+	c.subcommands = append(c.subcommands, sub)
+	return sub
+}
+
+// Flags returns the FlagSet used to define this Command's flags. The
+// same FlagSet is returned by every call, so that descriptions of its
+// scopes are available to Help.
+func (c *Command) Flags() tbnflag.FlagSet {
+	return c.flags
+}
+
+// Parent returns this Command's parent, or nil for a root Command.
+func (c *Command) Parent() *Command {
+	return c.parent
+}
+
+// Subcommands returns this Command's subcommands, in the order they
+// were added.
+func (c *Command) Subcommands() []*Command {
+	return c.subcommands
+}
+
+// Subcommand returns the named subcommand, or nil if there is none.
+func (c *Command) Subcommand(name string) *Command {
+	for _, sub := range c.subcommands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// Path returns the names of this Command and its ancestors, starting
+// with the root.
+func (c *Command) Path() []string {
+	if c.parent == nil {
+		return []string{c.Name}
+	}
+	return append(c.parent.Path(), c.Name)
+}
+
+// FullName returns the space-delimited Path of the Command, as it
+// would be typed on the command line.
+func (c *Command) FullName() string {
+	return strings.Join(c.Path(), " ")
+}
+
+// Main executes the Command with the process's arguments and exits
+// with the resulting exit code.
+func (c *Command) Main(os tbnos.OS) {
+	os.Exit(c.Execute(os, os.Args()[1:]))
+}
+
+// Execute parses the given arguments, selects a subcommand, fills
+// unset flags from the environment, checks for missing required flags,
+// and runs the selected command, returning an exit code. Help is
+// written to stdout if requested with -h or -help, and errors are
+// written to stderr. Execute is typically invoked on the root
+// Command.
+func (c *Command) Execute(os tbnos.OS, args []string) int {
+	inv := &invocation{os: os, set: map[string]bool{}}
+
+	cmd := c
+	for {
+		parseFS := cmd.parseFlagSet()
+		if err := parseFS.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				cmd.WriteHelp(os.Stdout())
+				return ExitSuccess
+			}
+			return inv.usageError(cmd, err)
+		}
+
+		if err := inv.recordSet(parseFS); err != nil {
+			return inv.usageError(cmd, err)
+		}
+		args = parseFS.Args()
+
+		if len(args) == 0 || len(cmd.subcommands) == 0 {
+			break
+		}
+
+		sub := cmd.Subcommand(args[0])
+		if sub == nil {
+			if cmd.Run == nil {
+				return inv.usageError(cmd, fmt.Errorf("unknown command %q", args[0]))
+			}
+			break
+		}
+
+		cmd = sub
+		args = args[1:]
+	}
+
+	if cmd.Run == nil {
+		return inv.usageError(cmd, errors.New("missing command"))
+	}
+
+	for _, owner := range cmd.lineage() {
+		if err := inv.fillFromEnv(owner); err != nil {
+			return inv.usageError(cmd, err)
+		}
+	}
+
+	for _, owner := range cmd.lineage() {
+		if err := tbnflag.CheckAliases(owner.fs); err != nil {
+			return inv.usageError(cmd, err)
+		}
+	}
+	inv.markAliasTargets(cmd)
+
+	if missing := inv.missingRequired(cmd); len(missing) > 0 {
+		return inv.usageError(
+			cmd,
+			fmt.Errorf("missing required flag(s): -%s", strings.Join(missing, ", -")),
+		)
+	}
+
+	for _, name := range inv.deprecatedAndSet(cmd) {
+		fmt.Fprintf(os.Stderr(), "warning: flag -%s is deprecated\n", name)
+	}
+
+	err := cmd.Run(cmd, args)
+	switch e := err.(type) {
+	case nil:
+		return ExitSuccess
+	case *UsageError:
+		return inv.usageError(cmd, e)
+	case *ExitError:
+		fmt.Fprintf(os.Stderr(), "%s: %s\n", cmd.FullName(), e.Error())
+		return e.Code
+	default:
+		fmt.Fprintf(os.Stderr(), "%s: %s\n", cmd.FullName(), e.Error())
+		return ExitFailure
+	}
+}
+
+// invocation holds the state of a single call to Execute.
+type invocation struct {
+	os  tbnos.OS
+	set map[string]bool
+}
+
+// recordSet records the flags set when the given flag.FlagSet was
+// parsed, returning an error if both an alias and the flag to which it
+// refers were set.
+func (inv *invocation) recordSet(parseFS *flag.FlagSet) error {
+	set := map[string]bool{}
+	parseFS.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	conflicts := []string{}
+	parseFS.Visit(func(f *flag.Flag) {
+		if target, ok := tbnflag.AliasTarget(f); ok && (set[target] || inv.set[target]) {
+			conflicts = append(conflicts, fmt.Sprintf("-%s and -%s", f.Name, target))
+		}
+	})
+	if len(conflicts) > 0 {
+		return fmt.Errorf("conflicting flag aliases: %s", strings.Join(conflicts, ", "))
+	}
+
+	for name := range set {
+		inv.set[name] = true
+		if target, ok := tbnflag.AliasTarget(parseFS.Lookup(name)); ok {
+			inv.set[target] = true
+		}
+	}
+	return nil
+}
+
+// markAliasTargets marks the flag to which each set alias refers as
+// set.
+func (inv *invocation) markAliasTargets(cmd *Command) {
+	cmd.parseFlagSet().VisitAll(func(f *flag.Flag) {
+		if target, ok := tbnflag.AliasTarget(f); ok && inv.set[f.Name] {
+			inv.set[target] = true
+		}
+	})
+}
+
+func (inv *invocation) usageError(cmd *Command, err error) int {
+	stderr := inv.os.Stderr()
+	fmt.Fprintf(stderr, "%s: %s\n\n", cmd.FullName(), err.Error())
+	cmd.WriteHelp(stderr)
+	return ExitUsage
+}
+
+// fillFromEnv sets each of the owner's flags that has not been set
+// from the environment.
+func (inv *invocation) fillFromEnv(owner *Command) error {
+	var firstErr error
+	owner.fs.VisitAll(func(f *flag.Flag) {
+		if inv.set[f.Name] || firstErr != nil {
+			return
+		}
+
+		// aliases do not override values set for their targets
+		if target, ok := tbnflag.AliasTarget(f); ok && inv.set[target] {
+			return
+		}
+
+		key := owner.envKey(f.Name)
+		value, found := inv.os.LookupEnv(key)
+		if !found {
+			return
+		}
+
+		if err := owner.fs.Set(f.Name, value); err != nil {
+			firstErr = fmt.Errorf("environment %s: %s", key, err.Error())
+			return
+		}
+		inv.set[f.Name] = true
+	})
+
+	return firstErr
+}
+
+func (inv *invocation) missingRequired(cmd *Command) []string {
+	return inv.filter(cmd, func(f *flag.Flag, set bool) bool {
+		return !set && usage.IsRequired(f)
+	})
+}
+
+// deprecatedAndSet omits aliases, which log their own warnings.
+func (inv *invocation) deprecatedAndSet(cmd *Command) []string {
+	return inv.filter(cmd, func(f *flag.Flag, set bool) bool {
+		_, isAlias := tbnflag.AliasTarget(f)
+		return set && !isAlias && usage.IsDeprecated(f)
+	})
+}
+
+func (inv *invocation) filter(cmd *Command, fn usage.FlagSetFilterFn) []string {
+	result := []string{}
+	cmd.parseFlagSet().VisitAll(func(f *flag.Flag) {
+		if fn(f, inv.set[f.Name]) {
+			result = append(result, f.Name)
+		}
+	})
+	return result
+}
+
+// lineage returns the Command and its ancestors, starting with the
+// root.
+func (c *Command) lineage() []*Command {
+	if c.parent == nil {
+		return []*Command{c}
+	}
+	return append(c.parent.lineage(), c)
+}
+
+func (c *Command) envKey(name string) string {
+	return tbnflag.EnvKey(append(c.Path(), name)...)
+}
+
+// owners returns the Command that defines each flag available to this
+// Command, keyed by flag name.
+func (c *Command) owners() map[string]*Command {
+	owners := map[string]*Command{}
+	for _, cmd := range c.lineage() {
+		cmd.fs.VisitAll(func(f *flag.Flag) {
+			owners[f.Name] = cmd
+		})
+	}
+	return owners
+}
+
+// parseFlagSet produces a flag.FlagSet containing this Command's flags
+// and the global flags of its ancestors. The flags share values with
+// the flag.FlagSets in which they were defined.
+func (c *Command) parseFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.FullName(), flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+
+	for name, owner := range c.owners() {
+		f := owner.fs.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+		fs.Lookup(name).DefValue = f.DefValue
+	}
+
+	return fs
+}
+
+// Help produces a tbnflag.Help describing this Command's flags,
+// including global flags.
+func (c *Command) Help() *tbnflag.Help {
+	descriptions := map[string]string{}
+	for _, cmd := range c.lineage() {
+		for prefix, description := range tbnflag.ScopeDescriptions(cmd.flags) {
+			descriptions[prefix] = description
+		}
+	}
+
+	help := tbnflag.NewHelpWithScopes(
+		tbnflag.Wrap(c.parseFlagSet()),
+		descriptions,
+		c.FullName(),
+		c.Summary,
+	)
+
+	owners := c.owners()
+	for _, g := range help.Groups {
+		for i := range g.Flags {
+			g.Flags[i].EnvKey = owners[g.Flags[i].Name].envKey(g.Flags[i].Name)
+		}
+	}
+
+	return help
+}
+
+// WriteHelp writes terminal help text for the Command to w, including
+// its flags and subcommands.
+func (c *Command) WriteHelp(w io.Writer) error {
+	if err := c.Help().WriteText(w); err != nil {
+		return err
+	}
+
+	if len(c.subcommands) == 0 {
+		return nil
+	}
+
+	subs := make([]*Command, len(c.subcommands))
+	copy(subs, c.subcommands)
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Name < subs[j].Name })
+
+	rows := make([]string, len(subs))
+	for i, sub := range subs {
+		rows[i] = fmt.Sprintf("  %s\t%s", sub.Name, sub.Summary)
+	}
+
+	_, err := fmt.Fprintf(
+		w,
+		"\nCommands:\n%s\nRun '%s <command> -h' for help with a command.\n",
+		tbntabwriter.Format(strings.Join(rows, "\n")),
+		c.FullName(),
+	)
+	return err
+}
@@ -0,0 +1,382 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package command
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	stdos "os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	tbnflag "github.com/turbinelabs/nonstdlib/flag"
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+	"github.com/turbinelabs/test/assert"
+)
+
+type testOS struct {
+	*tbnos.MockOS
+	stdout *bytes.Buffer
+	stderr *bytes.Buffer
+}
+
+func mkTestOS(ctrl *gomock.Controller, env map[string]string) *testOS {
+	os := &testOS{
+		MockOS: tbnos.NewMockOS(ctrl),
+		stdout: &bytes.Buffer{},
+		stderr: &bytes.Buffer{},
+	}
+	os.EXPECT().Stdout().Return(os.stdout).AnyTimes()
+	os.EXPECT().Stderr().Return(os.stderr).AnyTimes()
+	os.EXPECT().LookupEnv(gomock.Any()).DoAndReturn(
+		func(key string) (string, bool) {
+			v, ok := env[key]
+			return v, ok
+		},
+	).AnyTimes()
+	return os
+}
+
+type testApp struct {
+	root    *Command
+	serve   *Command
+	verbose *bool
+	port    *int
+	token   *string
+	ran     *Command
+	args    []string
+	err     error
+}
+
+func mkTestApp() *testApp {
+	app := &testApp{}
+	run := func(cmd *Command, args []string) error {
+		app.ran = cmd
+		app.args = args
+		return app.err
+	}
+
+	app.root = New("app", "Does things.", nil)
+	app.verbose = app.root.Flags().Bool("verbose", false, "Be verbose.")
+
+	app.serve = app.root.Add("serve", "Serves things.", run)
+	app.port = app.serve.Flags().Int("port", 80, "Port to serve on.")
+	app.token = app.serve.Flags().String("token", "", usage.Deprecated("Token."))
+
+	app.root.Add("version", "Prints the version.", run)
+
+	return app
+}
+
+func TestCommandPath(t *testing.T) {
+	app := mkTestApp()
+	assert.ArrayEqual(t, app.serve.Path(), []string{"app", "serve"})
+	assert.Equal(t, app.serve.FullName(), "app serve")
+	assert.SameInstance(t, app.serve.Parent(), app.root)
+	assert.Nil(t, app.root.Parent())
+	assert.SameInstance(t, app.root.Subcommand("serve"), app.serve)
+	assert.Nil(t, app.root.Subcommand("nope"))
+	assert.Equal(t, len(app.root.Subcommands()), 2)
+}
+
+func TestCommandAddDuplicate(t *testing.T) {
+	app := mkTestApp()
+	assert.Panic(t, func() { app.root.Add("serve", "", nil) })
+}
+
+func TestCommandExecute(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, nil)
+
+	code := app.root.Execute(os, []string{"-verbose", "serve", "-port=8080", "a", "b"})
+	assert.Equal(t, code, ExitSuccess)
+	assert.SameInstance(t, app.ran, app.serve)
+	assert.ArrayEqual(t, app.args, []string{"a", "b"})
+	assert.True(t, *app.verbose)
+	assert.Equal(t, *app.port, 8080)
+	assert.Equal(t, os.stderr.String(), "")
+}
+
+func TestCommandExecuteGlobalFlagAfterCommand(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, map[string]string{"APP_VERBOSE": "false"})
+
+	code := app.root.Execute(os, []string{"serve", "-verbose"})
+	assert.Equal(t, code, ExitSuccess)
+	assert.True(t, *app.verbose)
+}
+
+func TestCommandExecuteFillsFromEnv(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(
+		ctrl,
+		map[string]string{
+			"APP_VERBOSE":    "true",
+			"APP_SERVE_PORT": "9090",
+			"APP_PORT":       "1",
+		},
+	)
+
+	code := app.root.Execute(os, []string{"serve"})
+	assert.Equal(t, code, ExitSuccess)
+	assert.True(t, *app.verbose)
+	assert.Equal(t, *app.port, 9090)
+}
+
+func TestCommandExecuteEnvError(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, map[string]string{"APP_SERVE_PORT": "x"})
+
+	code := app.root.Execute(os, []string{"serve"})
+	assert.Equal(t, code, ExitUsage)
+	assert.Nil(t, app.ran)
+	assert.True(t, strings.HasPrefix(os.stderr.String(), "app serve: environment APP_SERVE_PORT: "))
+}
+
+func TestCommandExecuteRequired(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	app.root.Flags().String("region", "", usage.Required("Region."))
+	os := mkTestOS(ctrl, nil)
+
+	code := app.root.Execute(os, []string{"serve"})
+	assert.Equal(t, code, ExitUsage)
+	assert.Nil(t, app.ran)
+	assert.True(
+		t,
+		strings.HasPrefix(os.stderr.String(), "app serve: missing required flag(s): -region\n"),
+	)
+
+	os = mkTestOS(ctrl, map[string]string{"APP_REGION": "us-west"})
+	assert.Equal(t, app.root.Execute(os, []string{"serve"}), ExitSuccess)
+}
+
+func TestCommandExecuteDeprecated(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, nil)
+
+	code := app.root.Execute(os, []string{"serve", "-token=x"})
+	assert.Equal(t, code, ExitSuccess)
+	assert.Equal(t, os.stderr.String(), "warning: flag -token is deprecated\n")
+}
+
+func TestCommandExecuteMissingCommand(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, nil)
+
+	assert.Equal(t, app.root.Execute(os, []string{}), ExitUsage)
+	assert.True(t, strings.HasPrefix(os.stderr.String(), "app: missing command\n\nUsage: app"))
+
+	os = mkTestOS(ctrl, nil)
+	assert.Equal(t, app.root.Execute(os, []string{"nope"}), ExitUsage)
+	assert.True(t, strings.HasPrefix(os.stderr.String(), `app: unknown command "nope"`))
+}
+
+func TestCommandExecuteBadFlag(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, nil)
+
+	assert.Equal(t, app.root.Execute(os, []string{"serve", "-nope"}), ExitUsage)
+	assert.True(
+		t,
+		strings.HasPrefix(os.stderr.String(), "app serve: flag provided but not defined: -nope"),
+	)
+}
+
+func TestCommandExecuteHelp(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, nil)
+
+	assert.Equal(t, app.root.Execute(os, []string{"serve", "-h"}), ExitSuccess)
+	assert.Nil(t, app.ran)
+	assert.Equal(t, os.stdout.String(), `Usage: app serve [flags]
+
+Serves things.
+
+Flags:
+  -port int
+        Port to serve on.
+        Default: 80
+        Environment: APP_SERVE_PORT
+  -token string
+        [DEPRECATED] Token.
+        Environment: APP_SERVE_TOKEN
+  -verbose
+        Be verbose.
+        Environment: APP_VERBOSE
+`)
+}
+
+func TestCommandWriteHelpSubcommands(t *testing.T) {
+	app := mkTestApp()
+	buf := &bytes.Buffer{}
+	assert.Nil(t, app.root.WriteHelp(buf))
+	assert.Equal(t, buf.String(), `Usage: app [flags]
+
+Does things.
+
+Flags:
+  -verbose
+        Be verbose.
+        Environment: APP_VERBOSE
+
+Commands:
+  serve    Serves things.
+  version  Prints the version.
+
+Run 'app <command> -h' for help with a command.
+`)
+}
+
+func TestCommandHelpScopeDescriptions(t *testing.T) {
+	app := mkTestApp()
+	app.root.Flags().Scope("log", "Logging").String("level", "info", "Log level.")
+	app.serve.Flags().Scope("db", "Database").Scope("pool", "{{NAME}} pool").Int("size", 4, "Pool size.")
+	app.serve.Flags().Scope("tls", "").Bool("enabled", false, "Enable TLS.")
+
+	help := app.serve.Help()
+	descriptions := map[string]string{}
+	for _, g := range help.Groups {
+		descriptions[g.Scope] = g.Description
+	}
+	assert.DeepEqual(t, descriptions, map[string]string{
+		"":         "",
+		"db.pool.": "Database pool",
+		"log.":     "Logging",
+		"tls.":     "",
+	})
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, help.WriteText(buf))
+	assert.True(t, strings.Contains(buf.String(), "Flags for Database pool (db.pool.*):\n"))
+	assert.True(t, strings.Contains(buf.String(), "Flags for Logging (log.*):\n"))
+}
+
+func TestCommandExecuteErrors(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+
+	os := mkTestOS(ctrl, nil)
+	app.err = errors.New("boom")
+	assert.Equal(t, app.root.Execute(os, []string{"version"}), ExitFailure)
+	assert.Equal(t, os.stderr.String(), "app version: boom\n")
+
+	os = mkTestOS(ctrl, nil)
+	app.err = WithExitCode(3, errors.New("boom"))
+	assert.Equal(t, app.root.Execute(os, []string{"version"}), 3)
+	assert.Equal(t, os.stderr.String(), "app version: boom\n")
+
+	os = mkTestOS(ctrl, nil)
+	app.err = WithExitCode(4, nil)
+	assert.Equal(t, app.root.Execute(os, []string{"version"}), 4)
+	assert.Equal(t, os.stderr.String(), "app version: exit status 4\n")
+
+	os = mkTestOS(ctrl, nil)
+	app.err = UsageErrorf("bad %s", "arg")
+	assert.Equal(t, app.root.Execute(os, []string{"version"}), ExitUsage)
+	assert.True(t, strings.HasPrefix(os.stderr.String(), "app version: bad arg\n\nUsage: app version"))
+}
+
+func TestCommandMain(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	app := mkTestApp()
+	os := mkTestOS(ctrl, nil)
+	os.EXPECT().Args().Return([]string{"app", "version", "x"})
+	os.EXPECT().Exit(ExitSuccess)
+
+	app.root.Main(os)
+	assert.SameInstance(t, app.ran, app.root.Subcommand("version"))
+	assert.ArrayEqual(t, app.args, []string{"x"})
+}
+
+func TestCommandExecuteAliases(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	tbnflag.SetAliasLogger(nil)
+	defer tbnflag.SetAliasLogger(log.New(stdos.Stderr, "", log.LstdFlags))
+
+	mkApp := func() *testApp {
+		app := mkTestApp()
+		app.serve.Flags().Alias("listen-port", "port")
+		return app
+	}
+
+	app := mkApp()
+	os := mkTestOS(ctrl, map[string]string{"APP_SERVE_PORT": "1"})
+	assert.Equal(t, app.root.Execute(os, []string{"serve", "-listen-port=8080"}), ExitSuccess)
+	assert.Equal(t, *app.port, 8080)
+	assert.Equal(t, os.stderr.String(), "")
+
+	app = mkApp()
+	os = mkTestOS(ctrl, map[string]string{"APP_SERVE_LISTEN_PORT": "8081"})
+	assert.Equal(t, app.root.Execute(os, []string{"serve"}), ExitSuccess)
+	assert.Equal(t, *app.port, 8081)
+
+	app = mkApp()
+	os = mkTestOS(ctrl, nil)
+	assert.Equal(t, app.root.Execute(os, []string{"serve", "-port=1", "-listen-port=2"}), ExitUsage)
+	assert.True(
+		t,
+		strings.HasPrefix(
+			os.stderr.String(),
+			"app serve: conflicting flag aliases: -listen-port and -port\n",
+		),
+	)
+
+	app = mkApp()
+	os = mkTestOS(
+		ctrl,
+		map[string]string{"APP_SERVE_LISTEN_PORT": "1", "APP_SERVE_PORT": "2"},
+	)
+	assert.Equal(t, app.root.Execute(os, []string{"serve"}), ExitUsage)
+	assert.True(t, strings.Contains(os.stderr.String(), "-listen-port and -port"))
+}
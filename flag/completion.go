@@ -0,0 +1,247 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+)
+
+// completer is implemented by flag values with a fixed set of allowed
+// values, other than Choice and Strings.
+type completer interface {
+	completionValues() []string
+}
+
+// completionFlag describes a flag for shell completion.
+type completionFlag struct {
+	name        string
+	description string
+	isBool      bool
+	values      []string
+	kind        usage.ValueKind
+}
+
+var notIdentifier = regexp.MustCompile("[^A-Za-z0-9_]+")
+
+// completionFlags returns a completionFlag for each flag within the
+// FlagSet's scope. Deprecated and hidden flags are omitted.
+func completionFlags(fs FlagSet) []completionFlag {
+	scope := fs.GetScope()
+
+	flags := []completionFlag{}
+	fs.Unwrap().VisitAll(func(f *flag.Flag) {
+		if !strings.HasPrefix(f.Name, scope) {
+			return
+		}
+
+		u := usage.New(f.Usage)
+		if u.IsDeprecated() || u.IsHidden() {
+			return
+		}
+
+		cf := completionFlag{
+			name:        f.Name,
+			description: strings.TrimSpace(strings.SplitN(u.Usage(), "\n", 2)[0]),
+			kind:        u.ValueKind(),
+		}
+
+		// file completion also offers directories
+		if cf.kind == usage.PathValueKind {
+			cf.kind = usage.FileValueKind
+		}
+
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.isBool = true
+		}
+
+		cf.values = allowedValues(f.Value)
+
+		flags = append(flags, cf)
+	})
+
+	return flags
+}
+
+// allowedValues returns the fixed set of values allowed by the given
+// flag value, if any.
+func allowedValues(value flag.Value) []string {
+	switch v := value.(type) {
+	case *Choice:
+		return v.AllowedValues
+	case *Strings:
+		return v.AllowedValues
+	case completer:
+		return v.completionValues()
+	default:
+		return nil
+	}
+}
+
+func completionFuncName(program string) string {
+	return "_" + notIdentifier.ReplaceAllString(program, "_") + "_completion"
+}
+
+// WriteBashCompletion writes a bash completion script for the given
+// program to w. Flag names within the FlagSet's scope are completed,
+// as are the allowed values of Choice and constrained Strings flags.
+// Flags whose usage has a usage.FileValueKind, usage.PathValueKind, or
+// usage.DirValueKind complete file or directory paths. Deprecated and
+// hidden flags are omitted.
+func WriteBashCompletion(w io.Writer, fs FlagSet, program string) error {
+	flags := completionFlags(fs)
+
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "-" + f.name
+	}
+
+	b := &strings.Builder{}
+	funcName := completionFuncName(program)
+
+	fmt.Fprintf(b, "%s() {\n", funcName)
+	b.WriteString("    local cur prev\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	b.WriteString("    if [[ \"$prev\" == \"=\" ]]; then\n")
+	b.WriteString("        prev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n")
+	b.WriteString("    elif [[ \"$cur\" == \"=\" ]]; then\n")
+	b.WriteString("        cur=\"\"\n")
+	b.WriteString("    fi\n")
+	b.WriteString("\n")
+	b.WriteString("    case \"${prev#-}\" in\n")
+	for _, f := range flags {
+		var action string
+		switch {
+		case f.isBool:
+			continue
+		case len(f.values) > 0:
+			action = fmt.Sprintf("compgen -W %s -- \"$cur\"", shellQuote(strings.Join(f.values, " ")))
+		case f.kind == usage.FileValueKind:
+			action = "compgen -f -- \"$cur\""
+		case f.kind == usage.DirValueKind:
+			action = "compgen -d -- \"$cur\""
+		default:
+			action = ""
+		}
+
+		fmt.Fprintf(b, "        %s|-%s)\n", f.name, f.name)
+		if action != "" {
+			fmt.Fprintf(b, "            COMPREPLY=( $(%s) )\n", action)
+		} else {
+			b.WriteString("            COMPREPLY=()\n")
+		}
+		b.WriteString("            return 0\n")
+		b.WriteString("            ;;\n")
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("\n")
+	b.WriteString("    if [[ \"$cur\" == -* ]]; then\n")
+	fmt.Fprintf(
+		b,
+		"        COMPREPLY=( $(compgen -W %s -- \"$cur\") )\n",
+		shellQuote(strings.Join(names, " ")),
+	)
+	b.WriteString("    fi\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(b, "complete -F %s %s\n", funcName, program)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteZshCompletion writes a zsh completion script for the given
+// program to w. See WriteBashCompletion.
+func WriteZshCompletion(w io.Writer, fs FlagSet, program string) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "#compdef %s\n\n", program)
+	b.WriteString("_arguments")
+	for _, f := range completionFlags(fs) {
+		spec := fmt.Sprintf("-%s[%s]", f.name, zshEscape(f.description))
+
+		if !f.isBool {
+			switch {
+			case len(f.values) > 0:
+				escaped := make([]string, len(f.values))
+				for i, v := range f.values {
+					escaped[i] = zshEscape(v)
+				}
+				spec += fmt.Sprintf(":%s:(%s)", f.name, strings.Join(escaped, " "))
+			case f.kind == usage.FileValueKind:
+				spec += fmt.Sprintf(":%s:_files", f.name)
+			case f.kind == usage.DirValueKind:
+				spec += fmt.Sprintf(":%s:_files -/", f.name)
+			default:
+				spec += fmt.Sprintf(":%s: ", f.name)
+			}
+		}
+
+		fmt.Fprintf(b, " \\\n    %s", shellQuote(spec))
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteFishCompletion writes a fish completion script for the given
+// program to w. See WriteBashCompletion.
+func WriteFishCompletion(w io.Writer, fs FlagSet, program string) error {
+	b := &strings.Builder{}
+
+	for _, f := range completionFlags(fs) {
+		fmt.Fprintf(b, "complete -c %s -o %s", program, shellQuote(f.name))
+		if f.description != "" {
+			fmt.Fprintf(b, " -d %s", shellQuote(f.description))
+		}
+
+		if !f.isBool {
+			switch {
+			case len(f.values) > 0:
+				fmt.Fprintf(b, " -x -a %s", shellQuote(strings.Join(f.values, " ")))
+			case f.kind == usage.FileValueKind:
+				b.WriteString(" -r -F")
+			case f.kind == usage.DirValueKind:
+				b.WriteString(" -x -a '(__fish_complete_directories)'")
+			default:
+				b.WriteString(" -x")
+			}
+		}
+
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// shellQuote single-quotes s for bash, zsh, or fish.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// zshEscape escapes characters with special meaning in _arguments
+// specs.
+func zshEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "[", `\[`, "]", `\]`, ":", `\:`).Replace(s)
+}
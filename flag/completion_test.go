@@ -0,0 +1,134 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	"github.com/turbinelabs/test/assert"
+)
+
+func mkCompletionFlagSet() FlagSet {
+	fs := NewTestFlagSet()
+	fs.Bool("verbose", false, "Be verbose.\nMore detail.")
+	fs.String("config", "", usage.File("Config file."))
+	fs.String("old", "", usage.Deprecated("Old flag."))
+
+	exec := fs.Scope("exec", "executor")
+	mode := NewChoice("a", "b")
+	exec.Var(&mode, "mode", "Mode's [value]: a or b.")
+	exec.String("dir", "", usage.Dir("Working directory."))
+	exec.Int("n", 1, "")
+
+	return fs
+}
+
+func TestCompletionFlags(t *testing.T) {
+	fs := NewTestFlagSet()
+	s := NewStringsWithConstraint("x", "y")
+	fs.Var(&s, "s", "")
+	c := NewChoiceOf("p", "q")
+	fs.Var(&c, "c", "")
+	l := NewIntList().WithAllowedValues(1, 2)
+	fs.Var(&l, "l", "")
+
+	flags := completionFlags(fs)
+	assert.Equal(t, len(flags), 3)
+	assert.ArrayEqual(t, flags[0].values, []string{"p", "q"})
+	assert.ArrayEqual(t, flags[1].values, []string{"1", "2"})
+	assert.ArrayEqual(t, flags[2].values, []string{"x", "y"})
+
+	flags = completionFlags(mkCompletionFlagSet().Scope("exec", ""))
+	assert.Equal(t, len(flags), 3)
+	assert.Equal(t, flags[0].name, "exec.dir")
+
+	fs = NewTestFlagSet()
+	fs.String("hidden", "", usage.Hidden("Hidden."))
+	fs.String("path", "", usage.Path("Any path."))
+
+	flags = completionFlags(fs)
+	assert.Equal(t, len(flags), 1)
+	assert.Equal(t, flags[0].name, "path")
+	assert.Equal(t, flags[0].kind, usage.FileValueKind)
+}
+
+func TestWriteBashCompletion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, WriteBashCompletion(buf, mkCompletionFlagSet(), "my-app"))
+	assert.Equal(t, buf.String(), `_my_app_completion() {
+    local cur prev
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    if [[ "$prev" == "=" ]]; then
+        prev="${COMP_WORDS[COMP_CWORD-2]}"
+    elif [[ "$cur" == "=" ]]; then
+        cur=""
+    fi
+
+    case "${prev#-}" in
+        config|-config)
+            COMPREPLY=( $(compgen -f -- "$cur") )
+            return 0
+            ;;
+        exec.dir|-exec.dir)
+            COMPREPLY=( $(compgen -d -- "$cur") )
+            return 0
+            ;;
+        exec.mode|-exec.mode)
+            COMPREPLY=( $(compgen -W 'a b' -- "$cur") )
+            return 0
+            ;;
+        exec.n|-exec.n)
+            COMPREPLY=()
+            return 0
+            ;;
+    esac
+
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=( $(compgen -W '-config -exec.dir -exec.mode -exec.n -verbose' -- "$cur") )
+    fi
+}
+complete -F _my_app_completion my-app
+`)
+}
+
+func TestWriteZshCompletion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, WriteZshCompletion(buf, mkCompletionFlagSet(), "my-app"))
+	assert.Equal(t, buf.String(), `#compdef my-app
+
+_arguments \
+    '-config[Config file.]:config:_files' \
+    '-exec.dir[Working directory.]:exec.dir:_files -/' \
+    '-exec.mode[Mode'\''s \[value\]\: a or b.]:exec.mode:(a b)' \
+    '-exec.n[]:exec.n: ' \
+    '-verbose[Be verbose.]'
+`)
+}
+
+func TestWriteFishCompletion(t *testing.T) {
+	buf := &bytes.Buffer{}
+	assert.Nil(t, WriteFishCompletion(buf, mkCompletionFlagSet(), "my-app"))
+	assert.Equal(t, buf.String(), `complete -c my-app -o 'config' -d 'Config file.' -r -F
+complete -c my-app -o 'exec.dir' -d 'Working directory.' -x -a '(__fish_complete_directories)'
+complete -c my-app -o 'exec.mode' -d 'Mode'\''s [value]: a or b.' -x -a 'a b'
+complete -c my-app -o 'exec.n' -x
+complete -c my-app -o 'verbose' -d 'Be verbose.'
+`)
+}
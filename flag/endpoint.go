@@ -0,0 +1,251 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TCPNetwork is the network of TCP endpoints.
+	TCPNetwork = "tcp"
+
+	// UnixNetwork is the network of Unix domain socket endpoints.
+	UnixNetwork = "unix"
+)
+
+// EndpointValidator checks an Endpoint, returning an error if it is
+// not acceptable.
+type EndpointValidator func(Endpoint) error
+
+// RequireLoopback produces an EndpointValidator that accepts Unix
+// domain sockets and TCP endpoints whose host is "localhost" or a
+// loopback IP address.
+func RequireLoopback() EndpointValidator {
+	return func(e Endpoint) error {
+		if e.network == UnixNetwork || e.host == "localhost" {
+			return nil
+		}
+
+		if ip := net.ParseIP(e.host); ip != nil && ip.IsLoopback() {
+			return nil
+		}
+
+		return fmt.Errorf("%s is not a loopback address", e.String())
+	}
+}
+
+// RequirePortRange produces an EndpointValidator that accepts Unix
+// domain sockets and TCP endpoints with ports between min and max,
+// inclusive.
+func RequirePortRange(min, max int) EndpointValidator {
+	return func(e Endpoint) error {
+		if e.network == TCPNetwork && (e.port < min || e.port > max) {
+			return fmt.Errorf("port %d must be between %d and %d", e.port, min, max)
+		}
+		return nil
+	}
+}
+
+// RequireNetwork produces an EndpointValidator that accepts endpoints
+// with any of the given networks (e.g., TCPNetwork).
+func RequireNetwork(networks ...string) EndpointValidator {
+	return func(e Endpoint) error {
+		for _, n := range networks {
+			if e.network == n {
+				return nil
+			}
+		}
+		return fmt.Errorf(
+			"%s endpoints are not allowed, must be %s",
+			e.network,
+			strings.Join(networks, " or "),
+		)
+	}
+}
+
+// NewEndpoint constructs an Endpoint from the given string. If the
+// given argument is not a valid Endpoint, an empty Endpoint is
+// returned.
+func NewEndpoint(s string) Endpoint {
+	e := Endpoint{}
+	e.Set(s)
+	return e
+}
+
+// NewEndpointWithDefaultPort constructs an Endpoint from the given
+// string and a default port, which is used for TCP endpoints given
+// without a port, both in this constructor and in command line
+// flags.
+func NewEndpointWithDefaultPort(s string, port int) Endpoint {
+	e := Endpoint{defaultPort: port}
+	e.Set(s)
+	return e
+}
+
+// Endpoint represents the value of a network endpoint flag. It
+// accepts "tcp://host:port", "unix:///path/to/socket", or a bare
+// "host:port", in which the port may be a number or a service name.
+type Endpoint struct {
+	network     string
+	host        string
+	port        int
+	path        string
+	defaultPort int
+	validators  []EndpointValidator
+}
+
+var _ flag.Getter = &Endpoint{}
+
+// WithValidators returns a copy of the Endpoint that checks values
+// with the given EndpointValidators when set. The Endpoint's current
+// value is not checked.
+func (e Endpoint) WithValidators(validators ...EndpointValidator) Endpoint {
+	e.validators = append(append([]EndpointValidator{}, e.validators...), validators...)
+	return e
+}
+
+// Network returns the Endpoint's network: TCPNetwork, UnixNetwork,
+// or the empty string if the Endpoint has not been set.
+func (e *Endpoint) Network() string { return e.network }
+
+// Host returns the host of a TCP Endpoint, without brackets.
+func (e *Endpoint) Host() string { return e.host }
+
+// Port returns the port of a TCP Endpoint.
+func (e *Endpoint) Port() int { return e.port }
+
+// Path returns the path of a Unix domain socket Endpoint.
+func (e *Endpoint) Path() string { return e.path }
+
+// Addr returns an address suitable for use with the Endpoint's Network
+// by net.Dial or net.Listen: "host:port" for TCP endpoints and the
+// socket path for Unix endpoints.
+func (e *Endpoint) Addr() string {
+	if e.network == UnixNetwork {
+		return e.path
+	}
+	return net.JoinHostPort(e.host, strconv.Itoa(e.port))
+}
+
+// String returns the Endpoint in canonical form: "host:port" for TCP
+// endpoints and "unix:///path" for Unix endpoints.
+func (e *Endpoint) String() string {
+	if e == nil {
+		return ""
+	}
+
+	switch e.network {
+	case TCPNetwork:
+		return e.Addr()
+	case UnixNetwork:
+		return "unix://" + e.path
+	default:
+		return ""
+	}
+}
+
+// Get implements flag.Getter.
+func (e *Endpoint) Get() interface{} { return e }
+
+// Set implements flag.Value.
+func (e *Endpoint) Set(s string) error {
+	parsed, err := parseEndpoint(s, e.defaultPort)
+	if err != nil {
+		return err
+	}
+
+	for _, validate := range e.validators {
+		if err := validate(parsed); err != nil {
+			return err
+		}
+	}
+
+	e.network = parsed.network
+	e.host = parsed.host
+	e.port = parsed.port
+	e.path = parsed.path
+	return nil
+}
+
+func parseEndpoint(s string, defaultPort int) (Endpoint, error) {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "unix://") {
+		path := strings.TrimPrefix(s, "unix://")
+		if path == "" {
+			return Endpoint{}, fmt.Errorf("missing socket path in %s", s)
+		}
+		return Endpoint{network: UnixNetwork, path: path}, nil
+	}
+
+	s = strings.TrimPrefix(s, "tcp://")
+	if idx := strings.Index(s, "://"); idx >= 0 {
+		return Endpoint{}, fmt.Errorf("unsupported endpoint scheme %q", s[0:idx])
+	}
+
+	if s == "" {
+		return Endpoint{}, errors.New("empty endpoint")
+	}
+
+	host, port, err := net.SplitHostPort(s)
+	if err != nil {
+		if !strings.Contains(err.Error(), "missing port in address") || defaultPort <= 0 {
+			return Endpoint{}, err
+		}
+
+		host, _ = stripBrackets(s)
+		port = strconv.Itoa(defaultPort)
+	}
+
+	numericPort, err := net.LookupPort("tcp", port)
+	if err != nil {
+		return Endpoint{}, err
+	}
+
+	return Endpoint{network: TCPNetwork, host: host, port: numericPort}, nil
+}
+
+// Endpoints is a list of Endpoints. See NewEndpoints.
+type Endpoints = Repeated[Endpoint]
+
+// NewEndpoints produces an Endpoints that accepts comma-delimited
+// endpoints (e.g., "-flag=host1:80,unix:///sock"), or repetition of
+// the flag. TCP endpoints without a port are given the default port,
+// if it is greater than zero. Each endpoint is checked with the given
+// EndpointValidators. Duplicate endpoints are ignored.
+func NewEndpoints(defaultPort int, validators ...EndpointValidator) Endpoints {
+	proto := Endpoint{defaultPort: defaultPort}.WithValidators(validators...)
+
+	return NewList(
+		func(s string) (Endpoint, error) {
+			e := proto
+			err := e.Set(s)
+			return e, err
+		},
+	).WithFormat(
+		func(e Endpoint) string { return e.String() },
+	).WithKey(
+		func(e Endpoint) string { return e.String() },
+		DuplicateKeyKeepFirst,
+	)
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"testing"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestEndpointTCP(t *testing.T) {
+	for _, s := range []string{"example.com:80", "tcp://example.com:80", "example.com:http"} {
+		e := NewEndpoint(s)
+		assert.Equal(t, e.Network(), TCPNetwork)
+		assert.Equal(t, e.Host(), "example.com")
+		assert.Equal(t, e.Port(), 80)
+		assert.Equal(t, e.Path(), "")
+		assert.Equal(t, e.Addr(), "example.com:80")
+		assert.Equal(t, e.String(), "example.com:80")
+	}
+
+	e := NewEndpoint("[::1]:8080")
+	assert.Equal(t, e.Host(), "::1")
+	assert.Equal(t, e.String(), "[::1]:8080")
+	assert.SameInstance(t, e.Get(), &e)
+}
+
+func TestEndpointUnix(t *testing.T) {
+	e := NewEndpoint("unix:///var/run/app.sock")
+	assert.Equal(t, e.Network(), UnixNetwork)
+	assert.Equal(t, e.Path(), "/var/run/app.sock")
+	assert.Equal(t, e.Addr(), "/var/run/app.sock")
+	assert.Equal(t, e.String(), "unix:///var/run/app.sock")
+}
+
+func TestEndpointDefaultPort(t *testing.T) {
+	e := NewEndpointWithDefaultPort("example.com", 443)
+	assert.Equal(t, e.String(), "example.com:443")
+
+	e = NewEndpointWithDefaultPort("[::1]", 443)
+	assert.Equal(t, e.String(), "[::1]:443")
+
+	assert.Nil(t, e.Set("tcp://other"))
+	assert.Equal(t, e.String(), "other:443")
+
+	assert.Nil(t, e.Set("other:80"))
+	assert.Equal(t, e.String(), "other:80")
+}
+
+func TestEndpointSetErrors(t *testing.T) {
+	e := NewEndpoint("example.com:80")
+
+	assert.ErrorContains(t, e.Set("example.com"), "missing port in address")
+	assert.ErrorContains(t, e.Set("http://example.com:80"), `unsupported endpoint scheme "http"`)
+	assert.ErrorContains(t, e.Set("unix://"), "missing socket path in unix://")
+	assert.ErrorContains(t, e.Set(""), "empty endpoint")
+	assert.NonNil(t, e.Set("example.com:no-such-service"))
+	assert.Equal(t, e.String(), "example.com:80")
+
+	var zero *Endpoint
+	assert.Equal(t, zero.String(), "")
+	assert.Equal(t, (&Endpoint{}).String(), "")
+}
+
+func TestEndpointValidators(t *testing.T) {
+	e := NewEndpoint("").WithValidators(RequireLoopback(), RequirePortRange(1024, 65535))
+
+	assert.Nil(t, e.Set("localhost:8080"))
+	assert.Nil(t, e.Set("127.0.0.1:8080"))
+	assert.Nil(t, e.Set("[::1]:8080"))
+	assert.Nil(t, e.Set("unix:///tmp/sock"))
+
+	assert.ErrorContains(t, e.Set("example.com:8080"), "example.com:8080 is not a loopback address")
+	assert.ErrorContains(t, e.Set("localhost:80"), "port 80 must be between 1024 and 65535")
+	assert.Equal(t, e.String(), "unix:///tmp/sock")
+
+	e = NewEndpoint("").WithValidators(RequireNetwork(TCPNetwork))
+	assert.Nil(t, e.Set("example.com:80"))
+	assert.ErrorContains(t, e.Set("unix:///tmp/sock"), "unix endpoints are not allowed, must be tcp")
+}
+
+func TestEndpointFlag(t *testing.T) {
+	e := NewEndpointWithDefaultPort("localhost", 80)
+
+	fs := NewTestFlagSet()
+	fs.Var(&e, "endpoint", "")
+	assert.Equal(t, fs.Unwrap().Lookup("endpoint").DefValue, "localhost:80")
+
+	assert.Nil(t, fs.Parse([]string{"-endpoint=unix:///sock"}))
+	assert.Equal(t, e.Addr(), "/sock")
+}
+
+func TestEndpoints(t *testing.T) {
+	es := NewEndpoints(80, RequirePortRange(1, 1024))
+
+	assert.Nil(t, es.Set("a, b:81, tcp://a:80"))
+	assert.Nil(t, es.Set("unix:///sock,a:http"))
+	assert.Equal(t, es.String(), "a:80,b:81,unix:///sock")
+	assert.Equal(t, len(es.Values), 3)
+	assert.Equal(t, es.Values[2].Path(), "/sock")
+
+	assert.ErrorContains(t, es.Set("c:8080"), "invalid flag value: c:8080: port 8080 must be between 1 and 1024")
+	assert.Equal(t, len(es.Values), 3)
+
+	es.ResetDefault("x", "y:90")
+	assert.Equal(t, es.String(), "x:80,y:90")
+}
@@ -19,6 +19,7 @@ package flag
 import (
 	"flag"
 	"fmt"
+	"strconv"
 )
 
 func ExampleNewStrings() {
@@ -102,3 +103,45 @@ func ExampleNewChoice() {
 	// Output:
 	// c
 }
+
+func ExampleNewChoiceOf() {
+	type color string
+
+	var choice ChoiceOf[color] // typically a field in a struct
+	choice = NewChoiceOf[color]("red", "green", "blue").WithDefault("red")
+
+	flagset := flag.NewFlagSet("example", flag.PanicOnError)
+	flagset.Var(
+		&choice,
+		"color",
+		"Flag help. Allowed values: "+choice.ValidValuesDescription(),
+	)
+
+	flagset.Parse([]string{"-color=blue"})
+
+	var c color = choice.Value()
+	fmt.Println(c)
+	// Output:
+	// blue
+}
+
+func ExampleVar() {
+	flagset := Wrap(flag.NewFlagSet("example", flag.PanicOnError))
+
+	port := Var(
+		flagset,
+		"port",
+		uint16(80),
+		"Flag help.",
+		func(s string) (uint16, error) {
+			p, err := strconv.ParseUint(s, 10, 16)
+			return uint16(p), err
+		},
+	)
+
+	flagset.Unwrap().Parse([]string{"-port=8080"})
+
+	fmt.Println(*port)
+	// Output:
+	// 8080
+}
@@ -23,18 +23,58 @@ import (
 // Wrap converts an existing *flag.FlagSet into a FlagSet with no
 // scope.
 func Wrap(fs *flag.FlagSet) FlagSet {
-	return &flagSet{fs}
+	return &flagSet{FlagSet: fs}
 }
 
 // NewTestFlagSet creates a new FlagSet suitable for tests. It has no
 // prefix, contains no flags, and the unwrapped flag.FlagSet will
 // panic on parse errors.
 func NewTestFlagSet() TestFlagSet {
-	return &testFlagSet{&flagSet{flag.NewFlagSet("test flags", flag.PanicOnError)}}
+	return &testFlagSet{
+		&flagSet{FlagSet: flag.NewFlagSet("test flags", flag.PanicOnError)},
+	}
 }
 
 type flagSet struct {
 	*flag.FlagSet
+
+	// scopes maps the prefix of each scope created from this
+	// FlagSet to its description.
+	scopes map[string]string
+}
+
+// scopeRegistry is implemented by FlagSets that record the
+// descriptions of their scopes.
+type scopeRegistry interface {
+	recordScope(prefix, description string)
+	scopeDescriptions() map[string]string
+}
+
+func (fs *flagSet) recordScope(prefix, description string) {
+	if fs.scopes == nil {
+		fs.scopes = map[string]string{}
+	}
+	fs.scopes[prefix] = description
+}
+
+func (fs *flagSet) scopeDescriptions() map[string]string {
+	return fs.scopes
+}
+
+// ScopeDescriptions returns the descriptions given to Scope for
+// scopes created from the given FlagSet, or from the FlagSet it was
+// scoped from, keyed by prefix (including its trailing period).
+// Descriptions are recorded by the FlagSet returned from Wrap, so
+// scopes created from a different Wrap of the same flag.FlagSet are
+// not included.
+func ScopeDescriptions(fs FlagSet) map[string]string {
+	descriptions := map[string]string{}
+	if r, ok := fs.(scopeRegistry); ok {
+		for prefix, description := range r.scopeDescriptions() {
+			descriptions[prefix] = description
+		}
+	}
+	return descriptions
 }
 
 func (fs *flagSet) Scope(prefix, description string) FlagSet {
@@ -49,6 +89,10 @@ func (fs *flagSet) Unwrap() *flag.FlagSet {
 	return fs.FlagSet
 }
 
+func (fs *flagSet) Alias(old, new string) {
+	defineAlias(fs.FlagSet, old, new)
+}
+
 func (fs *flagSet) HostPortVar(hp *HostPort, name string, value HostPort, usage string) {
 	*hp = value
 	fs.Var(hp, name, usage)
@@ -60,6 +104,39 @@ func (fs *flagSet) HostPort(name string, value HostPort, usage string) *HostPort
 	return hp
 }
 
+func (fs *flagSet) BytesVar(b *Bytes, name string, value Bytes, usage string) {
+	*b = value
+	fs.Var(b, name, usage)
+}
+
+func (fs *flagSet) Bytes(name string, value Bytes, usage string) *Bytes {
+	b := &Bytes{}
+	fs.BytesVar(b, name, value, usage)
+	return b
+}
+
+func (fs *flagSet) PercentVar(p *Percent, name string, value Percent, usage string) {
+	*p = value
+	fs.Var(p, name, usage)
+}
+
+func (fs *flagSet) Percent(name string, value Percent, usage string) *Percent {
+	p := &Percent{}
+	fs.PercentVar(p, name, value, usage)
+	return p
+}
+
+func (fs *flagSet) RateVar(r *Rate, name string, value Rate, usage string) {
+	*r = value
+	fs.Var(r, name, usage)
+}
+
+func (fs *flagSet) Rate(name string, value Rate, usage string) *Rate {
+	r := &Rate{}
+	fs.RateVar(r, name, value, usage)
+	return r
+}
+
 // TestFlagSet represents an optionally scoped FlagSet for tests. It
 // differs from FlagSet only in that methods not normally needed by
 // consumers of FlagSet are directly available.
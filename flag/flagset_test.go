@@ -39,6 +39,28 @@ func TestScopedUnwrap(t *testing.T) {
 	assert.SameInstance(t, scoped.Unwrap(), fs)
 }
 
+func TestScopeDescriptions(t *testing.T) {
+	fs := Wrap(&flag.FlagSet{})
+	assert.Equal(t, len(ScopeDescriptions(fs)), 0)
+
+	fs.Scope("a", "A").Scope("b", "{{NAME}} B")
+	fs.Scope("c.", "C")
+	fs.Scope("", "ignored")
+	Wrap(fs.Unwrap()).Scope("d", "D")
+
+	expected := map[string]string{
+		"a.":   "A",
+		"a.b.": "A B",
+		"c.":   "C",
+	}
+	assert.DeepEqual(t, ScopeDescriptions(fs), expected)
+	assert.DeepEqual(t, ScopeDescriptions(fs.Scope("a", "A")), expected)
+
+	// the result is a copy
+	ScopeDescriptions(fs)["x."] = "X"
+	assert.DeepEqual(t, ScopeDescriptions(fs), expected)
+}
+
 func TestTestFlagSet(t *testing.T) {
 	tfs := NewTestFlagSet()
 
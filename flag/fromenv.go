@@ -20,13 +20,23 @@ package flag
 
 import (
 	"flag"
+	"fmt"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/turbinelabs/nonstdlib/flag/usage"
 	tbnos "github.com/turbinelabs/nonstdlib/os"
 )
 
+const redacted = "<redacted>"
+
+// maxSuggestionDistance is the largest edit distance between an
+// unknown environment key and a known key for which the known key is
+// suggested as a correction.
+const maxSuggestionDistance = 3
+
 var (
 	notAlphaNum         = regexp.MustCompile("[^A-Za-z0-9_]+")
 	multipleUnderscores = regexp.MustCompile("_+")
@@ -48,6 +58,51 @@ func NewFromEnv(fs *flag.FlagSet, scopes ...string) FromEnv {
 	}
 }
 
+// NewStrictFromEnv produces a FromEnv, as NewFromEnv, that also
+// reports environment variables beginning with its prefix that do not
+// correspond to any flag. After filling the FlagSet, Fill returns an
+// *UnknownEnvError listing such variables, with suggested corrections
+// where a similar key exists. If no scopes are given, the prefix is
+// empty and no variables are reported.
+func NewStrictFromEnv(fs *flag.FlagSet, scopes ...string) FromEnv {
+	fe := NewFromEnv(fs, scopes...).(fromEnv)
+	fe.strict = true
+	return fe
+}
+
+// UnknownEnvKey is an environment variable that matches no flag, along
+// with the most similar known key, if any.
+type UnknownEnvKey struct {
+	Key        string
+	Suggestion string
+}
+
+func (k UnknownEnvKey) String() string {
+	if k.Suggestion == "" {
+		return k.Key
+	}
+	return fmt.Sprintf("%s (did you mean %s?)", k.Key, k.Suggestion)
+}
+
+// UnknownEnvError is returned by the Fill method of a FromEnv created
+// with NewStrictFromEnv when environment variables with its prefix
+// match no flag.
+type UnknownEnvError struct {
+	Keys []UnknownEnvKey
+}
+
+func (e *UnknownEnvError) Error() string {
+	if len(e.Keys) == 1 {
+		return "unknown environment variable: " + e.Keys[0].String()
+	}
+
+	lines := make([]string, len(e.Keys))
+	for i, k := range e.Keys {
+		lines[i] = k.String()
+	}
+	return "unknown environment variables:\n  " + strings.Join(lines, "\n  ")
+}
+
 // FromEnv supports operations on a FlagSet based on environment variables.
 // In particular, FromEnv allows one to fill a FlagSet from the environment
 // and then inspect the results.
@@ -65,6 +120,17 @@ type FromEnv interface {
 	//
 	// the provided map[string]string is also populated with the keys and values
 	// added to the FlagSet.
+	//
+	// Flags defined with FlagSet.Alias are filled using the alias's name, unless
+	// the flag they refer to was already set.
+	//
+	// Flags with multiple values, such as Strings, Repeated, and StringMap, may
+	// also be filled from indexed environment variables, each holding one value:
+	//  SOME_PREFIX_SOME_FLAG_0, SOME_PREFIX_SOME_FLAG_1, ...
+	// Indices must be consecutive, starting from 0: variables after the first
+	// missing index are ignored, or reported as unknown by a FromEnv created
+	// with NewStrictFromEnv. Indexed values are appended to any value given by
+	// the unindexed variable.
 	Fill() error
 
 	// Filled returns a map of the environment keys and values for flags currently
@@ -81,6 +147,7 @@ type fromEnv struct {
 	fs            *flag.FlagSet
 	os            tbnos.OS
 	filledFromEnv map[string]string
+	strict        bool
 }
 
 func (fe fromEnv) Prefix() string {
@@ -94,25 +161,158 @@ func (fe fromEnv) Fill() error {
 		alreadySet[f.Name] = true
 	})
 	fe.fs.VisitAll(func(f *flag.Flag) {
+		// aliases do not override values set for their targets
+		if target, ok := AliasTarget(f); ok && alreadySet[target] {
+			return
+		}
+
 		if !alreadySet[f.Name] {
 			key := EnvKey(fe.prefix, f.Name)
-			val, found := fe.os.LookupEnv(key)
-			if found {
-				if usage.IsSensitive(f) {
-					fe.filledFromEnv[key] = "<redacted>"
-				} else {
-					fe.filledFromEnv[key] = val
-				}
-				if err := fe.fs.Set(f.Name, val); err != nil {
-					if firstErr == nil {
-						firstErr = err
-					}
-					return
-				}
+			if err := fe.fill(f, key); err != nil && firstErr == nil {
+				firstErr = err
 			}
 		}
 	})
-	return firstErr
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if fe.strict {
+		if unknown := fe.unknownKeys(); len(unknown) > 0 {
+			return &UnknownEnvError{Keys: unknown}
+		}
+	}
+
+	return nil
+}
+
+func (fe fromEnv) fill(f *flag.Flag, key string) error {
+	setFromEnv := func(key, val string) error {
+		if usage.IsSensitive(f) {
+			fe.filledFromEnv[key] = redacted
+		} else {
+			fe.filledFromEnv[key] = val
+		}
+		return fe.fs.Set(f.Name, val)
+	}
+
+	if val, found := fe.os.LookupEnv(key); found {
+		if err := setFromEnv(key, val); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := f.Value.(resettable); !ok {
+		return nil
+	}
+
+	for i := 0; ; i++ {
+		indexedKey := key + "_" + strconv.Itoa(i)
+		val, found := fe.os.LookupEnv(indexedKey)
+		if !found {
+			return nil
+		}
+
+		if err := setFromEnv(indexedKey, val); err != nil {
+			return err
+		}
+	}
+}
+
+// unknownKeys returns environment variables beginning with the prefix
+// that correspond to no flag, ordered by key.
+func (fe fromEnv) unknownKeys() []UnknownEnvKey {
+	if fe.prefix == "" {
+		return nil
+	}
+	prefix := fe.Prefix()
+
+	known := map[string]bool{}
+	consumed := map[string]bool{}
+	fe.fs.VisitAll(func(f *flag.Flag) {
+		key := EnvKey(fe.prefix, f.Name)
+		known[key] = true
+		if _, ok := f.Value.(resettable); !ok {
+			return
+		}
+
+		// only indexed keys before the first gap are read by fill
+		for i := 0; ; i++ {
+			indexedKey := key + "_" + strconv.Itoa(i)
+			if _, found := fe.os.LookupEnv(indexedKey); !found {
+				break
+			}
+			consumed[indexedKey] = true
+		}
+	})
+
+	knownKeys := make([]string, 0, len(known))
+	for key := range known {
+		knownKeys = append(knownKeys, key)
+	}
+	sort.Strings(knownKeys)
+
+	unknown := []UnknownEnvKey{}
+	for _, kv := range fe.os.Environ() {
+		key := kv
+		if idx := strings.Index(kv, "="); idx >= 0 {
+			key = kv[0:idx]
+		}
+
+		if !strings.HasPrefix(key, prefix) || known[key] || consumed[key] {
+			continue
+		}
+
+		unknown = append(unknown, UnknownEnvKey{Key: key, Suggestion: suggest(key, knownKeys)})
+	}
+
+	sort.Slice(unknown, func(i, j int) bool {
+		return unknown[i].Key < unknown[j].Key
+	})
+
+	return unknown
+}
+
+// suggest returns the candidate closest to key, if it is within
+// maxSuggestionDistance edits. Ties go to the earlier candidate.
+func suggest(key string, candidates []string) string {
+	best, bestDistance := "", maxSuggestionDistance+1
+	for _, c := range candidates {
+		if d := editDistance(key, c); d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < curr[j] {
+				curr[j] = d
+			}
+			if d := curr[j-1] + 1; d < curr[j] {
+				curr[j] = d
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
 }
 
 func (fe fromEnv) Filled() map[string]string {
@@ -151,3 +151,148 @@ func TestFillFromEnvOneSensitive(t *testing.T) {
 
 	assert.DeepEqual(t, fe.Filled(), map[string]string{"FOO_BAR_QUX": "<redacted>"})
 }
+
+func TestFillFromEnvIndexed(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	gomock.InOrder(
+		mockOS.EXPECT().LookupEnv("APP_HOSTS").Return("a,b", true),
+		mockOS.EXPECT().LookupEnv("APP_HOSTS_0").Return("c", true),
+		mockOS.EXPECT().LookupEnv("APP_HOSTS_1").Return("d", true),
+		mockOS.EXPECT().LookupEnv("APP_HOSTS_2").Return("", false),
+	)
+	gomock.InOrder(
+		mockOS.EXPECT().LookupEnv("APP_TAGS").Return("", false),
+		mockOS.EXPECT().LookupEnv("APP_TAGS_0").Return("x", true),
+		mockOS.EXPECT().LookupEnv("APP_TAGS_1").Return("", false),
+	)
+	mockOS.EXPECT().LookupEnv("APP_NAME").Return("", false)
+
+	fs := NewTestFlagSet()
+	hosts := NewStrings()
+	hosts.ResetDefault("default")
+	fs.Var(&hosts, "hosts", "the hosts")
+	tags := NewStrings()
+	fs.Var(&tags, "tags", "the tags")
+	fs.String("name", "", "the name")
+
+	fe := NewFromEnv(fs.Unwrap(), "app").(fromEnv)
+	fe.os = mockOS
+
+	assert.Nil(t, fe.Fill())
+	assert.ArrayEqual(t, hosts.Strings, []string{"a", "b", "c", "d"})
+	assert.ArrayEqual(t, tags.Strings, []string{"x"})
+	assert.DeepEqual(t, fe.Filled(), map[string]string{
+		"APP_HOSTS":   "a,b",
+		"APP_HOSTS_0": "c",
+		"APP_HOSTS_1": "d",
+		"APP_TAGS_0":  "x",
+	})
+}
+
+func TestFillFromEnvStrict(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().LookupEnv("MYAPP_EXEC_MAX_ATTEMPTS").Return("", false)
+	mockOS.EXPECT().LookupEnv("MYAPP_TAGS").Return("", false)
+	mockOS.EXPECT().LookupEnv("MYAPP_TAGS_0").Return("a", true).Times(2)
+	mockOS.EXPECT().LookupEnv("MYAPP_TAGS_1").Return("b", true).Times(2)
+	mockOS.EXPECT().LookupEnv("MYAPP_TAGS_2").Return("", false).Times(2)
+	mockOS.EXPECT().Environ().Return([]string{
+		"PATH=/bin",
+		"MYAPP_EXEC_MAX_ATEMPTS=3",
+		"MYAPP_TAGS_0=a",
+		"MYAPP_TAGS_1=b",
+		"MYAPP_TAGS_3=x",
+		"MYAPP_TAGS_X=y",
+		"MYAPP_COMPLETELY_DIFFERENT=1",
+		"MYAPPLICATION=1",
+		"MYAPP_NOEQUALS",
+	})
+
+	fs := NewTestFlagSet()
+	fs.Scope("exec", "").Int("max-attempts", 1, "attempts")
+	tags := NewStrings()
+	fs.Var(&tags, "tags", "the tags")
+
+	fe := NewStrictFromEnv(fs.Unwrap(), "myapp").(fromEnv)
+	fe.os = mockOS
+
+	err := fe.Fill()
+	assert.NonNil(t, err)
+	unknownErr, ok := err.(*UnknownEnvError)
+	assert.True(t, ok)
+	assert.ArrayEqual(
+		t,
+		unknownErr.Keys,
+		[]UnknownEnvKey{
+			{Key: "MYAPP_COMPLETELY_DIFFERENT"},
+			{Key: "MYAPP_EXEC_MAX_ATEMPTS", Suggestion: "MYAPP_EXEC_MAX_ATTEMPTS"},
+			{Key: "MYAPP_NOEQUALS"},
+			{Key: "MYAPP_TAGS_3", Suggestion: "MYAPP_TAGS"},
+			{Key: "MYAPP_TAGS_X", Suggestion: "MYAPP_TAGS"},
+		},
+	)
+	assert.ArrayEqual(t, tags.Strings, []string{"a", "b"})
+	assert.Equal(
+		t,
+		err.Error(),
+		"unknown environment variables:\n"+
+			"  MYAPP_COMPLETELY_DIFFERENT\n"+
+			"  MYAPP_EXEC_MAX_ATEMPTS (did you mean MYAPP_EXEC_MAX_ATTEMPTS?)\n"+
+			"  MYAPP_NOEQUALS\n"+
+			"  MYAPP_TAGS_3 (did you mean MYAPP_TAGS?)\n"+
+			"  MYAPP_TAGS_X (did you mean MYAPP_TAGS?)",
+	)
+
+	single := &UnknownEnvError{Keys: unknownErr.Keys[1:2]}
+	assert.Equal(
+		t,
+		single.Error(),
+		"unknown environment variable: MYAPP_EXEC_MAX_ATEMPTS (did you mean MYAPP_EXEC_MAX_ATTEMPTS?)",
+	)
+}
+
+func TestFillFromEnvStrictWithoutPrefix(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().LookupEnv("_NAME").Return("", false)
+
+	fs := NewTestFlagSet()
+	fs.String("name", "", "the name")
+
+	fe := NewStrictFromEnv(fs.Unwrap()).(fromEnv)
+	fe.os = mockOS
+
+	assert.Nil(t, fe.Fill())
+}
+
+func TestFillFromEnvStrictReportsSetErrorsFirst(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().LookupEnv("APP_INT").Return("x", true)
+
+	fs := NewTestFlagSet()
+	fs.Int("int", 0, "some int")
+
+	fe := NewStrictFromEnv(fs.Unwrap(), "app").(fromEnv)
+	fe.os = mockOS
+
+	assert.ErrorContains(t, fe.Fill(), "parse error")
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, editDistance("", ""), 0)
+	assert.Equal(t, editDistance("abc", ""), 3)
+	assert.Equal(t, editDistance("", "abc"), 3)
+	assert.Equal(t, editDistance("kitten", "sitting"), 3)
+	assert.Equal(t, editDistance("ATEMPTS", "ATTEMPTS"), 1)
+}
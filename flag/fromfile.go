@@ -0,0 +1,365 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+//go:generate mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE --write_package_comment=false
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+)
+
+// ConfigFormat identifies the format of a config file.
+type ConfigFormat string
+
+const (
+	// JSONConfigFormat is a JSON object. Scoped flag names may be
+	// written as nested objects: the flag "exec.delay" may be
+	// given as {"exec": {"delay": "1s"}} or {"exec.delay": "1s"}.
+	// Numbers and booleans are converted to strings. Arrays are
+	// joined with commas, suitable for Strings flags. Null values
+	// are ignored.
+	JSONConfigFormat ConfigFormat = "json"
+
+	// KeyValueConfigFormat contains one "key=value" pair per
+	// line. Whitespace around keys and values is ignored, and
+	// values may be double-quoted. Blank lines and lines starting
+	// with "#" or ";" are ignored. A line of the form "[exec]"
+	// begins a section: subsequent keys are prefixed with
+	// "exec.", until the next section. The section "[]" removes
+	// the prefix.
+	KeyValueConfigFormat ConfigFormat = "kv"
+)
+
+// ConfigFormatForPath returns JSONConfigFormat for paths with a
+// ".json" extension and KeyValueConfigFormat otherwise.
+func ConfigFormatForPath(path string) ConfigFormat {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return JSONConfigFormat
+	}
+
+	return KeyValueConfigFormat
+}
+
+// ParseConfig reads config in the given format, returning a map of
+// flag names to values.
+func ParseConfig(r io.Reader, format ConfigFormat) (map[string]string, error) {
+	lists, err := parseConfigLists(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(lists))
+	for k, l := range lists {
+		switch format {
+		case JSONConfigFormat:
+			values[k] = strings.Join(l, ",")
+		default:
+			// later keys replace earlier keys
+			values[k] = l[len(l)-1]
+		}
+	}
+
+	return values, nil
+}
+
+// parseConfigLists reads config in the given format, returning a map
+// of flag names to lists of values. Elements of JSON arrays and
+// repeated keys in KeyValueConfigFormat produce multiple values.
+func parseConfigLists(r io.Reader, format ConfigFormat) (map[string][]string, error) {
+	switch format {
+	case JSONConfigFormat:
+		return parseJSONConfig(r)
+	case KeyValueConfigFormat:
+		return parseKeyValueConfig(r)
+	default:
+		return nil, fmt.Errorf("unknown config format %q", format)
+	}
+}
+
+func parseJSONConfig(r io.Reader) (map[string][]string, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	obj := map[string]interface{}{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, err
+	}
+
+	values := map[string][]string{}
+	if err := flattenJSON("", obj, values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func flattenJSON(prefix string, obj map[string]interface{}, values map[string][]string) error {
+	for k, v := range obj {
+		key := prefix + k
+
+		switch t := v.(type) {
+		case nil:
+			continue
+
+		case map[string]interface{}:
+			if err := flattenJSON(key+".", t, values); err != nil {
+				return err
+			}
+			continue
+
+		case []interface{}:
+			parts := make([]string, 0, len(t))
+			for _, elem := range t {
+				s, ok := jsonScalar(elem)
+				if !ok {
+					return fmt.Errorf("%s: arrays may only contain strings, numbers, or booleans", key)
+				}
+				parts = append(parts, s)
+			}
+			values[key] = parts
+
+		default:
+			s, ok := jsonScalar(t)
+			if !ok {
+				return fmt.Errorf("%s: unsupported value", key)
+			}
+			values[key] = []string{s}
+		}
+	}
+
+	return nil
+}
+
+func jsonScalar(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case json.Number:
+		return t.String(), true
+	case bool:
+		return strconv.FormatBool(t), true
+	default:
+		return "", false
+	}
+}
+
+func parseKeyValueConfig(r io.Reader) (map[string][]string, error) {
+	values := map[string][]string{}
+	prefix := ""
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: malformed section %q", lineNum, line)
+			}
+
+			prefix = strings.TrimSpace(line[1 : len(line)-1])
+			if prefix != "" && !strings.HasSuffix(prefix, ".") {
+				prefix += "."
+			}
+			continue
+		}
+
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("line %d: expected key=value, got %q", lineNum, line)
+		}
+
+		key := strings.TrimSpace(line[0:idx])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", lineNum)
+		}
+
+		value := strings.TrimSpace(line[idx+1:])
+		if strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: malformed quoted value %s", lineNum, value)
+			}
+			value = unquoted
+		}
+
+		values[prefix+key] = append(values[prefix+key], value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// NewFromFile produces a FromFile for the given FlagSet, reading the
+// config file at the given path. The format of the file is determined
+// by ConfigFormatForPath.
+func NewFromFile(fs *flag.FlagSet, path string) FromFile {
+	return NewFromFileWithFormat(fs, path, ConfigFormatForPath(path))
+}
+
+// NewFromFileWithFormat produces a FromFile for the given FlagSet,
+// reading the config file at the given path in the given format.
+func NewFromFileWithFormat(fs *flag.FlagSet, path string, format ConfigFormat) FromFile {
+	return &fromFile{
+		path:           path,
+		format:         format,
+		fs:             fs,
+		os:             tbnos.New(),
+		values:         map[string]string{},
+		filledFromFile: map[string]string{},
+	}
+}
+
+// FromFile supports operations on a FlagSet based on a config
+// file. Keys in the file are flag names, including any scope
+// prefixes. FromFile is also a Layer, allowing it to be combined with
+// other sources of flag values via NewLayered.
+type FromFile interface {
+	Layer
+
+	// Path returns the path of the config file.
+	Path() string
+
+	// Fill reads the config file and, for each registered flag in
+	// the FlagSet that is not already set, sets its value from
+	// the file. Keys in the file that do not correspond to a flag
+	// produce an error, but do not prevent other flags from being
+	// set.
+	Fill() error
+
+	// Filled returns a map of the flag names and values for flags
+	// currently filled from the config file. Values for flags
+	// marked sensitive will be redacted.
+	Filled() map[string]string
+}
+
+type fromFile struct {
+	path           string
+	format         ConfigFormat
+	fs             *flag.FlagSet
+	os             tbnos.OS
+	values         map[string]string
+	filledFromFile map[string]string
+}
+
+func (ff *fromFile) Path() string {
+	return ff.path
+}
+
+func (ff *fromFile) Source() Source {
+	return FileSource
+}
+
+// Load reads and parses the config file. If the fromFile has a
+// FlagSet, keys that do not correspond to a flag produce an error.
+func (ff *fromFile) Load() error {
+	f, err := ff.os.Open(ff.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	values, err := ParseConfig(f, ff.format)
+	if err != nil {
+		return fmt.Errorf("%s: %s", ff.path, err.Error())
+	}
+	ff.values = values
+
+	if ff.fs == nil {
+		return nil
+	}
+
+	unknown := []string{}
+	for key := range values {
+		if ff.fs.Lookup(key) == nil {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf(
+			"%s: unknown flag(s): %s",
+			ff.path,
+			strings.Join(unknown, ", "),
+		)
+	}
+
+	return nil
+}
+
+func (ff *fromFile) Lookup(name string) (string, string, bool) {
+	value, found := ff.values[name]
+	return name, value, found
+}
+
+func (ff *fromFile) Fill() error {
+	firstErr := ff.Load()
+	if ff.fs == nil {
+		return firstErr
+	}
+
+	alreadySet := map[string]bool{}
+	ff.fs.Visit(func(f *flag.Flag) {
+		alreadySet[f.Name] = true
+	})
+	ff.fs.VisitAll(func(f *flag.Flag) {
+		if alreadySet[f.Name] {
+			return
+		}
+
+		val, found := ff.values[f.Name]
+		if !found {
+			return
+		}
+
+		if usage.IsSensitive(f) {
+			ff.filledFromFile[f.Name] = redacted
+		} else {
+			ff.filledFromFile[f.Name] = val
+		}
+
+		if err := ff.fs.Set(f.Name, val); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	})
+
+	return firstErr
+}
+
+func (ff *fromFile) Filled() map[string]string {
+	return ff.filledFromFile
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+func writeConfigFile(t *testing.T, name, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "fromfile-test")
+	assert.Nil(t, err)
+
+	path := filepath.Join(dir, name)
+	assert.Nil(t, ioutil.WriteFile(path, []byte(contents), 0644))
+
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func TestConfigFormatForPath(t *testing.T) {
+	assert.Equal(t, ConfigFormatForPath("x.json"), JSONConfigFormat)
+	assert.Equal(t, ConfigFormatForPath("/a/b/x.JSON"), JSONConfigFormat)
+	assert.Equal(t, ConfigFormatForPath("x.conf"), KeyValueConfigFormat)
+	assert.Equal(t, ConfigFormatForPath("x"), KeyValueConfigFormat)
+}
+
+func TestParseConfigJSON(t *testing.T) {
+	values, err := ParseConfig(
+		strings.NewReader(`{
+			"name": "x",
+			"exec": {
+				"delay": "1s",
+				"max-attempts": 3,
+				"retry": {"enabled": true}
+			},
+			"exec.timeout": 1.5,
+			"tags": ["a", "b", 3],
+			"unset": null
+		}`),
+		JSONConfigFormat,
+	)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, values, map[string]string{
+		"name":               "x",
+		"exec.delay":         "1s",
+		"exec.max-attempts":  "3",
+		"exec.retry.enabled": "true",
+		"exec.timeout":       "1.5",
+		"tags":               "a,b,3",
+	})
+}
+
+func TestParseConfigJSONErrors(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader(`[1, 2]`), JSONConfigFormat)
+	assert.NonNil(t, err)
+
+	_, err = ParseConfig(strings.NewReader(`{"a": [{"b": 1}]}`), JSONConfigFormat)
+	assert.ErrorContains(t, err, "a: arrays may only contain")
+
+	_, err = ParseConfig(strings.NewReader(`{"a": 1`), JSONConfigFormat)
+	assert.NonNil(t, err)
+}
+
+func TestParseConfigKeyValue(t *testing.T) {
+	values, err := ParseConfig(
+		strings.NewReader(`
+# comment
+name = x
+; another comment
+quoted = " padded "
+
+[exec]
+delay=1s
+max-attempts = 3
+
+[exec.retry.]
+enabled = true
+
+[]
+empty =
+`),
+		KeyValueConfigFormat,
+	)
+	assert.Nil(t, err)
+	assert.DeepEqual(t, values, map[string]string{
+		"name":               "x",
+		"quoted":             " padded ",
+		"exec.delay":         "1s",
+		"exec.max-attempts":  "3",
+		"exec.retry.enabled": "true",
+		"empty":              "",
+	})
+}
+
+func TestParseConfigKeyValueErrors(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader("a=1\nnope\n"), KeyValueConfigFormat)
+	assert.ErrorContains(t, err, `line 2: expected key=value, got "nope"`)
+
+	_, err = ParseConfig(strings.NewReader("[a\n"), KeyValueConfigFormat)
+	assert.ErrorContains(t, err, "line 1: malformed section")
+
+	_, err = ParseConfig(strings.NewReader(" = 1\n"), KeyValueConfigFormat)
+	assert.ErrorContains(t, err, "line 1: missing key")
+
+	_, err = ParseConfig(strings.NewReader(`a = "x`), KeyValueConfigFormat)
+	assert.ErrorContains(t, err, "line 1: malformed quoted value")
+}
+
+func TestParseConfigUnknownFormat(t *testing.T) {
+	_, err := ParseConfig(strings.NewReader(""), ConfigFormat("yaml"))
+	assert.ErrorContains(t, err, `unknown config format "yaml"`)
+}
+
+func TestFromFileFill(t *testing.T) {
+	path, cleanup := writeConfigFile(
+		t,
+		"config.json",
+		`{"foo-baz": "from-file", "bar": "bar-file", "qux": "secret"}`,
+	)
+	defer cleanup()
+
+	fs, fooFlag, barFlag, quxFlag := testFlags()
+	fs.Parse([]string{"-bar=bar-cmdline"})
+
+	ff := NewFromFile(fs, path)
+	assert.Equal(t, ff.Path(), path)
+	assert.Equal(t, ff.Source(), FileSource)
+
+	assert.Nil(t, ff.Fill())
+	assert.Equal(t, *fooFlag, "from-file")
+	assert.Equal(t, *barFlag, "bar-cmdline")
+	assert.Equal(t, *quxFlag, "secret")
+	assert.DeepEqual(t, ff.Filled(), map[string]string{
+		"foo-baz": "from-file",
+		"qux":     redacted,
+	})
+
+	key, value, found := ff.Lookup("bar")
+	assert.Equal(t, key, "bar")
+	assert.Equal(t, value, "bar-file")
+	assert.True(t, found)
+
+	_, _, found = ff.Lookup("nope")
+	assert.False(t, found)
+}
+
+func TestFromFileFillKeyValueWithScopes(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "config", "[exec]\ndelay = 5s\n")
+	defer cleanup()
+
+	fs := NewTestFlagSet()
+	delay := fs.Scope("exec", "").String("delay", "1s", "the delay")
+
+	assert.Nil(t, NewFromFile(fs.Unwrap(), path).Fill())
+	assert.Equal(t, *delay, "5s")
+}
+
+func TestFromFileFillUnknownKeys(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "config", "foo-baz = x\nzzz = 1\naaa = 2\n")
+	defer cleanup()
+
+	fs, fooFlag, _, _ := testFlags()
+
+	err := NewFromFile(fs, path).Fill()
+	assert.ErrorContains(t, err, "unknown flag(s): aaa, zzz")
+	assert.Equal(t, *fooFlag, "x")
+}
+
+func TestFromFileFillIllegalValue(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "config", "int = nope\n")
+	defer cleanup()
+
+	fs, _, _, _ := testFlags()
+	fs.Int("int", 0, "some int")
+
+	assert.NonNil(t, NewFromFile(fs, path).Fill())
+}
+
+func TestFromFileMissingFile(t *testing.T) {
+	fs, _, _, _ := testFlags()
+	err := NewFromFile(fs, "/does/not/exist.json").Fill()
+	assert.NonNil(t, err)
+}
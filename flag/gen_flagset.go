@@ -57,6 +57,49 @@ type FlagSet interface {
 	// flag. The flag accepts "host:port" strings.
 	HostPort(name string, value HostPort, usage string) *HostPort
 
+	// BytesVar defines a Bytes flag with the specified name,
+	// default value, and usage string. The argument b points to a
+	// Bytes variable in which to store the value of the flag.
+	// The flag accepts sizes such as "512KiB" or "1.5GB".
+	BytesVar(b *Bytes, name string, value Bytes, usage string)
+
+	// Bytes defines a Bytes flag with the specified name, default
+	// value, and usage string. The return value is the address of
+	// a Bytes variable that stores the value of the flag. The flag
+	// accepts sizes such as "512KiB" or "1.5GB".
+	Bytes(name string, value Bytes, usage string) *Bytes
+
+	// PercentVar defines a Percent flag with the specified name,
+	// default value, and usage string. The argument p points to a
+	// Percent variable in which to store the value of the flag.
+	// The flag accepts "75%" or "0.75".
+	PercentVar(p *Percent, name string, value Percent, usage string)
+
+	// Percent defines a Percent flag with the specified name,
+	// default value, and usage string. The return value is the
+	// address of a Percent variable that stores the value of the
+	// flag. The flag accepts "75%" or "0.75".
+	Percent(name string, value Percent, usage string) *Percent
+
+	// RateVar defines a Rate flag with the specified name, default
+	// value, and usage string. The argument r points to a Rate
+	// variable in which to store the value of the flag. The flag
+	// accepts rates such as "100/s" or "5/min".
+	RateVar(r *Rate, name string, value Rate, usage string)
+
+	// Rate defines a Rate flag with the specified name, default
+	// value, and usage string. The return value is the address of
+	// a Rate variable that stores the value of the flag. The flag
+	// accepts rates such as "100/s" or "5/min".
+	Rate(name string, value Rate, usage string) *Rate
+
+	// Alias defines a deprecated flag, old, that forwards its
+	// values to the existing flag, new. Setting the alias logs a
+	// deprecation warning (see SetAliasLogger). Setting both the
+	// alias and the flag it refers to is an error. Panics if new
+	// is not defined.
+	Alias(old, new string)
+
 	// BoolVar defines a bool flag with the specified name,
 	// default value, and usage. The flag's value is stored in p.
 	BoolVar(p *bool, name string, value bool, usage string)
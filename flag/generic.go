@@ -0,0 +1,210 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// ParseFunc converts a command line string into a value of type T.
+type ParseFunc[T any] func(string) (T, error)
+
+// Value conforms to the flag.Value and flag.Getter interfaces, and
+// can be used to populate a variable of any type from a flag.Flag,
+// using a ParseFunc. Values are formatted for usage text and String
+// with fmt.Sprint, unless a format function is given via
+// WithFormat.
+type Value[T any] struct {
+	p      *T
+	parse  ParseFunc[T]
+	format func(T) string
+}
+
+var _ flag.Getter = &Value[int]{}
+
+// NewValue produces a Value that stores its value in p, which is
+// initialized to value.
+func NewValue[T any](p *T, value T, parse ParseFunc[T]) *Value[T] {
+	*p = value
+	return &Value[T]{p: p, parse: parse}
+}
+
+// WithFormat sets the function used to format the Value as a string.
+// The function must produce strings accepted by the Value's
+// ParseFunc.
+func (v *Value[T]) WithFormat(format func(T) string) *Value[T] {
+	v.format = format
+	return v
+}
+
+// String returns the current value formatted as a string.
+func (v *Value[T]) String() string {
+	// The flag package invokes String on zero Values to
+	// determine whether a flag's default is the zero value.
+	if v == nil || v.p == nil {
+		return ""
+	}
+
+	if v.format != nil {
+		return v.format(*v.p)
+	}
+
+	return fmt.Sprint(*v.p)
+}
+
+// Set parses the given string and stores the result.
+func (v *Value[T]) Set(s string) error {
+	t, err := v.parse(s)
+	if err != nil {
+		return err
+	}
+
+	*v.p = t
+	return nil
+}
+
+// Get retrieves the current value as an interface{}.
+func (v *Value[T]) Get() interface{} {
+	return *v.p
+}
+
+// Value retrieves the current value.
+func (v *Value[T]) Value() T {
+	return *v.p
+}
+
+// TypedVar defines a flag of type T with the specified name, default
+// value, and usage string. The argument p points to a T variable in
+// which to store the value of the flag. Command line strings are
+// converted with the given ParseFunc.
+func TypedVar[T any](
+	fs FlagSet,
+	p *T,
+	name string,
+	value T,
+	usage string,
+	parse ParseFunc[T],
+) *Value[T] {
+	v := NewValue(p, value, parse)
+	fs.Var(v, name, usage)
+	return v
+}
+
+// Var defines a flag of type T with the specified name, default
+// value, and usage string. The return value is the address of a T
+// variable that stores the value of the flag. Command line strings
+// are converted with the given ParseFunc.
+func Var[T any](
+	fs FlagSet,
+	name string,
+	value T,
+	usage string,
+	parse ParseFunc[T],
+) *T {
+	p := new(T)
+	TypedVar(fs, p, name, value, usage, parse)
+	return p
+}
+
+// ChoiceOf conforms to the flag.Value and flag.Getter interfaces, and
+// can be used to populate a string-based type, such as an
+// enumeration, from a flag.Flag. It behaves like Choice, but its
+// value and allowed values are of type T.
+type ChoiceOf[T ~string] struct {
+	// Populated from the command line.
+	Choice *T
+
+	// All possible values allowed to appear in Choice.
+	AllowedValues []T
+}
+
+var _ flag.Getter = &ChoiceOf[string]{}
+var _ ConstrainedValue = &ChoiceOf[string]{}
+
+// NewChoiceOf produces a ChoiceOf with a set of allowed values.
+func NewChoiceOf[T ~string](allowedValues ...T) ChoiceOf[T] {
+	return ChoiceOf[T]{AllowedValues: allowedValues}
+}
+
+// WithDefault assigns a default value. If value is not a valid choice
+// it is ignored.
+func (cv ChoiceOf[T]) WithDefault(value T) ChoiceOf[T] {
+	cv.Set(string(value))
+	return cv
+}
+
+func (cv *ChoiceOf[T]) allowedStrings() []string {
+	allowed := make([]string, len(cv.AllowedValues))
+	for i, v := range cv.AllowedValues {
+		allowed[i] = string(v)
+	}
+	return allowed
+}
+
+func (cv *ChoiceOf[T]) completionValues() []string {
+	return cv.allowedStrings()
+}
+
+// ValidValuesDescription returns a string describing the allowed
+// values for this ChoiceOf. For example: "a", "b", or "c".
+func (cv *ChoiceOf[T]) ValidValuesDescription() string {
+	return allowedValuesToDescription(cv.allowedStrings())
+}
+
+// String returns the current value of the ChoiceOf.
+func (cv *ChoiceOf[T]) String() string {
+	if cv != nil && cv.Choice != nil {
+		return string(*cv.Choice)
+	}
+	return ""
+}
+
+// Set sets the current value of the ChoiceOf, returning an error if
+// the value is not one of the available choices.
+func (cv *ChoiceOf[T]) Set(value string) error {
+	for _, allowed := range cv.AllowedValues {
+		if string(allowed) == value {
+			t := allowed
+			cv.Choice = &t
+			return nil
+		}
+	}
+
+	return fmt.Errorf(
+		"invalid flag value: %s, must be one of %s",
+		value,
+		strings.Join(cv.allowedStrings(), ", "),
+	)
+}
+
+// Get retrieves the current value of the ChoiceOf as an interface{}.
+// The underlying value is a *T.
+func (cv *ChoiceOf[T]) Get() interface{} {
+	return cv.Choice
+}
+
+// Value retrieves the current value of the ChoiceOf, or the zero
+// value of T if no value has been chosen.
+func (cv *ChoiceOf[T]) Value() T {
+	if cv.Choice == nil {
+		var zero T
+		return zero
+	}
+	return *cv.Choice
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+type testEnum string
+
+const (
+	testEnumA testEnum = "a"
+	testEnumB testEnum = "b"
+)
+
+func parseURL(s string) (*url.URL, error) {
+	return url.Parse(s)
+}
+
+func TestGenericVar(t *testing.T) {
+	fs := NewTestFlagSet()
+	u := Var(fs.Scope("x", ""), "url", &url.URL{Host: "default"}, "a url", parseURL)
+	assert.Equal(t, (*u).Host, "default")
+
+	assert.Nil(t, fs.Parse([]string{"-x.url=http://example.com/path"}))
+	assert.Equal(t, (*u).Host, "example.com")
+	assert.Equal(t, (*u).Path, "/path")
+}
+
+func TestGenericTypedVar(t *testing.T) {
+	fs := NewTestFlagSet()
+
+	var n uint8
+	v := TypedVar(
+		fs,
+		&n,
+		"n",
+		7,
+		"a small number",
+		func(s string) (uint8, error) {
+			i, err := strconv.ParseUint(s, 10, 8)
+			return uint8(i), err
+		},
+	)
+	assert.Equal(t, n, uint8(7))
+	assert.Equal(t, v.String(), "7")
+	assert.Equal(t, fs.Unwrap().Lookup("n").DefValue, "7")
+
+	assert.Nil(t, fs.Parse([]string{"-n=200"}))
+	assert.Equal(t, n, uint8(200))
+	assert.Equal(t, v.Value(), uint8(200))
+	assert.Equal(t, v.Get(), uint8(200))
+
+	assert.NonNil(t, v.Set("300"))
+	assert.Equal(t, n, uint8(200))
+}
+
+func TestValueWithFormat(t *testing.T) {
+	var words []string
+	v := NewValue(
+		&words,
+		[]string{"a", "b"},
+		func(s string) ([]string, error) { return strings.Split(s, " "), nil },
+	).WithFormat(func(w []string) string { return strings.Join(w, " ") })
+
+	assert.Equal(t, v.String(), "a b")
+	assert.Nil(t, v.Set("c d e"))
+	assert.ArrayEqual(t, words, []string{"c", "d", "e"})
+	assert.Equal(t, v.String(), "c d e")
+}
+
+func TestValueSetError(t *testing.T) {
+	var i int
+	v := NewValue(&i, 1, func(string) (int, error) { return 0, errors.New("boom") })
+	assert.ErrorContains(t, v.Set("x"), "boom")
+	assert.Equal(t, i, 1)
+}
+
+func TestValueZero(t *testing.T) {
+	var v *Value[int]
+	assert.Equal(t, v.String(), "")
+	assert.Equal(t, (&Value[int]{}).String(), "")
+}
+
+func TestChoiceOf(t *testing.T) {
+	choice := NewChoiceOf(testEnumA, testEnumB)
+	assert.Nil(t, choice.Choice)
+	assert.Equal(t, choice.String(), "")
+	assert.Equal(t, choice.Value(), testEnum(""))
+	assert.Equal(t, choice.ValidValuesDescription(), `"a" or "b"`)
+
+	assert.Nil(t, choice.Set("b"))
+	assert.Equal(t, choice.Value(), testEnumB)
+	assert.Equal(t, *choice.Get().(*testEnum), testEnumB)
+
+	assert.ErrorContains(t, choice.Set("c"), "invalid flag value: c, must be one of a, b")
+	assert.Equal(t, choice.Value(), testEnumB)
+}
+
+func TestChoiceOfWithDefault(t *testing.T) {
+	choice := NewChoiceOf(testEnumA, testEnumB).WithDefault(testEnumB)
+	assert.Equal(t, choice.Value(), testEnumB)
+
+	choice = NewChoiceOf(testEnumA, testEnumB).WithDefault("c")
+	assert.Nil(t, choice.Choice)
+}
+
+func TestChoiceOfFlag(t *testing.T) {
+	choice := NewChoiceOf(testEnumA, testEnumB).WithDefault(testEnumA)
+
+	fs := NewTestFlagSet()
+	fs.Var(&choice, "enum", "an enum")
+	assert.Equal(t, fs.Unwrap().Lookup("enum").DefValue, "a")
+
+	assert.Nil(t, fs.Parse([]string{"-enum=b"}))
+	assert.Equal(t, choice.Value(), testEnumB)
+}
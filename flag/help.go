@@ -0,0 +1,422 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+)
+
+// Help describes the flags of a FlagSet, grouped by scope, and renders
+// the description as terminal help text, markdown, or a roff man
+// page.
+type Help struct {
+	// Name of the command.
+	Name string
+
+	// One-line summary of the command.
+	Summary string
+
+	// Flags grouped by scope. The group of unscoped flags, if any,
+	// is first, followed by the remaining groups ordered by scope.
+	Groups []HelpGroup
+}
+
+// HelpGroup describes the flags in a single scope.
+type HelpGroup struct {
+	// Scope prefix, including a trailing period, or the empty
+	// string for unscoped flags.
+	Scope string
+
+	// Description of the scope, as given to FlagSet.Scope.
+	Description string
+
+	// Flags in the scope, ordered by name.
+	Flags []HelpFlag
+}
+
+// HelpFlag describes a single flag.
+type HelpFlag struct {
+	// Full name of the flag, including any scope prefix.
+	Name string
+
+	// Name of the flag's type (for example, "duration"), if any.
+	Type string
+
+	// Usage text, without usage.Usage metadata.
+	Usage string
+
+	// Default value, if not empty or a false boolean. Defaults
+	// of sensitive flags are never included.
+	Default string
+
+	// Description of the allowed values, if the flag's value is
+	// a ConstrainedValue.
+	AllowedValues string
+
+	// Environment variable that may supply the flag's value, if
+	// environment scopes were given to NewHelp.
+	EnvKey string
+
+	// Deprecated aliases of the flag (see FlagSet.Alias).
+	Aliases []string
+
+	Required   bool
+	Sensitive  bool
+	Deprecated bool
+}
+
+// Markers returns the flag's REQUIRED, SENSITIVE, and DEPRECATED
+// markers.
+func (hf HelpFlag) Markers() []string {
+	markers := []string{}
+	if hf.Required {
+		markers = append(markers, "REQUIRED")
+	}
+	if hf.Sensitive {
+		markers = append(markers, "SENSITIVE")
+	}
+	if hf.Deprecated {
+		markers = append(markers, "DEPRECATED")
+	}
+	return markers
+}
+
+// NewHelp produces a Help for the flags of the given FlagSet. If the
+// FlagSet is scoped, only flags within its scope are included. If
+// envScopes are given, each flag's environment key is computed as with
+// NewFromEnv. Flags marked hidden (see usage.Hidden) are omitted.
+// Flags are grouped using the scope descriptions recorded by the
+// FlagSet (see ScopeDescriptions).
+func NewHelp(fs FlagSet, name, summary string, envScopes ...string) *Help {
+	return NewHelpWithScopes(fs, ScopeDescriptions(fs), name, summary, envScopes...)
+}
+
+// NewHelpWithScopes is like NewHelp, but groups flags using the given
+// scope descriptions, keyed by prefix (including its trailing period),
+// rather than those recorded by the FlagSet.
+func NewHelpWithScopes(
+	fs FlagSet,
+	descriptions map[string]string,
+	name string,
+	summary string,
+	envScopes ...string,
+) *Help {
+	envPrefix := ""
+	if len(envScopes) > 0 {
+		envPrefix = EnvKey(envScopes...)
+	}
+
+	root := fs.GetScope()
+
+	aliases := map[string][]string{}
+	fs.Unwrap().VisitAll(func(f *flag.Flag) {
+		if target, ok := AliasTarget(f); ok {
+			aliases[target] = append(aliases[target], f.Name)
+		}
+	})
+
+	groups := map[string]*HelpGroup{}
+	fs.Unwrap().VisitAll(func(f *flag.Flag) {
+		if !strings.HasPrefix(f.Name, root) {
+			return
+		}
+
+		if _, isAlias := AliasTarget(f); isAlias {
+			return
+		}
+
+		if usage.IsHidden(f) {
+			return
+		}
+
+		scope := flagScope(f.Name, descriptions)
+		group, ok := groups[scope]
+		if !ok {
+			group = &HelpGroup{Scope: scope, Description: descriptions[scope]}
+			groups[scope] = group
+		}
+
+		hf := newHelpFlag(f, envPrefix)
+		hf.Aliases = aliases[f.Name]
+		group.Flags = append(group.Flags, hf)
+	})
+
+	scopes := make([]string, 0, len(groups))
+	for scope := range groups {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	help := &Help{Name: name, Summary: summary}
+	for _, scope := range scopes {
+		help.Groups = append(help.Groups, *groups[scope])
+	}
+
+	return help
+}
+
+// flagScope returns the longest known scope prefix of the given flag
+// name or, if there is none, the portion of the name up to and
+// including its last period.
+func flagScope(name string, known map[string]string) string {
+	longest := ""
+	for prefix := range known {
+		if strings.HasPrefix(name, prefix) && len(prefix) > len(longest) {
+			longest = prefix
+		}
+	}
+
+	if longest != "" {
+		return longest
+	}
+
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[0 : idx+1]
+	}
+
+	return ""
+}
+
+func newHelpFlag(f *flag.Flag, envPrefix string) HelpFlag {
+	u := usage.New(f.Usage)
+
+	// UnquoteUsage derives the type name from the flag's Value
+	// and any back-quoted name in its usage.
+	typeName, text := flag.UnquoteUsage(
+		&flag.Flag{Name: f.Name, Usage: u.Usage(), Value: f.Value},
+	)
+
+	hf := HelpFlag{
+		Name:       f.Name,
+		Type:       typeName,
+		Usage:      text,
+		Required:   u.IsRequired(),
+		Sensitive:  u.IsSensitive(),
+		Deprecated: u.IsDeprecated(),
+	}
+
+	// Boolean flags have no type name and default to false.
+	if !hf.Sensitive && !(hf.Type == "" && f.DefValue == "false") {
+		hf.Default = f.DefValue
+	}
+
+	if cv, ok := f.Value.(ConstrainedValue); ok {
+		hf.AllowedValues = cv.ValidValuesDescription()
+	}
+
+	if envPrefix != "" {
+		hf.EnvKey = EnvKey(envPrefix, f.Name)
+	}
+
+	return hf
+}
+
+func (g HelpGroup) title() string {
+	switch {
+	case g.Scope == "":
+		return "Flags"
+	case g.Description == "":
+		return fmt.Sprintf("Flags for %s*", g.Scope)
+	default:
+		return fmt.Sprintf("Flags for %s (%s*)", g.Description, g.Scope)
+	}
+}
+
+// WriteText writes the Help to w as terminal help text.
+func (h *Help) WriteText(w io.Writer) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "Usage: %s [flags]\n", h.Name)
+	if h.Summary != "" {
+		fmt.Fprintf(b, "\n%s\n", h.Summary)
+	}
+
+	for _, g := range h.Groups {
+		fmt.Fprintf(b, "\n%s:\n", g.title())
+		for _, f := range g.Flags {
+			fmt.Fprintf(b, "  -%s", f.Name)
+			if f.Type != "" {
+				fmt.Fprintf(b, " %s", f.Type)
+			}
+			b.WriteString("\n")
+
+			text := f.Usage
+			if markers := f.Markers(); len(markers) > 0 {
+				text = fmt.Sprintf("[%s] %s", strings.Join(markers, "/"), text)
+			}
+			fmt.Fprintf(b, "        %s\n", text)
+
+			if f.AllowedValues != "" {
+				fmt.Fprintf(b, "        Allowed values: %s\n", f.AllowedValues)
+			}
+			if f.Default != "" {
+				fmt.Fprintf(b, "        Default: %s\n", f.Default)
+			}
+			if f.EnvKey != "" {
+				fmt.Fprintf(b, "        Environment: %s\n", f.EnvKey)
+			}
+			if len(f.Aliases) > 0 {
+				fmt.Fprintf(b, "        Deprecated aliases: -%s\n", strings.Join(f.Aliases, ", -"))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteMarkdown writes the Help to w as markdown, with a table of flags
+// for each scope.
+func (h *Help) WriteMarkdown(w io.Writer) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, "# %s\n", h.Name)
+	if h.Summary != "" {
+		fmt.Fprintf(b, "\n%s\n", h.Summary)
+	}
+
+	for _, g := range h.Groups {
+		fmt.Fprintf(b, "\n## %s\n\n", g.title())
+		b.WriteString("| Flag | Type | Default | Environment | Description |\n")
+		b.WriteString("| ---- | ---- | ------- | ----------- | ----------- |\n")
+
+		for _, f := range g.Flags {
+			desc := []string{}
+			for _, marker := range f.Markers() {
+				desc = append(desc, "**"+marker+"**")
+			}
+			desc = append(desc, markdownEscape(f.Usage))
+			if f.AllowedValues != "" {
+				desc = append(desc, "Allowed values: "+markdownEscape(f.AllowedValues)+".")
+			}
+			if len(f.Aliases) > 0 {
+				desc = append(
+					desc,
+					"Deprecated aliases: `-"+strings.Join(f.Aliases, "`, `-")+"`.",
+				)
+			}
+
+			fmt.Fprintf(
+				b,
+				"| `-%s` | %s | %s | %s | %s |\n",
+				f.Name,
+				f.Type,
+				markdownCode(f.Default),
+				markdownCode(f.EnvKey),
+				strings.Join(desc, " "),
+			)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func markdownEscape(s string) string {
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Replace(s, "\n", " ", -1)
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownEscape(s) + "`"
+}
+
+// WriteMan writes the Help to w as a roff man page in the given
+// section.
+func (h *Help) WriteMan(w io.Writer, section int) error {
+	b := &strings.Builder{}
+
+	fmt.Fprintf(b, ".TH %s %d\n", roffEscape(strings.ToUpper(h.Name)), section)
+	b.WriteString(".SH NAME\n")
+	if h.Summary != "" {
+		fmt.Fprintf(b, "%s \\- %s\n", roffEscape(h.Name), roffEscape(h.Summary))
+	} else {
+		fmt.Fprintf(b, "%s\n", roffEscape(h.Name))
+	}
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(b, ".B %s\n[\\fIflags\\fR]\n", roffEscape(h.Name))
+
+	if len(h.Groups) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+	}
+
+	for _, g := range h.Groups {
+		if g.Scope != "" {
+			fmt.Fprintf(b, ".SS \"%s\"\n", roffEscape(g.title()))
+		}
+
+		for _, f := range g.Flags {
+			b.WriteString(".TP\n")
+			fmt.Fprintf(b, "\\fB\\-%s\\fR", roffEscape(f.Name))
+			if f.Type != "" {
+				fmt.Fprintf(b, " \\fI%s\\fR", roffEscape(f.Type))
+			}
+			b.WriteString("\n")
+
+			text := roffEscape(f.Usage)
+			if markers := f.Markers(); len(markers) > 0 {
+				text = fmt.Sprintf("[%s] %s", strings.Join(markers, "/"), text)
+			}
+			fmt.Fprintf(b, "%s\n", text)
+
+			if f.AllowedValues != "" {
+				fmt.Fprintf(b, ".br\nAllowed values: %s\n", roffEscape(f.AllowedValues))
+			}
+			if f.Default != "" {
+				fmt.Fprintf(b, ".br\nDefault: %s\n", roffEscape(f.Default))
+			}
+			if f.EnvKey != "" {
+				fmt.Fprintf(b, ".br\nEnvironment: \\fB%s\\fR\n", roffEscape(f.EnvKey))
+			}
+			if len(f.Aliases) > 0 {
+				fmt.Fprintf(
+					b,
+					".br\nDeprecated aliases: \\fB\\-%s\\fR\n",
+					roffEscape(strings.Join(f.Aliases, ", -")),
+				)
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// roffEscape escapes backslashes and hyphens, and prevents lines from
+// being interpreted as roff requests.
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	s = strings.Replace(s, "-", `\-`, -1)
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
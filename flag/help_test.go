@@ -0,0 +1,222 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	"github.com/turbinelabs/test/assert"
+)
+
+func mkHelpFlagSet() TestFlagSet {
+	fs := NewTestFlagSet()
+	fs.Bool("verbose", false, "Enable verbose output.")
+	fs.String("token", "s3cr3t", usage.Sensitive("API `key` | token."))
+	fs.String("internal", "", usage.Hidden("For internal use."))
+
+	exec := fs.Scope("exec", "executor")
+	exec.Duration("timeout", time.Second, usage.Required("Timeout for the {{NAME}}."))
+	choice := NewChoice("a", "b").WithDefault("a")
+	exec.Var(&choice, "mode", usage.Deprecated("Mode of the {{NAME}}."))
+
+	exec.Scope("retry", "").Int("max", 3, "Maximum retries.")
+
+	return fs
+}
+
+func TestNewHelp(t *testing.T) {
+	h := NewHelp(mkHelpFlagSet(), "app", "Does things.", "app")
+	assert.Equal(t, h.Name, "app")
+	assert.Equal(t, h.Summary, "Does things.")
+	assert.DeepEqual(t, h.Groups, []HelpGroup{
+		{
+			Flags: []HelpFlag{
+				{
+					Name:      "token",
+					Type:      "key",
+					Usage:     "API key | token.",
+					EnvKey:    "APP_TOKEN",
+					Sensitive: true,
+				},
+				{
+					Name:   "verbose",
+					Usage:  "Enable verbose output.",
+					EnvKey: "APP_VERBOSE",
+				},
+			},
+		},
+		{
+			Scope:       "exec.",
+			Description: "executor",
+			Flags: []HelpFlag{
+				{
+					Name:          "exec.mode",
+					Type:          "value",
+					Usage:         "Mode of the executor.",
+					Default:       "a",
+					AllowedValues: `"a" or "b"`,
+					EnvKey:        "APP_EXEC_MODE",
+					Deprecated:    true,
+				},
+				{
+					Name:     "exec.timeout",
+					Type:     "duration",
+					Usage:    "Timeout for the executor.",
+					Default:  "1s",
+					EnvKey:   "APP_EXEC_TIMEOUT",
+					Required: true,
+				},
+			},
+		},
+		{
+			Scope: "exec.retry.",
+			Flags: []HelpFlag{
+				{
+					Name:    "exec.retry.max",
+					Type:    "int",
+					Usage:   "Maximum retries.",
+					Default: "3",
+					EnvKey:  "APP_EXEC_RETRY_MAX",
+				},
+			},
+		},
+	})
+}
+
+func TestNewHelpScoped(t *testing.T) {
+	fs := mkHelpFlagSet()
+	h := NewHelp(fs.Scope("exec", "executor"), "app", "")
+	assert.Equal(t, len(h.Groups), 2)
+	assert.Equal(t, h.Groups[0].Scope, "exec.")
+	assert.Equal(t, h.Groups[0].Flags[0].EnvKey, "")
+	assert.Equal(t, h.Groups[1].Scope, "exec.retry.")
+}
+
+func TestHelpMarkers(t *testing.T) {
+	assert.ArrayEqual(t, HelpFlag{}.Markers(), []string{})
+	assert.ArrayEqual(
+		t,
+		HelpFlag{Required: true, Sensitive: true, Deprecated: true}.Markers(),
+		[]string{"REQUIRED", "SENSITIVE", "DEPRECATED"},
+	)
+}
+
+func TestHelpWriteText(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewHelp(mkHelpFlagSet(), "app", "Does things.", "app")
+	assert.Nil(t, h.WriteText(buf))
+	assert.Equal(t, buf.String(), `Usage: app [flags]
+
+Does things.
+
+Flags:
+  -token key
+        [SENSITIVE] API key | token.
+        Environment: APP_TOKEN
+  -verbose
+        Enable verbose output.
+        Environment: APP_VERBOSE
+
+Flags for executor (exec.*):
+  -exec.mode value
+        [DEPRECATED] Mode of the executor.
+        Allowed values: "a" or "b"
+        Default: a
+        Environment: APP_EXEC_MODE
+  -exec.timeout duration
+        [REQUIRED] Timeout for the executor.
+        Default: 1s
+        Environment: APP_EXEC_TIMEOUT
+
+Flags for exec.retry.*:
+  -exec.retry.max int
+        Maximum retries.
+        Default: 3
+        Environment: APP_EXEC_RETRY_MAX
+`)
+}
+
+func TestHelpWriteMarkdown(t *testing.T) {
+	buf := &bytes.Buffer{}
+	h := NewHelp(mkHelpFlagSet().Scope("exec", "executor"), "app", "Does things.")
+	assert.Nil(t, h.WriteMarkdown(buf))
+	assert.Equal(t, buf.String(), "# app\n"+
+		"\n"+
+		"Does things.\n"+
+		"\n"+
+		"## Flags for executor (exec.*)\n"+
+		"\n"+
+		"| Flag | Type | Default | Environment | Description |\n"+
+		"| ---- | ---- | ------- | ----------- | ----------- |\n"+
+		"| `-exec.mode` | value | `a` |  | **DEPRECATED** Mode of the executor. Allowed values: \"a\" or \"b\". |\n"+
+		"| `-exec.timeout` | duration | `1s` |  | **REQUIRED** Timeout for the executor. |\n"+
+		"\n"+
+		"## Flags for exec.retry.*\n"+
+		"\n"+
+		"| Flag | Type | Default | Environment | Description |\n"+
+		"| ---- | ---- | ------- | ----------- | ----------- |\n"+
+		"| `-exec.retry.max` | int | `3` |  | Maximum retries. |\n",
+	)
+
+	buf.Reset()
+	h = NewHelp(mkHelpFlagSet(), "app", "")
+	assert.Nil(t, h.WriteMarkdown(buf))
+	assert.True(
+		t,
+		strings.Contains(
+			buf.String(),
+			"| `-token` | key |  |  | **SENSITIVE** API key \\| token. |\n",
+		),
+	)
+}
+
+func TestHelpWriteMan(t *testing.T) {
+	fs := NewTestFlagSet()
+	fs.String("x-y", `a\b`, ".leading dot")
+	fs.Scope("s", "scoped").Int("n", 1, usage.Required("A number."))
+
+	buf := &bytes.Buffer{}
+	h := NewHelp(fs, "my-app", "Does things.", "my-app")
+	assert.Nil(t, h.WriteMan(buf, 1))
+	assert.Equal(t, buf.String(), `.TH MY\-APP 1
+.SH NAME
+my\-app \- Does things.
+.SH SYNOPSIS
+.B my\-app
+[\fIflags\fR]
+.SH OPTIONS
+.TP
+\fB\-x\-y\fR \fIstring\fR
+\&.leading dot
+.br
+Default: a\eb
+.br
+Environment: \fBMY_APP_X_Y\fR
+.SS "Flags for scoped (s.*)"
+.TP
+\fB\-s.n\fR \fIint\fR
+[REQUIRED] A number.
+.br
+Default: 1
+.br
+Environment: \fBMY_APP_S_N\fR
+`)
+}
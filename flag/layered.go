@@ -0,0 +1,242 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+//go:generate mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE --write_package_comment=false
+
+import (
+	"flag"
+	"fmt"
+
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+)
+
+// Source identifies where a flag's value came from.
+type Source string
+
+const (
+	// CommandLineSource indicates a value set by parsing the
+	// command line.
+	CommandLineSource Source = "command line"
+
+	// EnvSource indicates a value set from an environment
+	// variable.
+	EnvSource Source = "environment"
+
+	// FileSource indicates a value set from a config file.
+	FileSource Source = "config file"
+
+	// DefaultSource indicates the flag's default value.
+	DefaultSource Source = "default"
+)
+
+// Layer supplies flag values from a single source, for use with
+// NewLayered.
+type Layer interface {
+	// Source identifies the kind of source.
+	Source() Source
+
+	// Load prepares the Layer for calls to Lookup. It is invoked
+	// at the start of each call to Layered.Fill.
+	Load() error
+
+	// Lookup returns the value supplied for the named flag, if
+	// any, along with the key under which it was found (for
+	// example, an environment variable name).
+	Lookup(name string) (key, value string, found bool)
+}
+
+// NewCommandLineLayer produces a Layer containing the flags set when
+// the given FlagSet was parsed. The set flags are recorded the first
+// time the Layer is loaded, which must be after the FlagSet is parsed
+// and before any other Layer fills it.
+func NewCommandLineLayer(fs *flag.FlagSet) Layer {
+	return &commandLineLayer{fs: fs}
+}
+
+type commandLineLayer struct {
+	fs  *flag.FlagSet
+	set map[string]bool
+}
+
+func (l *commandLineLayer) Source() Source {
+	return CommandLineSource
+}
+
+func (l *commandLineLayer) Load() error {
+	if l.set == nil {
+		l.set = map[string]bool{}
+		l.fs.Visit(func(f *flag.Flag) {
+			l.set[f.Name] = true
+		})
+	}
+
+	return nil
+}
+
+func (l *commandLineLayer) Lookup(name string) (string, string, bool) {
+	if !l.set[name] {
+		return "", "", false
+	}
+
+	return name, l.fs.Lookup(name).Value.String(), true
+}
+
+// NewEnvLayer produces a Layer supplying values from environment
+// variables. Keys are constructed from the scopes and flag names as
+// with FromEnv.
+func NewEnvLayer(scopes ...string) Layer {
+	return &envLayer{prefix: EnvKey(scopes...), os: tbnos.New()}
+}
+
+type envLayer struct {
+	prefix string
+	os     tbnos.OS
+}
+
+func (l *envLayer) Source() Source {
+	return EnvSource
+}
+
+func (l *envLayer) Load() error {
+	return nil
+}
+
+func (l *envLayer) Lookup(name string) (string, string, bool) {
+	key := EnvKey(l.prefix, name)
+	value, found := l.os.LookupEnv(key)
+	return key, value, found
+}
+
+// Origin records the Source, and the key within that Source, that
+// supplied a flag's value.
+type Origin struct {
+	Source Source `json:"source"`
+	Key    string `json:"key,omitempty"`
+}
+
+// Layered fills a FlagSet from multiple Layers, in priority order.
+type Layered interface {
+	// Fill loads each Layer and sets each flag from the first
+	// Layer, in priority order, that supplies a value for it.
+	// Flags not supplied by any Layer retain their current
+	// values. Returns the first error encountered.
+	Fill() error
+
+	// Origins returns the Origin of every flag in the FlagSet as
+	// of the last call to Fill, keyed by flag name.
+	Origins() map[string]Origin
+}
+
+// NewLayered produces a Layered that fills the given FlagSet from the
+// given Layers. Layers are consulted in the order given; earlier
+// Layers take precedence. Flags not supplied by any Layer have
+// DefaultSource. The conventional order is:
+//
+//	NewLayered(
+//	    fs,
+//	    NewCommandLineLayer(fs),
+//	    NewEnvLayer("myapp"),
+//	    NewFromFile(fs, "/etc/myapp.conf"),
+//	)
+//
+// which gives the command line precedence over the environment,
+// the environment precedence over the config file, and the config
+// file precedence over defaults.
+func NewLayered(fs *flag.FlagSet, layers ...Layer) Layered {
+	return &layered{
+		fs:      fs,
+		layers:  layers,
+		origins: map[string]Origin{},
+	}
+}
+
+type layered struct {
+	fs      *flag.FlagSet
+	layers  []Layer
+	origins map[string]Origin
+}
+
+func (l *layered) Fill() error {
+	origins, settings, err := resolveLayers(l.fs, l.layers)
+	if err != nil {
+		return err
+	}
+	l.origins = origins
+
+	var firstErr error
+	for _, s := range settings {
+		if err := l.fs.Set(s.name, s.value); err != nil && firstErr == nil {
+			firstErr = s.wrapErr(err)
+		}
+	}
+
+	return firstErr
+}
+
+func (l *layered) Origins() map[string]Origin {
+	return l.origins
+}
+
+// layerSetting is a value supplied for a flag by a Layer other than
+// the command line.
+type layerSetting struct {
+	name   string
+	value  string
+	origin Origin
+}
+
+func (s layerSetting) wrapErr(err error) error {
+	return fmt.Errorf("%s %s: %s", s.origin.Source, s.origin.Key, err.Error())
+}
+
+// resolveLayers loads the given Layers and determines the Origin of
+// each flag in the FlagSet, along with the values to be set for flags
+// supplied by Layers other than the command line. Every flag is
+// resolved before any are set, so that Layers inspecting the FlagSet
+// see it unchanged.
+func resolveLayers(
+	fs *flag.FlagSet,
+	layers []Layer,
+) (map[string]Origin, []layerSetting, error) {
+	for _, layer := range layers {
+		if err := layer.Load(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	settings := []layerSetting{}
+	origins := map[string]Origin{}
+	fs.VisitAll(func(f *flag.Flag) {
+		origin := Origin{Source: DefaultSource}
+		for _, layer := range layers {
+			key, value, found := layer.Lookup(f.Name)
+			if !found {
+				continue
+			}
+
+			origin = Origin{Source: layer.Source(), Key: key}
+			if origin.Source != CommandLineSource {
+				settings = append(settings, layerSetting{f.Name, value, origin})
+			}
+			break
+		}
+		origins[f.Name] = origin
+	})
+
+	return origins, settings, nil
+}
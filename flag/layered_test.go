@@ -0,0 +1,158 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"errors"
+	"flag"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+	"github.com/turbinelabs/test/assert"
+)
+
+type layeredTestFlags struct {
+	fs          *flag.FlagSet
+	a, b, c, d  *string
+	fileLayer   FromFile
+	cleanupFile func()
+}
+
+func mkLayeredTestFlags(t *testing.T) *layeredTestFlags {
+	fs := flag.NewFlagSet("layered", flag.PanicOnError)
+	ltf := &layeredTestFlags{
+		fs: fs,
+		a:  fs.String("a", "a-default", "a"),
+		b:  fs.String("b", "b-default", "b"),
+		c:  fs.String("c", "c-default", "c"),
+		d:  fs.String("d", "d-default", "d"),
+	}
+
+	path, cleanup := writeConfigFile(t, "config", "a = a-file\nb = b-file\nc = c-file\n")
+	ltf.fileLayer = NewFromFile(fs, path)
+	ltf.cleanupFile = cleanup
+
+	return ltf
+}
+
+func mkTestEnvLayer(ctrl *gomock.Controller, env map[string]string) Layer {
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().LookupEnv(gomock.Any()).AnyTimes().DoAndReturn(
+		func(key string) (string, bool) {
+			v, ok := env[key]
+			return v, ok
+		},
+	)
+
+	return &envLayer{prefix: EnvKey("app"), os: mockOS}
+}
+
+func TestLayeredFill(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	ltf := mkLayeredTestFlags(t)
+	defer ltf.cleanupFile()
+
+	ltf.fs.Parse([]string{"-a=a-cmdline"})
+
+	l := NewLayered(
+		ltf.fs,
+		NewCommandLineLayer(ltf.fs),
+		mkTestEnvLayer(ctrl, map[string]string{"APP_A": "a-env", "APP_B": "b-env"}),
+		ltf.fileLayer,
+	)
+
+	assert.Nil(t, l.Fill())
+	assert.Equal(t, *ltf.a, "a-cmdline")
+	assert.Equal(t, *ltf.b, "b-env")
+	assert.Equal(t, *ltf.c, "c-file")
+	assert.Equal(t, *ltf.d, "d-default")
+
+	assert.DeepEqual(t, l.Origins(), map[string]Origin{
+		"a": {Source: CommandLineSource, Key: "a"},
+		"b": {Source: EnvSource, Key: "APP_B"},
+		"c": {Source: FileSource, Key: "c"},
+		"d": {Source: DefaultSource},
+	})
+
+	// Filling again does not mistake filled values for command
+	// line values.
+	assert.Nil(t, l.Fill())
+	assert.Equal(t, l.Origins()["b"], Origin{Source: EnvSource, Key: "APP_B"})
+}
+
+func TestLayeredFillCustomOrder(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	ltf := mkLayeredTestFlags(t)
+	defer ltf.cleanupFile()
+
+	ltf.fs.Parse([]string{"-a=a-cmdline"})
+
+	l := NewLayered(
+		ltf.fs,
+		ltf.fileLayer,
+		mkTestEnvLayer(ctrl, map[string]string{"APP_D": "d-env"}),
+		NewCommandLineLayer(ltf.fs),
+	)
+
+	assert.Nil(t, l.Fill())
+	assert.Equal(t, *ltf.a, "a-file")
+	assert.Equal(t, *ltf.b, "b-file")
+	assert.Equal(t, *ltf.c, "c-file")
+	assert.Equal(t, *ltf.d, "d-env")
+
+	assert.Equal(t, l.Origins()["a"], Origin{Source: FileSource, Key: "a"})
+	assert.Equal(t, l.Origins()["d"], Origin{Source: EnvSource, Key: "APP_D"})
+}
+
+func TestLayeredFillLoadError(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	fs := flag.NewFlagSet("layered", flag.PanicOnError)
+	fs.String("a", "", "a")
+
+	layer := NewMockLayer(ctrl)
+	layer.EXPECT().Load().Return(errors.New("boom"))
+
+	l := NewLayered(fs, layer)
+	assert.ErrorContains(t, l.Fill(), "boom")
+	assert.Equal(t, len(l.Origins()), 0)
+}
+
+func TestLayeredFillSetError(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	fs := flag.NewFlagSet("layered", flag.ContinueOnError)
+	i := fs.Int("i", 0, "i")
+	j := fs.Int("j", 0, "j")
+
+	l := NewLayered(
+		fs,
+		mkTestEnvLayer(ctrl, map[string]string{"APP_I": "nope", "APP_J": "2"}),
+	)
+
+	assert.ErrorContains(t, l.Fill(), "environment APP_I: ")
+	assert.Equal(t, *i, 0)
+	assert.Equal(t, *j, 2)
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Percent conforms to the flag.Value, flag.Getter, and
+// ConstrainedValue interfaces. It can be used to populate a ratio
+// from a command line argument given either as a percentage ("75%")
+// or as a fraction ("0.75"). In both cases the Value is the fraction
+// (0.75).
+//
+// Optional minimum and maximum bounds, expressed as fractions, may be
+// set with WithMin and WithMax.
+type Percent struct {
+	Value float64
+
+	min, max       float64
+	hasMin, hasMax bool
+}
+
+var _ ConstrainedValue = &Percent{}
+var _ flag.Getter = &Percent{}
+
+// NewPercent produces a Percent with the given default value,
+// expressed as a fraction.
+func NewPercent(defaultValue float64) Percent {
+	return Percent{Value: defaultValue}
+}
+
+// WithMin returns a copy of the Percent that rejects values smaller
+// than min, expressed as a fraction.
+func (p Percent) WithMin(min float64) Percent {
+	p.min = min
+	p.hasMin = true
+	return p
+}
+
+// WithMax returns a copy of the Percent that rejects values larger
+// than max, expressed as a fraction.
+func (p Percent) WithMax(max float64) Percent {
+	p.max = max
+	p.hasMax = true
+	return p
+}
+
+// ValidValuesDescription returns a string describing the allowed
+// values for this Percent. For example: "a percentage between 0% and
+// 100%".
+func (p *Percent) ValidValuesDescription() string {
+	return "a percentage" + boundsDescription(
+		p.hasMin,
+		p.hasMax,
+		formatPercent(p.min),
+		formatPercent(p.max),
+	)
+}
+
+// Get retrieves the current value of the Percent as a float64
+// fraction.
+func (p *Percent) Get() interface{} {
+	return p.Value
+}
+
+// String returns the current value of the Percent as a
+// percentage. For example: "75%".
+func (p *Percent) String() string {
+	if p == nil {
+		return ""
+	}
+	return formatPercent(p.Value)
+}
+
+// Set sets the current value of the Percent, returning an error if
+// the value cannot be parsed or is out of bounds.
+func (p *Percent) Set(value string) error {
+	trimmed := strings.TrimSpace(value)
+
+	divisor := 1.0
+	if strings.HasSuffix(trimmed, "%") {
+		trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, "%"))
+		divisor = 100.0
+	}
+
+	f, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("invalid percentage: %s", value)
+	}
+	f /= divisor
+
+	if (p.hasMin && f < p.min) || (p.hasMax && f > p.max) {
+		return fmt.Errorf(
+			"invalid flag value: %s, must be %s",
+			value,
+			p.ValidValuesDescription(),
+		)
+	}
+
+	p.Value = f
+	return nil
+}
+
+func formatPercent(f float64) string {
+	return strconv.FormatFloat(f*100, 'g', 12, 64) + "%"
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"testing"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestPercentSet(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected float64
+	}{
+		{"75%", 0.75},
+		{"0.75", 0.75},
+		{"150%", 1.5},
+		{" 5 % ", 0.05},
+		{"0", 0},
+		{"-10%", -0.1},
+	}
+
+	for _, tc := range testCases {
+		assert.Group(tc.value, t, func(g *assert.G) {
+			p := NewPercent(0.5)
+			assert.Nil(g, p.Set(tc.value))
+			assert.Equal(g, p.Value, tc.expected)
+			assert.Equal(g, p.Get(), tc.expected)
+		})
+	}
+}
+
+func TestPercentSetErrors(t *testing.T) {
+	p := NewPercent(0.5)
+	assert.ErrorContains(t, p.Set(""), "invalid percentage: ")
+	assert.ErrorContains(t, p.Set("%"), "invalid percentage: %")
+	assert.ErrorContains(t, p.Set("lots"), "invalid percentage: lots")
+	assert.ErrorContains(t, p.Set("NaN"), "invalid percentage: NaN")
+	assert.ErrorContains(t, p.Set("Inf%"), "invalid percentage: Inf%")
+	assert.ErrorContains(t, p.Set("-inf"), "invalid percentage: -inf")
+	assert.Equal(t, p.Value, 0.5)
+
+	p = NewPercent(0.5).WithMin(0).WithMax(1)
+	assert.ErrorContains(t, p.Set("nan%"), "invalid percentage: nan%")
+	assert.Equal(t, p.Value, 0.5)
+}
+
+func TestPercentBounds(t *testing.T) {
+	p := NewPercent(0.5).WithMin(0).WithMax(1)
+	assert.Equal(t, p.ValidValuesDescription(), "a percentage between 0% and 100%")
+
+	assert.Nil(t, p.Set("0%"))
+	assert.Nil(t, p.Set("1"))
+	assert.ErrorContains(
+		t,
+		p.Set("101%"),
+		"invalid flag value: 101%, must be a percentage between 0% and 100%",
+	)
+	assert.ErrorContains(t, p.Set("-0.01"), "must be a percentage between 0% and 100%")
+	assert.Equal(t, p.Value, 1.0)
+
+	p = NewPercent(0).WithMin(0.25)
+	assert.Equal(t, p.ValidValuesDescription(), "a percentage of at least 25%")
+
+	p = NewPercent(0).WithMax(0.9)
+	assert.Equal(t, p.ValidValuesDescription(), "a percentage of at most 90%")
+
+	p = NewPercent(0)
+	assert.Equal(t, p.ValidValuesDescription(), "a percentage")
+}
+
+func TestPercentString(t *testing.T) {
+	testCases := map[float64]string{
+		0:     "0%",
+		0.07:  "7%",
+		0.75:  "75%",
+		0.333: "33.3%",
+		1.5:   "150%",
+	}
+
+	for value, expected := range testCases {
+		p := NewPercent(value)
+		assert.Equal(t, p.String(), expected)
+	}
+
+	var nilPercent *Percent
+	assert.Equal(t, nilPercent.String(), "")
+}
@@ -43,6 +43,10 @@ func newPrefixedFlagSet(fs FlagSet, prefix, descriptor string) *prefixedFlagSet
 		prefix = prefix + "."
 	}
 
+	if r, ok := fs.(scopeRegistry); ok && prefix != "" {
+		r.recordScope(prefix, descriptor)
+	}
+
 	return &prefixedFlagSet{
 		FlagSet:    fs,
 		prefix:     prefix,
@@ -68,6 +72,34 @@ func (f *prefixedFlagSet) HostPort(name string, value HostPort, usage string) *H
 	return f.FlagSet.HostPort(f.prefix+name, value, f.mkUsage(usage))
 }
 
+func (f *prefixedFlagSet) BytesVar(b *Bytes, name string, value Bytes, usage string) {
+	f.FlagSet.BytesVar(b, f.prefix+name, value, f.mkUsage(usage))
+}
+
+func (f *prefixedFlagSet) Bytes(name string, value Bytes, usage string) *Bytes {
+	return f.FlagSet.Bytes(f.prefix+name, value, f.mkUsage(usage))
+}
+
+func (f *prefixedFlagSet) PercentVar(p *Percent, name string, value Percent, usage string) {
+	f.FlagSet.PercentVar(p, f.prefix+name, value, f.mkUsage(usage))
+}
+
+func (f *prefixedFlagSet) Percent(name string, value Percent, usage string) *Percent {
+	return f.FlagSet.Percent(f.prefix+name, value, f.mkUsage(usage))
+}
+
+func (f *prefixedFlagSet) RateVar(r *Rate, name string, value Rate, usage string) {
+	f.FlagSet.RateVar(r, f.prefix+name, value, f.mkUsage(usage))
+}
+
+func (f *prefixedFlagSet) Rate(name string, value Rate, usage string) *Rate {
+	return f.FlagSet.Rate(f.prefix+name, value, f.mkUsage(usage))
+}
+
+func (f *prefixedFlagSet) Alias(old, new string) {
+	f.FlagSet.Alias(f.prefix+old, f.prefix+new)
+}
+
 // Scope scopes the target prefixedFlagSet to produce a new FlagSet,
 // with the given scope an descriptor.
 func (f *prefixedFlagSet) Scope(prefix, descriptor string) FlagSet {
@@ -77,6 +109,19 @@ func (f *prefixedFlagSet) Scope(prefix, descriptor string) FlagSet {
 	return newPrefixedFlagSet(f.FlagSet, f.prefix+prefix, descriptor)
 }
 
+func (f *prefixedFlagSet) recordScope(prefix, description string) {
+	if r, ok := f.FlagSet.(scopeRegistry); ok {
+		r.recordScope(prefix, description)
+	}
+}
+
+func (f *prefixedFlagSet) scopeDescriptions() map[string]string {
+	if r, ok := f.FlagSet.(scopeRegistry); ok {
+		return r.scopeDescriptions()
+	}
+	return nil
+}
+
 func (f *prefixedFlagSet) GetScope() string {
 	return f.prefix
 }
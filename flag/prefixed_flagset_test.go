@@ -210,3 +210,30 @@ func TestGetScope(t *testing.T) {
 	scopedAgain := scoped.Scope("more", "")
 	assert.Equal(t, scopedAgain.GetScope(), "theprefix.more.")
 }
+
+func TestBytesPercentAndRate(t *testing.T) {
+	fs := NewTestFlagSet()
+
+	pfs := fs.Scope("theprefix", "the-app-name")
+
+	b := pfs.Bytes("bytes", NewBytes(1024), flagUsage)
+	var p Percent
+	pfs.PercentVar(&p, "percent", NewPercent(0.5), flagUsage)
+	r := pfs.Rate("rate", NewRate(1, time.Second), flagUsage)
+
+	fs.Parse([]string{
+		"-theprefix.bytes=1.5GB",
+		"-theprefix.percent=75%",
+		"-theprefix.rate=5/min",
+	})
+
+	assert.Equal(t, b.Value, uint64(1500000000))
+	assert.Equal(t, p.Value, 0.75)
+	assert.Equal(t, r.String(), "5/min")
+
+	for _, name := range []string{"bytes", "percent", "rate"} {
+		f := fs.Unwrap().Lookup("theprefix." + name)
+		assert.NonNil(t, f)
+		assert.Equal(t, f.Usage, fmt.Sprintf(flagUsageFmt, "the-app-name", "theprefix."))
+	}
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+//go:generate mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE --write_package_comment=false
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/turbinelabs/nonstdlib/arrays/indexof"
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+)
+
+// NewPrompter produces a Prompter that reads values for flags in the
+// given FlagSet from the OS's Stdin and writes prompts to its Stdout.
+func NewPrompter(fs *flag.FlagSet, o tbnos.OS) Prompter {
+	return &prompter{
+		fs:         fs,
+		os:         o,
+		setEcho:    setTerminalEcho,
+		isTerminal: IsTerminal,
+		interrupts: notifyInterrupts,
+	}
+}
+
+// Prompter interactively requests values for flags. Each value is
+// validated by the flag's Set method, and the prompt is repeated
+// until a valid value is given. Input for flags marked sensitive is
+// not echoed when Stdin is a terminal whose echo can be disabled (via
+// stty); otherwise a warning is written before reading. If the process
+// is interrupted while echo is disabled, echo is restored and the
+// process exits with status 130. For flags with a fixed set of
+// allowed values, such as Choice, the values are listed and may be
+// selected by number, unless the input is itself an allowed value.
+type Prompter interface {
+	// Prompt requests a value for each of the named flags, in
+	// order. It returns an error if a flag is not defined or if
+	// Stdin is exhausted before a valid value is given.
+	Prompt(names ...string) error
+
+	// PromptMissing requests a value for each required flag that
+	// has not been set (see usage.MissingRequired).
+	PromptMissing() error
+}
+
+// IsTerminal returns true if the OS's Stdin is a terminal. Callers
+// should typically only prompt for missing flags if it is.
+func IsTerminal(o tbnos.OS) bool {
+	f, ok := o.Stdin().(*os.File)
+	if !ok {
+		return false
+	}
+
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+type prompter struct {
+	fs         *flag.FlagSet
+	os         tbnos.OS
+	in         *bufio.Reader
+	setEcho    func(in io.Reader, on bool) error
+	isTerminal func(tbnos.OS) bool
+	interrupts func() (<-chan os.Signal, func())
+}
+
+func (p *prompter) PromptMissing() error {
+	return p.Prompt(usage.MissingRequired(p.fs)...)
+}
+
+func (p *prompter) Prompt(names ...string) error {
+	for _, name := range names {
+		f := p.fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag -%s", name)
+		}
+
+		if err := p.prompt(f); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *prompter) prompt(f *flag.Flag) error {
+	out := p.os.Stdout()
+	u := usage.New(f.Usage)
+	choices := allowedValues(f.Value)
+
+	if text := u.Usage(); text != "" {
+		fmt.Fprintf(out, "-%s: %s\n", f.Name, text)
+	}
+	for i, c := range choices {
+		fmt.Fprintf(out, "  %d) %s\n", i+1, c)
+	}
+
+	for {
+		prompt := fmt.Sprintf("Enter a value for -%s", f.Name)
+		hidden := false
+		if u.IsSensitive() {
+			if err := p.setEcho(p.os.Stdin(), false); err == nil {
+				hidden = true
+				prompt += " (input hidden)"
+			} else if p.isTerminal(p.os) {
+				fmt.Fprintf(out, "WARNING: input for -%s will be visible: %s\n", f.Name, err)
+			}
+		}
+		fmt.Fprint(out, prompt+": ")
+
+		value, err := p.readLine(hidden)
+		if value == "" && err != nil {
+			if err == io.EOF {
+				err = errors.New("end of input")
+			}
+			return fmt.Errorf("no value given for -%s: %s", f.Name, err)
+		}
+
+		if value == "" {
+			fmt.Fprintln(out, "A value is required.")
+			continue
+		}
+
+		// an allowed value takes precedence over a selection number
+		if indexof.String(choices, value) == indexof.NotFound {
+			if n, convErr := strconv.Atoi(value); convErr == nil && n >= 1 && n <= len(choices) {
+				value = choices[n-1]
+			}
+		}
+
+		if setErr := p.fs.Set(f.Name, value); setErr != nil {
+			msg := setErr.Error()
+			if u.IsSensitive() {
+				msg = redact(msg, value)
+			}
+			fmt.Fprintf(out, "Invalid value: %s\n", msg)
+			continue
+		}
+
+		return nil
+	}
+}
+
+// readLine reads a line from Stdin, without its line ending. If
+// hidden is true, terminal echo has been disabled and is restored
+// after reading, or if the process is interrupted while reading.
+func (p *prompter) readLine(hidden bool) (string, error) {
+	stdin := p.os.Stdin()
+	if p.in == nil {
+		p.in = bufio.NewReader(stdin)
+	}
+
+	if hidden {
+		// restore may be called by both the interrupt handler and
+		// the deferred cleanup
+		var once sync.Once
+		restore := func() {
+			once.Do(func() {
+				p.setEcho(stdin, true)
+				// the user's newline was not echoed
+				fmt.Fprintln(p.os.Stdout())
+			})
+		}
+
+		sigs, stop := p.interrupts()
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-sigs:
+				restore()
+				p.os.Exit(130)
+			case <-done:
+			}
+		}()
+
+		defer func() {
+			stop()
+			close(done)
+			restore()
+		}()
+	}
+
+	line, err := p.in.ReadString('\n')
+	return strings.TrimRight(line, "\r\n"), err
+}
+
+// notifyInterrupts returns a channel receiving interrupt signals and
+// a function that stops delivery to it.
+func notifyInterrupts() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, os.Interrupt)
+	return ch, func() { signal.Stop(ch) }
+}
+
+// setTerminalEcho enables or disables echo on the given input, if it
+// is a terminal. It returns an error if the input is not a terminal
+// or if echo cannot be changed.
+func setTerminalEcho(in io.Reader, on bool) error {
+	f, ok := in.(*os.File)
+	if !ok {
+		return errors.New("input is not a terminal")
+	}
+
+	arg := "-echo"
+	if on {
+		arg = "echo"
+	}
+
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = f
+	return cmd.Run()
+}
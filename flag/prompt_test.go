@@ -0,0 +1,284 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+	"github.com/turbinelabs/test/assert"
+)
+
+type echoCall struct {
+	on bool
+}
+
+func mkTestPrompter(
+	t *testing.T,
+	fs FlagSet,
+	input string,
+) (*prompter, *bytes.Buffer, *[]echoCall, func()) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+
+	stdin := strings.NewReader(input)
+	stdout := &bytes.Buffer{}
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().Stdin().Return(stdin).AnyTimes()
+	mockOS.EXPECT().Stdout().Return(stdout).AnyTimes()
+
+	calls := &[]echoCall{}
+	p := NewPrompter(fs.Unwrap(), mockOS).(*prompter)
+	p.setEcho = func(in io.Reader, on bool) error {
+		assert.SameInstance(t, in, stdin)
+		*calls = append(*calls, echoCall{on})
+		return nil
+	}
+	p.interrupts = func() (<-chan os.Signal, func()) { return nil, func() {} }
+
+	return p, stdout, calls, ctrl.Finish
+}
+
+func TestPrompterPromptMissing(t *testing.T) {
+	fs := NewTestFlagSet()
+	name := fs.String("name", "", usage.Required("The name."))
+	count := fs.Int("count", 0, usage.Required("The count."))
+	optional := fs.String("optional", "", "Not required.")
+	set := fs.String("set", "", usage.Required("Already set."))
+	assert.Nil(t, fs.Parse([]string{"-set=x"}))
+
+	p, stdout, calls, finish := mkTestPrompter(t, fs, "\nlots\n3\r\nbob\n")
+	defer finish()
+
+	assert.Nil(t, p.PromptMissing())
+	assert.Equal(t, *name, "bob")
+	assert.Equal(t, *count, 3)
+	assert.Equal(t, *optional, "")
+	assert.Equal(t, *set, "x")
+	assert.Equal(t, len(*calls), 0)
+	assert.Equal(t, len(usage.MissingRequired(fs.Unwrap())), 0)
+
+	assert.Equal(
+		t,
+		stdout.String(),
+		"-count: The count.\n"+
+			"Enter a value for -count: "+
+			"A value is required.\n"+
+			"Enter a value for -count: "+
+			"Invalid value: parse error\n"+
+			"Enter a value for -count: "+
+			"-name: The name.\n"+
+			"Enter a value for -name: ",
+	)
+}
+
+func TestPrompterChoices(t *testing.T) {
+	fs := NewTestFlagSet()
+	choice := NewChoice("fast", "safe")
+	fs.Var(&choice, "mode", "The mode.")
+	other := NewChoiceOf("x", "y")
+	fs.Var(&other, "other", "")
+
+	p, stdout, _, finish := mkTestPrompter(t, fs, "slow\n3\nsafe\n1\n")
+	defer finish()
+
+	assert.Nil(t, p.Prompt("mode", "other"))
+	assert.Equal(t, *choice.Choice, "safe")
+	assert.Equal(t, other.Value(), "x")
+
+	assert.Equal(
+		t,
+		stdout.String(),
+		"-mode: The mode.\n"+
+			"  1) fast\n"+
+			"  2) safe\n"+
+			"Enter a value for -mode: "+
+			"Invalid value: invalid flag value: slow, must be one of fast, safe\n"+
+			"Enter a value for -mode: "+
+			"Invalid value: invalid flag value: 3, must be one of fast, safe\n"+
+			"Enter a value for -mode: "+
+			"  1) x\n"+
+			"  2) y\n"+
+			"Enter a value for -other: ",
+	)
+}
+
+func TestPrompterChoicesPreferExactMatch(t *testing.T) {
+	fs := NewTestFlagSet()
+	choice := NewChoice("10", "1")
+	fs.Var(&choice, "level", "")
+
+	p, _, _, finish := mkTestPrompter(t, fs, "1\n")
+	defer finish()
+
+	assert.Nil(t, p.Prompt("level"))
+	assert.Equal(t, *choice.Choice, "1")
+
+	p, _, _, finish = mkTestPrompter(t, fs, "2\n")
+	defer finish()
+
+	assert.Nil(t, p.Prompt("level"))
+	assert.Equal(t, *choice.Choice, "1")
+
+	p, _, _, finish = mkTestPrompter(t, fs, "10\n")
+	defer finish()
+
+	assert.Nil(t, p.Prompt("level"))
+	assert.Equal(t, *choice.Choice, "10")
+}
+
+func TestPrompterSensitive(t *testing.T) {
+	fs := NewTestFlagSet()
+	secret := fs.String("secret", "", usage.Sensitive("The secret."))
+	size := NewBytes(0)
+	fs.Var(&size, "size", usage.Sensitive(""))
+
+	p, stdout, calls, finish := mkTestPrompter(t, fs, "hunter2\n12XB\n1KiB\n")
+	defer finish()
+
+	assert.Nil(t, p.Prompt("secret", "size"))
+	assert.Equal(t, *secret, "hunter2")
+	assert.Equal(t, size.Value, uint64(1024))
+
+	assert.ArrayEqual(
+		t,
+		*calls,
+		[]echoCall{{false}, {true}, {false}, {true}, {false}, {true}},
+	)
+
+	out := stdout.String()
+	assert.False(t, strings.Contains(out, "hunter2"))
+	assert.False(t, strings.Contains(out, "12XB"))
+	assert.Equal(
+		t,
+		out,
+		"-secret: The secret.\n"+
+			"Enter a value for -secret (input hidden): \n"+
+			"Enter a value for -size (input hidden): \n"+
+			"Invalid value: invalid byte size: <redacted>: unknown unit \"XB\"\n"+
+			"Enter a value for -size (input hidden): \n",
+	)
+}
+
+func TestPrompterEchoUnavailable(t *testing.T) {
+	fs := NewTestFlagSet()
+	secret := fs.String("secret", "", usage.Sensitive(""))
+
+	p, stdout, _, finish := mkTestPrompter(t, fs, "hunter2")
+	defer finish()
+	p.setEcho = func(io.Reader, bool) error { return errors.New("not a terminal") }
+
+	assert.Nil(t, p.Prompt("secret"))
+	assert.Equal(t, *secret, "hunter2")
+	assert.Equal(t, stdout.String(), "Enter a value for -secret: ")
+}
+
+func TestPrompterEchoUnavailableOnTerminal(t *testing.T) {
+	fs := NewTestFlagSet()
+	secret := fs.String("secret", "", usage.Sensitive(""))
+
+	p, stdout, _, finish := mkTestPrompter(t, fs, "hunter2\n")
+	defer finish()
+	p.setEcho = func(io.Reader, bool) error { return errors.New("stty: not found") }
+	p.isTerminal = func(tbnos.OS) bool { return true }
+
+	assert.Nil(t, p.Prompt("secret"))
+	assert.Equal(t, *secret, "hunter2")
+	assert.Equal(
+		t,
+		stdout.String(),
+		"WARNING: input for -secret will be visible: stty: not found\n"+
+			"Enter a value for -secret: ",
+	)
+}
+
+func TestPrompterInterruptRestoresEcho(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	stdin, stdinWriter := io.Pipe()
+	stdout := &bytes.Buffer{}
+	exited := make(chan struct{})
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().Stdin().Return(stdin).AnyTimes()
+	mockOS.EXPECT().Stdout().Return(stdout).AnyTimes()
+	mockOS.EXPECT().Exit(130).Do(func(int) { close(exited) })
+
+	fs := NewTestFlagSet()
+	fs.String("secret", "", usage.Sensitive(""))
+
+	calls := []echoCall{}
+	sigs := make(chan os.Signal, 1)
+	stopped := false
+
+	p := NewPrompter(fs.Unwrap(), mockOS).(*prompter)
+	p.setEcho = func(_ io.Reader, on bool) error {
+		calls = append(calls, echoCall{on})
+		return nil
+	}
+	p.interrupts = func() (<-chan os.Signal, func()) {
+		return sigs, func() { stopped = true }
+	}
+
+	result := make(chan error, 1)
+	go func() { result <- p.Prompt("secret") }()
+
+	sigs <- os.Interrupt
+	<-exited
+	assert.ArrayEqual(t, calls, []echoCall{{false}, {true}})
+
+	stdinWriter.Close()
+	assert.ErrorContains(t, <-result, "no value given for -secret")
+	assert.True(t, stopped)
+
+	// echo is restored only once
+	assert.ArrayEqual(t, calls, []echoCall{{false}, {true}})
+}
+
+func TestPrompterErrors(t *testing.T) {
+	fs := NewTestFlagSet()
+	fs.String("name", "", "")
+
+	p, _, _, finish := mkTestPrompter(t, fs, "\n")
+	defer finish()
+
+	assert.ErrorContains(t, p.Prompt("nope"), "unknown flag -nope")
+	assert.ErrorContains(t, p.Prompt("name"), "no value given for -name: end of input")
+}
+
+func TestIsTerminal(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().Stdin().Return(strings.NewReader(""))
+
+	assert.False(t, IsTerminal(mockOS))
+}
+
+func TestSetTerminalEchoRequiresFile(t *testing.T) {
+	assert.ErrorContains(t, setTerminalEcho(strings.NewReader(""), false), "not a terminal")
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type rateUnit struct {
+	names []string
+	per   time.Duration
+}
+
+// rateUnits lists named units. The first name is used when formatting.
+var rateUnits = []rateUnit{
+	{[]string{"ns"}, time.Nanosecond},
+	{[]string{"us", "µs"}, time.Microsecond},
+	{[]string{"ms"}, time.Millisecond},
+	{[]string{"s", "sec", "second"}, time.Second},
+	{[]string{"min", "m", "minute"}, time.Minute},
+	{[]string{"h", "hr", "hour"}, time.Hour},
+	{[]string{"d", "day"}, 24 * time.Hour},
+}
+
+// Rate conforms to the flag.Value, flag.Getter, and ConstrainedValue
+// interfaces. It can be used to populate a rate of events from a
+// command line argument of the form "count/unit", where unit is one
+// of ns, us, ms, s, min, h, or d (or a longer name such as "second",
+// "minute", "hour", or "day"), or any duration accepted by
+// time.ParseDuration. For example: "100/s", "5/min", or "10/30s".
+//
+// Optional minimum and maximum bounds may be set with WithMin and
+// WithMax. Rates are compared by their number of events per second.
+type Rate struct {
+	count float64
+	per   time.Duration
+
+	min, max *Rate
+}
+
+var _ ConstrainedValue = &Rate{}
+var _ flag.Getter = &Rate{}
+
+// NewRate produces a Rate of count events per the given duration.
+func NewRate(count float64, per time.Duration) Rate {
+	return Rate{count: count, per: per}
+}
+
+// WithMin returns a copy of the Rate that rejects values lower than
+// min.
+func (r Rate) WithMin(min Rate) Rate {
+	r.min = &Rate{count: min.count, per: min.per}
+	return r
+}
+
+// WithMax returns a copy of the Rate that rejects values higher than
+// max.
+func (r Rate) WithMax(max Rate) Rate {
+	r.max = &Rate{count: max.count, per: max.per}
+	return r
+}
+
+// Count returns the number of events per Per.
+func (r *Rate) Count() float64 {
+	return r.count
+}
+
+// Per returns the duration over which Count events occur.
+func (r *Rate) Per() time.Duration {
+	return r.per
+}
+
+// PerSecond returns the Rate as a number of events per second. A Rate
+// with a zero duration returns 0.
+func (r *Rate) PerSecond() float64 {
+	if r.per <= 0 {
+		return 0
+	}
+	return r.count * float64(time.Second) / float64(r.per)
+}
+
+// Interval returns the average time between events. A Rate of zero
+// events returns 0.
+func (r *Rate) Interval() time.Duration {
+	if r.count <= 0 {
+		return 0
+	}
+	return time.Duration(float64(r.per) / r.count)
+}
+
+// ValidValuesDescription returns a string describing the allowed
+// values for this Rate. For example: "a rate between 1/s and
+// 100/s".
+func (r *Rate) ValidValuesDescription() string {
+	return "a rate" + boundsDescription(
+		r.min != nil,
+		r.max != nil,
+		r.min.String(),
+		r.max.String(),
+	)
+}
+
+// Get retrieves the current value of the Rate.
+func (r *Rate) Get() interface{} {
+	return Rate{count: r.count, per: r.per}
+}
+
+// String returns the current value of the Rate as "count/unit".
+func (r *Rate) String() string {
+	if r == nil || r.per == 0 {
+		return ""
+	}
+
+	count := strconv.FormatFloat(r.count, 'g', -1, 64)
+	for _, u := range rateUnits {
+		if r.per == u.per {
+			return count + "/" + u.names[0]
+		}
+	}
+
+	return count + "/" + r.per.String()
+}
+
+// Set sets the current value of the Rate, returning an error if the
+// value cannot be parsed or is out of bounds.
+func (r *Rate) Set(value string) error {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid rate: %s: expecting count/unit", value)
+	}
+
+	count, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || count < 0 || math.IsNaN(count) || math.IsInf(count, 0) {
+		return fmt.Errorf("invalid rate: %s: invalid count", value)
+	}
+
+	per, err := parseRateUnit(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return fmt.Errorf("invalid rate: %s: %s", value, err)
+	}
+
+	candidate := Rate{count: count, per: per}
+	perSecond := candidate.PerSecond()
+	if (r.min != nil && perSecond < r.min.PerSecond()) ||
+		(r.max != nil && perSecond > r.max.PerSecond()) {
+		return fmt.Errorf(
+			"invalid flag value: %s, must be %s",
+			value,
+			r.ValidValuesDescription(),
+		)
+	}
+
+	r.count = count
+	r.per = per
+	return nil
+}
+
+func parseRateUnit(unit string) (time.Duration, error) {
+	lower := strings.ToLower(unit)
+	for _, u := range rateUnits {
+		for _, name := range u.names {
+			if lower == name {
+				return u.per, nil
+			}
+		}
+	}
+
+	d, err := time.ParseDuration(unit)
+	if err != nil {
+		return 0, fmt.Errorf("unknown unit %q", unit)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("unit must be positive: %q", unit)
+	}
+	return d, nil
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestRateSet(t *testing.T) {
+	testCases := []struct {
+		value         string
+		expectedCount float64
+		expectedPer   time.Duration
+		expectedStr   string
+	}{
+		{"100/s", 100, time.Second, "100/s"},
+		{"5/min", 5, time.Minute, "5/min"},
+		{"5/m", 5, time.Minute, "5/min"},
+		{"2.5/Second", 2.5, time.Second, "2.5/s"},
+		{"10/hour", 10, time.Hour, "10/h"},
+		{"1/day", 1, 24 * time.Hour, "1/d"},
+		{"3/ms", 3, time.Millisecond, "3/ms"},
+		{"10/30s", 10, 30 * time.Second, "10/30s"},
+		{" 7 / 1m30s ", 7, 90 * time.Second, "7/1m30s"},
+	}
+
+	for _, tc := range testCases {
+		assert.Group(tc.value, t, func(g *assert.G) {
+			r := NewRate(1, time.Second)
+			assert.Nil(g, r.Set(tc.value))
+			assert.Equal(g, r.Count(), tc.expectedCount)
+			assert.Equal(g, r.Per(), tc.expectedPer)
+			assert.Equal(g, r.String(), tc.expectedStr)
+			assert.DeepEqual(g, r.Get(), NewRate(tc.expectedCount, tc.expectedPer))
+		})
+	}
+}
+
+func TestRateSetErrors(t *testing.T) {
+	r := NewRate(1, time.Second)
+	assert.ErrorContains(t, r.Set("100"), "invalid rate: 100: expecting count/unit")
+	assert.ErrorContains(t, r.Set("x/s"), "invalid rate: x/s: invalid count")
+	assert.ErrorContains(t, r.Set("-1/s"), "invalid count")
+	assert.ErrorContains(t, r.Set("NaN/s"), "invalid rate: NaN/s: invalid count")
+	assert.ErrorContains(t, r.Set("+Inf/s"), "invalid rate: +Inf/s: invalid count")
+	assert.ErrorContains(t, r.Set("1/fortnight"), `unknown unit "fortnight"`)
+	assert.ErrorContains(t, r.Set("1/0s"), `unit must be positive: "0s"`)
+	assert.Equal(t, r.String(), "1/s")
+}
+
+func TestRateConversions(t *testing.T) {
+	r := NewRate(5, time.Minute)
+	assert.Equal(t, r.PerSecond(), 5.0/60.0)
+	assert.Equal(t, r.Interval(), 12*time.Second)
+
+	r = NewRate(0, time.Second)
+	assert.Equal(t, r.PerSecond(), 0.0)
+	assert.Equal(t, r.Interval(), time.Duration(0))
+
+	r = Rate{}
+	assert.Equal(t, r.PerSecond(), 0.0)
+	assert.Equal(t, r.String(), "")
+
+	var nilRate *Rate
+	assert.Equal(t, nilRate.String(), "")
+}
+
+func TestRateBounds(t *testing.T) {
+	r := NewRate(10, time.Second).
+		WithMin(NewRate(1, time.Second)).
+		WithMax(NewRate(100, time.Second))
+	assert.Equal(t, r.ValidValuesDescription(), "a rate between 1/s and 100/s")
+
+	assert.Nil(t, r.Set("60/min"))
+	assert.Nil(t, r.Set("100/s"))
+	assert.ErrorContains(
+		t,
+		r.Set("30/min"),
+		"invalid flag value: 30/min, must be a rate between 1/s and 100/s",
+	)
+	assert.ErrorContains(t, r.Set("101/s"), "must be a rate between 1/s and 100/s")
+	assert.ErrorContains(t, r.Set("NaN/s"), "invalid count")
+	assert.Equal(t, r.String(), "100/s")
+
+	r = NewRate(0, time.Second).WithMin(NewRate(5, time.Minute))
+	assert.Equal(t, r.ValidValuesDescription(), "a rate of at least 5/min")
+
+	r = NewRate(0, time.Second).WithMax(NewRate(1, time.Hour))
+	assert.Equal(t, r.ValidValuesDescription(), "a rate of at most 1/h")
+
+	r = NewRate(0, time.Second)
+	assert.Equal(t, r.ValidValuesDescription(), "a rate")
+}
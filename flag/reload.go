@@ -0,0 +1,552 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+//go:generate mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE --write_package_comment=false
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+)
+
+// ChangeFunc is invoked when the value of a dynamic flag changes. If
+// the flag's Value implements flag.Getter, the values passed are
+// those returned by Get. Otherwise they are the flag's string
+// representation. See OnChange for a typed alternative.
+type ChangeFunc func(oldValue, newValue interface{})
+
+// Reloader re-reads the Layers used to fill a FlagSet at runtime,
+// applying changes to flags marked dynamic.
+//
+// Reloads triggered by WatchSignals and WatchFiles run on goroutines
+// owned by the Reloader, and flag values are updated in place. Once
+// either has been called, the values of dynamic flags must only be
+// read with Get, Snapshot, or Current, or from within a ChangeFunc.
+// Reading the variables bound to dynamic flags directly races with
+// reloads. Get and Snapshot never observe a partially applied
+// reload. Alternatively, callers may avoid WatchSignals and WatchFiles
+// and invoke Reload from the goroutine that reads the flags.
+type Reloader interface {
+	// Dynamic marks the named flags as dynamic, allowing their
+	// values to change on reload. Returns an error if any of the
+	// named flags is not defined.
+	Dynamic(names ...string) error
+
+	// OnChange registers a ChangeFunc for the named flag. Returns
+	// an error if the flag is not dynamic. ChangeFuncs must not
+	// call Reload.
+	OnChange(name string, fn ChangeFunc) error
+
+	// Get returns the current value of the named flag, as passed
+	// to a ChangeFunc. Returns an error if the flag is not defined.
+	Get(name string) (interface{}, error)
+
+	// Snapshot returns the current values of all flags, as passed
+	// to a ChangeFunc, keyed by flag name. The values are
+	// consistent with a single reload.
+	Snapshot() map[string]interface{}
+
+	// OnError registers a function invoked with any error
+	// produced by a reload triggered by WatchSignals or
+	// WatchFiles. By default, such errors are ignored.
+	OnError(fn func(error))
+
+	// Reload loads each Layer and determines each flag's value,
+	// as with Layered.Fill. Flags no longer supplied by any Layer
+	// revert to their defaults, and flags set on the command line
+	// never change. If the value supplied for a flag that is not
+	// dynamic has changed, or if any new value is invalid, no
+	// flags are changed or marked as set and an error is returned.
+	// Otherwise, every change is applied before any ChangeFuncs are
+	// invoked, in flag name order.
+	Reload() error
+
+	// WatchSignals reloads each time the process receives one of
+	// the given signals, or SIGHUP if none are given. Returns a
+	// function that stops watching.
+	WatchSignals(sigs ...os.Signal) func()
+
+	// WatchFiles checks the config file of each FromFile Layer at
+	// the given period, reloading when a file's modification time
+	// or size changes. Returns a function that stops watching.
+	WatchFiles(period time.Duration) func()
+}
+
+// NewReloader produces a Reloader for the given FlagSet and Layers,
+// which should be the same Layers, in the same order, as were used to
+// fill the FlagSet. The values currently supplied by the Layers are
+// recorded so that later changes can be detected, so NewReloader
+// returns an error if any Layer fails to load.
+func NewReloader(fs *flag.FlagSet, layers ...Layer) (Reloader, error) {
+	r := &reloader{
+		fs:       fs,
+		layers:   layers,
+		os:       tbnos.New(),
+		dynamic:  map[string]bool{},
+		watchers: map[string][]ChangeFunc{},
+	}
+
+	supplied, err := r.resolve()
+	if err != nil {
+		return nil, err
+	}
+	r.supplied = supplied
+
+	return r, nil
+}
+
+// OnChange registers a typed function invoked when the value of the
+// named dynamic flag changes. T must be the type of the values that
+// would be passed to a ChangeFunc for the flag (e.g., time.Duration
+// for a flag defined with flag.Duration, or []string for Strings).
+// Returns an error if the flag is not defined, is not dynamic, or has
+// values of a different type.
+func OnChange[T any](r Reloader, name string, fn func(oldValue, newValue T)) error {
+	value, err := r.Get(name)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := value.(T); !ok {
+		var zero T
+		return fmt.Errorf("flag %s has values of type %T, not %T", name, value, zero)
+	}
+
+	return r.OnChange(name, func(oldValue, newValue interface{}) {
+		fn(oldValue.(T), newValue.(T))
+	})
+}
+
+// Current returns the current value of the named flag as a T. See
+// Reloader.Get. Returns an error if the flag is not defined or has
+// values of a different type.
+func Current[T any](r Reloader, name string) (T, error) {
+	var zero T
+
+	value, err := r.Get(name)
+	if err != nil {
+		return zero, err
+	}
+
+	t, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("flag %s has values of type %T, not %T", name, value, zero)
+	}
+
+	return t, nil
+}
+
+type reloader struct {
+	fs     *flag.FlagSet
+	layers []Layer
+	os     tbnos.OS
+
+	// reloadMutex serializes reloads, including the invocation
+	// of ChangeFuncs.
+	reloadMutex sync.Mutex
+	supplied    map[string]layerSetting
+
+	// valueMutex guards flag values while a reload applies or
+	// rolls back changes.
+	valueMutex sync.RWMutex
+
+	mutex    sync.Mutex
+	dynamic  map[string]bool
+	watchers map[string][]ChangeFunc
+	onError  func(error)
+}
+
+func (r *reloader) Dynamic(names ...string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	unknown := []string{}
+	for _, name := range names {
+		if r.fs.Lookup(name) == nil {
+			unknown = append(unknown, name)
+			continue
+		}
+		r.dynamic[name] = true
+	}
+
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown flag(s): %s", strings.Join(unknown, ", "))
+	}
+
+	return nil
+}
+
+func (r *reloader) OnChange(name string, fn ChangeFunc) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.dynamic[name] {
+		return fmt.Errorf("flag %s is not dynamic", name)
+	}
+
+	r.watchers[name] = append(r.watchers[name], fn)
+	return nil
+}
+
+func (r *reloader) Get(name string) (interface{}, error) {
+	f := r.fs.Lookup(name)
+	if f == nil {
+		return nil, fmt.Errorf("unknown flag %s", name)
+	}
+
+	r.valueMutex.RLock()
+	defer r.valueMutex.RUnlock()
+
+	return getFlagValue(f), nil
+}
+
+func (r *reloader) Snapshot() map[string]interface{} {
+	r.valueMutex.RLock()
+	defer r.valueMutex.RUnlock()
+
+	values := map[string]interface{}{}
+	r.fs.VisitAll(func(f *flag.Flag) {
+		values[f.Name] = getFlagValue(f)
+	})
+
+	return values
+}
+
+func (r *reloader) OnError(fn func(error)) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.onError = fn
+}
+
+// resolve returns the value supplied for each flag not set on the
+// command line, keyed by flag name. Flags not supplied by any Layer
+// are given their default value.
+func (r *reloader) resolve() (map[string]layerSetting, error) {
+	origins, settings, err := resolveLayers(r.fs, r.layers)
+	if err != nil {
+		return nil, err
+	}
+
+	supplied := map[string]layerSetting{}
+	for _, s := range settings {
+		supplied[s.name] = s
+	}
+
+	r.fs.VisitAll(func(f *flag.Flag) {
+		if origins[f.Name].Source == DefaultSource {
+			supplied[f.Name] = layerSetting{
+				name:   f.Name,
+				value:  f.DefValue,
+				origin: origins[f.Name],
+			}
+		}
+	})
+
+	return supplied, nil
+}
+
+type flagChange struct {
+	flag     *flag.Flag
+	setting  layerSetting
+	saved    savedValue
+	oldValue interface{}
+	newValue interface{}
+	watchers []ChangeFunc
+}
+
+func (r *reloader) Reload() error {
+	r.reloadMutex.Lock()
+	defer r.reloadMutex.Unlock()
+
+	supplied, err := r.resolve()
+	if err != nil {
+		return err
+	}
+
+	set := map[string]bool{}
+	r.fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	r.mutex.Lock()
+	changes := []*flagChange{}
+	rejected := []string{}
+	for name, s := range supplied {
+		if prev, ok := r.supplied[name]; ok && prev.value == s.value {
+			continue
+		}
+
+		if !r.dynamic[name] {
+			rejected = append(rejected, name)
+			continue
+		}
+
+		f := r.fs.Lookup(name)
+		changes = append(
+			changes,
+			&flagChange{
+				flag:     f,
+				setting:  s,
+				saved:    saveValue(f, set[name]),
+				oldValue: getFlagValue(f),
+				watchers: r.watchers[name],
+			},
+		)
+	}
+	r.mutex.Unlock()
+
+	if len(rejected) > 0 {
+		sort.Strings(rejected)
+		return fmt.Errorf(
+			"cannot reload non-dynamic flag(s): %s",
+			strings.Join(rejected, ", "),
+		)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].flag.Name < changes[j].flag.Name
+	})
+
+	if err := r.apply(changes); err != nil {
+		return err
+	}
+
+	r.supplied = supplied
+
+	for _, c := range changes {
+		if c.flag.Value.String() == c.saved.str {
+			// equivalent value, e.g. "60s" replaced by "1m"
+			continue
+		}
+
+		c.newValue = getFlagValue(c.flag)
+		for _, fn := range c.watchers {
+			fn(c.oldValue, c.newValue)
+		}
+	}
+
+	return nil
+}
+
+// apply sets each changed flag to its new value. Values are set
+// directly, so that the FlagSet does not mark the flags as set until
+// every value has been applied successfully. If any value is invalid,
+// every flag is restored to its previous value and set state.
+func (r *reloader) apply(changes []*flagChange) error {
+	r.valueMutex.Lock()
+	defer r.valueMutex.Unlock()
+
+	for i, c := range changes {
+		if rv, ok := c.flag.Value.(resettable); ok {
+			rv.ResetDefault()
+		}
+
+		if err := c.flag.Value.Set(c.setting.value); err != nil {
+			for _, applied := range changes[0 : i+1] {
+				applied.saved.restore(applied.flag)
+			}
+			return c.setting.wrapErr(err)
+		}
+	}
+
+	// Mark flags supplied by a Layer as set, as Layered.Fill would.
+	// The same value was just applied successfully, so it cannot
+	// fail now.
+	for _, c := range changes {
+		if c.setting.origin.Source != DefaultSource && !c.saved.set {
+			resetAndSet(r.fs, c.flag, c.setting.value)
+		}
+	}
+
+	return nil
+}
+
+func (r *reloader) reportError(err error) {
+	if err == nil {
+		return
+	}
+
+	r.mutex.Lock()
+	onError := r.onError
+	r.mutex.Unlock()
+
+	if onError != nil {
+		onError(err)
+	}
+}
+
+func (r *reloader) WatchSignals(sigs ...os.Signal) func() {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ch:
+				r.reportError(r.Reload())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return mkStopFunc(done, func() { signal.Stop(ch) })
+}
+
+func (r *reloader) WatchFiles(period time.Duration) func() {
+	paths := []string{}
+	for _, layer := range r.layers {
+		if ff, ok := layer.(FromFile); ok {
+			paths = append(paths, ff.Path())
+		}
+	}
+
+	type fileState struct {
+		modTime time.Time
+		size    int64
+	}
+
+	stat := func() map[string]fileState {
+		states := map[string]fileState{}
+		for _, path := range paths {
+			if info, err := r.os.Stat(path); err == nil {
+				states[path] = fileState{info.ModTime(), info.Size()}
+			}
+		}
+		return states
+	}
+
+	ticker := time.NewTicker(period)
+	last := stat()
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+
+			current := stat()
+			changed := len(current) != len(last)
+			for path, state := range current {
+				if last[path] != state {
+					changed = true
+				}
+			}
+			last = current
+
+			if changed {
+				r.reportError(r.Reload())
+			}
+		}
+	}()
+
+	return mkStopFunc(done, ticker.Stop)
+}
+
+// mkStopFunc returns a function that, on its first invocation only,
+// invokes cleanup and closes done.
+func mkStopFunc(done chan struct{}, cleanup func()) func() {
+	once := sync.Once{}
+	return func() {
+		once.Do(func() {
+			cleanup()
+			close(done)
+		})
+	}
+}
+
+// resettable is implemented by flag values, such as Strings, whose
+// Set method appends to rather than replaces a previously set value.
+type resettable interface {
+	ResetDefault(values ...string)
+}
+
+// savedValue records a flag's value, and whether it was set, so that
+// it can be restored exactly.
+type savedValue struct {
+	str    string
+	values []string
+	set    bool
+}
+
+func saveValue(f *flag.Flag, set bool) savedValue {
+	saved := savedValue{str: f.Value.String(), set: set}
+	if mv, ok := f.Value.(multiValued); ok {
+		saved.values = append([]string{}, mv.stringValues()...)
+	}
+	return saved
+}
+
+// restore sets the flag's Value to the saved value without marking
+// the flag as set in its FlagSet. Resettable values that were not set
+// are restored as defaults, so that a later Set replaces them.
+func (s savedValue) restore(f *flag.Flag) {
+	r, ok := f.Value.(resettable)
+	switch {
+	case !ok:
+		f.Value.Set(s.str)
+
+	case s.values == nil:
+		r.ResetDefault()
+		f.Value.Set(s.str)
+
+	case !s.set:
+		r.ResetDefault(s.values...)
+
+	default:
+		r.ResetDefault()
+		for _, v := range s.values {
+			f.Value.Set(v)
+		}
+	}
+}
+
+// resetAndSet sets the flag's value, replacing rather than appending
+// to any previous value.
+func resetAndSet(fs *flag.FlagSet, f *flag.Flag, value string) error {
+	if r, ok := f.Value.(resettable); ok {
+		r.ResetDefault()
+	}
+
+	return fs.Set(f.Name, value)
+}
+
+func getFlagValue(f *flag.Flag) interface{} {
+	if g, ok := f.Value.(flag.Getter); ok {
+		return g.Get()
+	}
+
+	return f.Value.String()
+}
@@ -0,0 +1,414 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+type reloadTestFlags struct {
+	fs      *flag.FlagSet
+	delay   *time.Duration
+	name    *string
+	fixed   *string
+	cmdline *string
+	tags    *Strings
+	timeout *time.Duration
+	path    string
+	layers  []Layer
+}
+
+func mkReloadTestFlags(t *testing.T, config string, args ...string) (*reloadTestFlags, func()) {
+	fs := flag.NewFlagSet("reload", flag.PanicOnError)
+	tags := NewStrings()
+	rtf := &reloadTestFlags{
+		fs:      fs,
+		delay:   fs.Duration("delay", time.Second, "delay"),
+		name:    fs.String("name", "name-default", "name"),
+		fixed:   fs.String("fixed", "fixed-default", "fixed"),
+		cmdline: fs.String("cmdline", "cmdline-default", "cmdline"),
+		tags:    &tags,
+		timeout: fs.Duration("timeout", time.Minute, "timeout"),
+	}
+	fs.Var(rtf.tags, "tags", "tags")
+
+	path, cleanup := writeConfigFile(t, "config", config)
+	rtf.path = path
+
+	fs.Parse(args)
+
+	rtf.layers = []Layer{NewCommandLineLayer(fs), NewFromFile(fs, path)}
+	assert.Nil(t, NewLayered(fs, rtf.layers...).Fill())
+
+	return rtf, cleanup
+}
+
+func (rtf *reloadTestFlags) rewrite(t *testing.T, config string) {
+	assert.Nil(t, ioutil.WriteFile(rtf.path, []byte(config), 0644))
+}
+
+func TestReloaderReload(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(
+		t,
+		"delay = 5s\nname = a\ntags = x,y\nfixed = f\ncmdline = c-file\n",
+		"-cmdline=c-cmdline",
+	)
+	defer cleanup()
+
+	assert.Equal(t, *rtf.delay, 5*time.Second)
+	assert.ArrayEqual(t, rtf.tags.Strings, []string{"x", "y"})
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("delay", "name", "tags", "cmdline"))
+
+	type change struct{ old, new interface{} }
+	changes := map[string][]change{}
+	for _, name := range []string{"delay", "name", "tags"} {
+		n := name
+		assert.Nil(t, r.OnChange(n, func(old, new interface{}) {
+			// every change is applied before watchers run
+			assert.Equal(t, *rtf.delay, 10*time.Second)
+			assert.Equal(t, *rtf.name, "b")
+			changes[n] = append(changes[n], change{old, new})
+		}))
+	}
+
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, len(changes), 0)
+
+	rtf.rewrite(t, "delay = 10s\nname = b\ntags = z\nfixed = f\ncmdline = c-file-2\n")
+	assert.Nil(t, r.Reload())
+
+	assert.Equal(t, *rtf.delay, 10*time.Second)
+	assert.Equal(t, *rtf.name, "b")
+	assert.ArrayEqual(t, rtf.tags.Strings, []string{"z"})
+	assert.Equal(t, *rtf.cmdline, "c-cmdline")
+
+	assert.DeepEqual(t, changes, map[string][]change{
+		"delay": {{5 * time.Second, 10 * time.Second}},
+		"name":  {{"a", "b"}},
+		"tags":  {{[]string{"x", "y"}, []string{"z"}}},
+	})
+}
+
+func TestReloaderRevertsToDefault(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "name = a\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("name"))
+
+	var got []interface{}
+	assert.Nil(t, r.OnChange("name", func(old, new interface{}) {
+		got = []interface{}{old, new}
+	}))
+
+	rtf.rewrite(t, "")
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, *rtf.name, "name-default")
+	assert.ArrayEqual(t, got, []interface{}{"a", "name-default"})
+}
+
+func TestReloaderEquivalentValue(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "delay = 60s\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("delay"))
+	assert.Nil(t, r.OnChange("delay", func(_, _ interface{}) {
+		t.Error("unexpected change")
+	}))
+
+	rtf.rewrite(t, "delay = 1m\n")
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, *rtf.delay, time.Minute)
+}
+
+func TestReloaderRejectsNonDynamic(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "name = a\nfixed = f\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("name"))
+
+	rtf.rewrite(t, "name = b\nfixed = g\n")
+	assert.ErrorContains(t, r.Reload(), "cannot reload non-dynamic flag(s): fixed")
+	assert.Equal(t, *rtf.name, "a")
+	assert.Equal(t, *rtf.fixed, "f")
+
+	// still rejected until the file is corrected
+	assert.NonNil(t, r.Reload())
+
+	rtf.rewrite(t, "name = b\nfixed = f\n")
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, *rtf.name, "b")
+}
+
+func TestReloaderInvalidValueRollsBack(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "delay = 5s\nname = a\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("delay", "name"))
+
+	changed := false
+	assert.Nil(t, r.OnChange("name", func(_, _ interface{}) { changed = true }))
+
+	rtf.rewrite(t, "delay = soon\nname = b\n")
+	err = r.Reload()
+	assert.ErrorContains(t, err, "config file delay: ")
+	assert.Equal(t, *rtf.delay, 5*time.Second)
+	assert.Equal(t, *rtf.name, "a")
+	assert.False(t, changed)
+}
+
+func TestReloaderInvalidValueRestoresSetState(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "delay = 5s\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("delay", "name", "tags", "timeout"))
+
+	setFlags := func() []string {
+		names := []string{}
+		rtf.fs.Visit(func(f *flag.Flag) { names = append(names, f.Name) })
+		return names
+	}
+	assert.ArrayEqual(t, setFlags(), []string{"delay"})
+
+	rtf.rewrite(t, "delay = 10s\nname = b\ntags = x,y\ntimeout = soon\n")
+	assert.ErrorContains(t, r.Reload(), "config file timeout: ")
+	assert.Equal(t, *rtf.delay, 5*time.Second)
+	assert.Equal(t, *rtf.name, "name-default")
+	assert.Equal(t, len(rtf.tags.Strings), 0)
+	assert.Equal(t, *rtf.timeout, time.Minute)
+	assert.ArrayEqual(t, setFlags(), []string{"delay"})
+
+	// tags is still unset, so Set replaces rather than appends
+	rtf.tags.ResetDefault("d")
+	assert.Nil(t, rtf.tags.Set("z"))
+	assert.ArrayEqual(t, rtf.tags.Strings, []string{"z"})
+	rtf.tags.ResetDefault()
+
+	rtf.rewrite(t, "delay = 10s\nname = b\ntags = x,y\n")
+	assert.Nil(t, r.Reload())
+	assert.Equal(t, *rtf.name, "b")
+	assert.ArrayEqual(t, rtf.tags.Strings, []string{"x", "y"})
+	assert.ArrayEqual(t, setFlags(), []string{"delay", "name", "tags"})
+}
+
+func TestReloaderLoadError(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "name = a\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+
+	rtf.rewrite(t, "unknown = 1\n")
+	assert.ErrorContains(t, r.Reload(), "unknown flag(s): unknown")
+	assert.Equal(t, *rtf.name, "a")
+
+	os.Remove(rtf.path)
+	_, err = NewReloader(rtf.fs, rtf.layers...)
+	assert.NonNil(t, err)
+}
+
+func TestReloaderDynamicAndOnChangeErrors(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+
+	assert.ErrorContains(t, r.Dynamic("name", "nope", "nada"), "unknown flag(s): nope, nada")
+	assert.ErrorContains(t, r.OnChange("fixed", func(_, _ interface{}) {}), "flag fixed is not dynamic")
+	assert.Nil(t, r.OnChange("name", func(_, _ interface{}) {}))
+}
+
+func TestReloaderGetAndSnapshot(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "delay = 5s\nname = a\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("delay", "name"))
+
+	v, err := r.Get("delay")
+	assert.Nil(t, err)
+	assert.Equal(t, v, 5*time.Second)
+
+	_, err = r.Get("nope")
+	assert.ErrorContains(t, err, "unknown flag nope")
+
+	rtf.rewrite(t, "delay = 10s\nname = b\n")
+	assert.Nil(t, r.Reload())
+
+	snapshot := r.Snapshot()
+	assert.Equal(t, snapshot["delay"], 10*time.Second)
+	assert.Equal(t, snapshot["name"], "b")
+	assert.Equal(t, snapshot["fixed"], "fixed-default")
+
+	delay, err := Current[time.Duration](r, "delay")
+	assert.Nil(t, err)
+	assert.Equal(t, delay, 10*time.Second)
+
+	_, err = Current[string](r, "delay")
+	assert.ErrorContains(t, err, "flag delay has values of type time.Duration, not string")
+}
+
+func TestReloaderSnapshotConsistentDuringReload(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "delay = 1s\nname = 1\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("delay", "name"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 2; i < 20; i++ {
+			rtf.rewrite(t, fmt.Sprintf("delay = %ds\nname = %d\n", i, i))
+			assert.Nil(t, r.Reload())
+		}
+	}()
+
+	for {
+		snapshot := r.Snapshot()
+		delay := snapshot["delay"].(time.Duration)
+		assert.Equal(t, snapshot["name"], strconv.Itoa(int(delay/time.Second)))
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+	}
+}
+
+func TestReloaderOnChangeTyped(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "delay = 5s\ntags = x\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("delay", "tags"))
+
+	var delays []time.Duration
+	assert.Nil(t, OnChange(r, "delay", func(old, new time.Duration) {
+		delays = append(delays, old, new)
+	}))
+
+	var tags [][]string
+	assert.Nil(t, OnChange(r, "tags", func(old, new []string) {
+		tags = append(tags, old, new)
+	}))
+
+	assert.ErrorContains(
+		t,
+		OnChange(r, "delay", func(_, _ string) {}),
+		"flag delay has values of type time.Duration, not string",
+	)
+	assert.ErrorContains(t, OnChange(r, "nope", func(_, _ string) {}), "unknown flag nope")
+	assert.ErrorContains(t, OnChange(r, "name", func(_, _ string) {}), "flag name is not dynamic")
+
+	rtf.rewrite(t, "delay = 10s\ntags = y,z\n")
+	assert.Nil(t, r.Reload())
+
+	assert.ArrayEqual(t, delays, []time.Duration{5 * time.Second, 10 * time.Second})
+	assert.DeepEqual(t, tags, [][]string{{"x"}, {"y", "z"}})
+}
+
+func TestReloaderWatchFiles(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "name = a\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("name"))
+
+	changes := make(chan interface{}, 1)
+	assert.Nil(t, r.OnChange("name", func(_, new interface{}) { changes <- new }))
+
+	stop := r.WatchFiles(5 * time.Millisecond)
+	defer stop()
+
+	rtf.rewrite(t, "name = bb\n")
+
+	select {
+	case v := <-changes:
+		assert.Equal(t, v, "bb")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+
+	stop()
+	stop()
+}
+
+func TestReloaderWatchSignals(t *testing.T) {
+	rtf, cleanup := mkReloadTestFlags(t, "name = a\n")
+	defer cleanup()
+
+	r, err := NewReloader(rtf.fs, rtf.layers...)
+	assert.Nil(t, err)
+	assert.Nil(t, r.Dynamic("name"))
+
+	changes := make(chan interface{}, 1)
+	assert.Nil(t, r.OnChange("name", func(_, new interface{}) { changes <- new }))
+
+	errs := make(chan error, 1)
+	r.OnError(func(err error) { errs <- err })
+
+	stop := r.WatchSignals(syscall.SIGUSR1)
+	defer stop()
+
+	rtf.rewrite(t, "name = b\n")
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case v := <-changes:
+		assert.Equal(t, v, "b")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for reload")
+	}
+
+	rtf.rewrite(t, "name = c\nfixed = x\n")
+	assert.Nil(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "non-dynamic")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for error")
+	}
+}
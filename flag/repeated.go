@@ -0,0 +1,268 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbinelabs/nonstdlib/arrays/indexof"
+)
+
+// Repeated conforms to the flag.Value and flag.Getter interfaces, and
+// can be used to populate a slice of any type from a flag.Flag, using
+// a ParseFunc. After command line parsing, the values can be retrieved
+// via the Values field. Each instance of the flag adds values (e.g.,
+// "-route=a:host1:80 -route=b:host2:80"). If a Delimiter is set, a
+// single instance of the flag may also supply multiple values (e.g.,
+// "-port=80,443"). Use ResetDefault to prepare Repeated for re-use.
+// The zero value has no ParseFunc and rejects every value; use
+// NewRepeated or NewList to construct a Repeated.
+//
+// Values are formatted with fmt.Sprint, unless a format function is
+// given via WithFormat. Allowed values, if any, are compared in their
+// formatted form. If a key function is given via WithKey, values with
+// the same key are handled according to the DuplicateKeyPolicy.
+type Repeated[T any] struct {
+	// Populated from the command line.
+	Values []T
+
+	// Delimiter used to parse the string from the command line. If
+	// empty, each instance of the flag supplies a single value.
+	Delimiter string
+
+	parse         ParseFunc[T]
+	format        func(T) string
+	allowedValues []string
+	key           func(T) string
+	duplicates    DuplicateKeyPolicy
+	isSet         bool
+}
+
+var _ flag.Getter = &Repeated[int]{}
+var _ ConstrainedValue = &Repeated[int]{}
+
+// NewRepeated produces a Repeated that adds a single value parsed
+// with the given ParseFunc for each instance of the flag.
+func NewRepeated[T any](parse ParseFunc[T]) Repeated[T] {
+	return Repeated[T]{parse: parse}
+}
+
+// NewList produces a Repeated that accepts multiple values, parsed
+// with the given ParseFunc, in each instance of the flag, separated
+// by the default delimiter (",").
+func NewList[T any](parse ParseFunc[T]) Repeated[T] {
+	return Repeated[T]{Delimiter: ",", parse: parse}
+}
+
+// NewIntList produces a Repeated that accepts comma-delimited
+// integers.
+func NewIntList() Repeated[int] {
+	return NewList(strconv.Atoi)
+}
+
+// NewDurationList produces a Repeated that accepts comma-delimited
+// durations.
+func NewDurationList() Repeated[time.Duration] {
+	return NewList(time.ParseDuration)
+}
+
+// NewHostPortList produces a Repeated that accepts comma-delimited
+// host:port pairs.
+func NewHostPortList() Repeated[HostPort] {
+	return NewList(
+		func(s string) (HostPort, error) {
+			hp := HostPort{}
+			err := hp.Set(s)
+			return hp, err
+		},
+	).WithFormat(func(hp HostPort) string { return hp.String() })
+}
+
+// WithFormat sets the function used to format values as strings. The
+// function must produce strings accepted by the Repeated's ParseFunc.
+func (rv Repeated[T]) WithFormat(format func(T) string) Repeated[T] {
+	rv.format = format
+	return rv
+}
+
+// WithAllowedValues constrains the values that may be set.
+func (rv Repeated[T]) WithAllowedValues(allowedValues ...T) Repeated[T] {
+	rv.allowedValues = make([]string, len(allowedValues))
+	for i, v := range allowedValues {
+		rv.allowedValues[i] = rv.formatValue(v)
+	}
+	return rv
+}
+
+// WithKey sets a function that produces a key for each value, and the
+// policy applied when more than one value has the same key. Under
+// DuplicateKeyReplace, a later value replaces the earlier value in
+// place.
+func (rv Repeated[T]) WithKey(key func(T) string, policy DuplicateKeyPolicy) Repeated[T] {
+	rv.key = key
+	rv.duplicates = policy
+	return rv
+}
+
+func (rv *Repeated[T]) formatValue(v T) string {
+	if rv.format != nil {
+		return rv.format(v)
+	}
+	return fmt.Sprint(v)
+}
+
+func (rv *Repeated[T]) completionValues() []string {
+	return rv.allowedValues
+}
+
+// ValidValuesDescription returns a string describing the allowed
+// values for this Repeated. For example: "a", "b", or "c". If this
+// Repeated is unconstrained, it returns an empty string.
+func (rv *Repeated[T]) ValidValuesDescription() string {
+	return allowedValuesToDescription(rv.allowedValues)
+}
+
+// Retrieves the formatted values set on Repeated joined by the
+// delimiter, or by a comma if there is no delimiter.
+func (rv *Repeated[T]) String() string {
+	if rv == nil {
+		return ""
+	}
+
+	delim := rv.Delimiter
+	if delim == "" {
+		delim = ","
+	}
+
+	return strings.Join(rv.stringValues(), delim)
+}
+
+// stringValues returns the formatted values set on Repeated, each of
+// which may be passed to Set.
+func (rv *Repeated[T]) stringValues() []string {
+	strs := make([]string, len(rv.Values))
+	for i, v := range rv.Values {
+		strs[i] = rv.formatValue(v)
+	}
+	return strs
+}
+
+// ResetDefault resets Repeated for use and parses the given strings as
+// the default value. Any call to Set (e.g., via flag.FlagSet) will
+// replace these values. Default values are not checked against the
+// allowed values, and values that cannot be parsed are ignored.
+func (rv *Repeated[T]) ResetDefault(values ...string) {
+	rv.Values = []T{}
+	if rv.parse != nil {
+		for _, s := range values {
+			if v, err := rv.parse(s); err == nil {
+				rv.Values = append(rv.Values, v)
+			}
+		}
+	}
+	rv.isSet = false
+}
+
+// Set sets the current value. The first call (after initialization or
+// a call to ResetDefault) will replace all current values. Subsequent
+// calls append values. If any value cannot be parsed, is not allowed,
+// or repeats a key under the DuplicateKeyError policy, an error is
+// returned and the current value is unchanged.
+func (rv *Repeated[T]) Set(value string) error {
+	if rv.parse == nil {
+		return fmt.Errorf("invalid flag value: %s: no ParseFunc (use NewRepeated or NewList)", value)
+	}
+
+	var parts []string
+	if rv.Delimiter == "" {
+		parts = []string{value}
+	} else {
+		parts = strings.Split(value, rv.Delimiter)
+	}
+
+	values := []T{}
+	if rv.isSet {
+		values = append(values, rv.Values...)
+	}
+
+	keys := map[string]int{}
+	if rv.key != nil {
+		for i, v := range values {
+			keys[rv.key(v)] = i
+		}
+	}
+
+	disallowed := []string{}
+	duplicated := []string{}
+
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		v, err := rv.parse(part)
+		if err != nil {
+			return fmt.Errorf("invalid flag value: %s: %s", part, err.Error())
+		}
+
+		if len(rv.allowedValues) > 0 {
+			if indexof.String(rv.allowedValues, rv.formatValue(v)) == indexof.NotFound {
+				disallowed = append(disallowed, part)
+				continue
+			}
+		}
+
+		if rv.key != nil {
+			k := rv.key(v)
+			if i, exists := keys[k]; exists {
+				switch rv.duplicates {
+				case DuplicateKeyReplace:
+					values[i] = v
+				case DuplicateKeyError:
+					duplicated = append(duplicated, k)
+				}
+				continue
+			}
+			keys[k] = len(values)
+		}
+
+		values = append(values, v)
+	}
+
+	if len(disallowed) > 0 {
+		return fmt.Errorf("invalid flag value(s): %s", strings.Join(disallowed, ", "))
+	}
+
+	if len(duplicated) > 0 {
+		return fmt.Errorf("duplicate flag key(s): %s", strings.Join(duplicated, ", "))
+	}
+
+	rv.Values = values
+	rv.isSet = true
+
+	return nil
+}
+
+// Get retrieves the current value.
+func (rv *Repeated[T]) Get() interface{} {
+	return rv.Values
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+type testRoute struct {
+	name string
+	addr string
+}
+
+func parseTestRoute(s string) (testRoute, error) {
+	name, addr, ok := strings.Cut(s, ":")
+	if !ok {
+		return testRoute{}, fmt.Errorf("expected name:host:port")
+	}
+	return testRoute{name, addr}, nil
+}
+
+func formatTestRoute(r testRoute) string {
+	return r.name + ":" + r.addr
+}
+
+func TestRepeated(t *testing.T) {
+	r := NewRepeated(parseTestRoute).WithFormat(formatTestRoute)
+	assert.Equal(t, r.Delimiter, "")
+	assert.Equal(t, r.String(), "")
+	assert.Equal(t, r.ValidValuesDescription(), "")
+
+	assert.Nil(t, r.Set("a:host1:80"))
+	assert.Nil(t, r.Set("b:host2:80,host3:80"))
+	assert.ArrayEqual(
+		t,
+		r.Values,
+		[]testRoute{{"a", "host1:80"}, {"b", "host2:80,host3:80"}},
+	)
+	assert.DeepEqual(t, r.Get(), r.Values)
+	assert.Equal(t, r.String(), "a:host1:80,b:host2:80,host3:80")
+
+	assert.ErrorContains(t, r.Set("nope"), "invalid flag value: nope: expected name:host:port")
+	assert.Equal(t, len(r.Values), 2)
+}
+
+func TestRepeatedZeroValue(t *testing.T) {
+	r := Repeated[int]{}
+	assert.ErrorContains(t, r.Set("1"), "invalid flag value: 1: no ParseFunc")
+	assert.Equal(t, len(r.Values), 0)
+
+	r.ResetDefault("1", "2")
+	assert.Equal(t, len(r.Values), 0)
+	assert.Equal(t, r.String(), "")
+}
+
+func TestRepeatedWithKey(t *testing.T) {
+	name := func(r testRoute) string { return r.name }
+
+	r := NewRepeated(parseTestRoute).WithKey(name, DuplicateKeyReplace)
+	assert.Nil(t, r.Set("a:1"))
+	assert.Nil(t, r.Set("b:2"))
+	assert.Nil(t, r.Set("a:3"))
+	assert.ArrayEqual(t, r.Values, []testRoute{{"a", "3"}, {"b", "2"}})
+
+	r = NewRepeated(parseTestRoute).WithKey(name, DuplicateKeyKeepFirst)
+	assert.Nil(t, r.Set("a:1"))
+	assert.Nil(t, r.Set("a:3"))
+	assert.ArrayEqual(t, r.Values, []testRoute{{"a", "1"}})
+
+	r = NewRepeated(parseTestRoute).WithKey(name, DuplicateKeyError)
+	assert.Nil(t, r.Set("a:1"))
+	assert.ErrorContains(t, r.Set("a:3"), "duplicate flag key(s): a")
+	assert.ArrayEqual(t, r.Values, []testRoute{{"a", "1"}})
+}
+
+func TestIntList(t *testing.T) {
+	l := NewIntList().WithAllowedValues(1, 2, 3)
+	assert.Equal(t, l.ValidValuesDescription(), `"1", "2", or "3"`)
+
+	assert.Nil(t, l.Set("1, 2,,"))
+	assert.Nil(t, l.Set("3"))
+	assert.ArrayEqual(t, l.Values, []int{1, 2, 3})
+	assert.Equal(t, l.String(), "1,2,3")
+
+	assert.ErrorContains(t, l.Set("4,1,5"), "invalid flag value(s): 4, 5")
+	assert.ErrorContains(t, l.Set("x"), "invalid flag value: x: ")
+	assert.ArrayEqual(t, l.Values, []int{1, 2, 3})
+}
+
+func TestDurationList(t *testing.T) {
+	l := NewDurationList()
+	l.ResetDefault("1s", "bad", "1m")
+	assert.ArrayEqual(t, l.Values, []time.Duration{time.Second, time.Minute})
+
+	assert.Nil(t, l.Set("5ms,2h"))
+	assert.ArrayEqual(t, l.Values, []time.Duration{5 * time.Millisecond, 2 * time.Hour})
+	assert.Equal(t, l.String(), "5ms,2h0m0s")
+}
+
+func TestHostPortList(t *testing.T) {
+	l := NewHostPortList()
+	assert.Nil(t, l.Set("example.com:80,[::1]:443"))
+	assert.Equal(t, len(l.Values), 2)
+	assert.Equal(t, l.Values[0].Addr(), "example.com:80")
+	assert.Equal(t, l.String(), "example.com:80,[::1]:443")
+
+	assert.NonNil(t, l.Set("example.com"))
+}
+
+func TestRepeatedFlagSetIntegration(t *testing.T) {
+	l := NewIntList()
+	l.ResetDefault("8080")
+
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.Var(&l, "port", "ports")
+	assert.Equal(t, fs.Lookup("port").DefValue, "8080")
+
+	assert.Nil(t, fs.Parse([]string{"-port=80,443", "-port", "8443"}))
+	assert.ArrayEqual(t, l.Values, []int{80, 443, 8443})
+}
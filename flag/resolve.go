@@ -0,0 +1,161 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbntabwriter "github.com/turbinelabs/nonstdlib/text/tabwriter"
+)
+
+// String returns a human-readable description of the Origin. For
+// environment variables, the key is included.
+func (o Origin) String() string {
+	if o.Source == EnvSource && o.Key != "" {
+		return fmt.Sprintf("%s (%s)", o.Source, o.Key)
+	}
+
+	return string(o.Source)
+}
+
+// EnvOrigins returns the Origin of each flag filled by the given
+// FromEnv, keyed by flag name, for use with Resolve.
+func EnvOrigins(fe FromEnv) map[string]Origin {
+	filled := fe.Filled()
+
+	origins := map[string]Origin{}
+	for _, f := range fe.AllFlags() {
+		key := EnvKey(fe.Prefix(), f.Name)
+		if _, ok := filled[key]; ok {
+			origins[f.Name] = Origin{Source: EnvSource, Key: key}
+		}
+	}
+
+	return origins
+}
+
+// FileOrigins returns the Origin of each flag filled by the given
+// FromFile, keyed by flag name, for use with Resolve.
+func FileOrigins(ff FromFile) map[string]Origin {
+	origins := map[string]Origin{}
+	for name := range ff.Filled() {
+		origins[name] = Origin{Source: FileSource, Key: name}
+	}
+
+	return origins
+}
+
+// ResolvedFlag describes the final value of a flag and its Origin.
+type ResolvedFlag struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source Source `json:"source"`
+	Key    string `json:"key,omitempty"`
+}
+
+// Origin returns the ResolvedFlag's Origin.
+func (r ResolvedFlag) Origin() Origin {
+	return Origin{Source: r.Source, Key: r.Key}
+}
+
+// Resolution reports the final value and Origin of every flag in a
+// FlagSet. See Resolve.
+type Resolution []ResolvedFlag
+
+// Resolve produces a Resolution for every flag in the FlagSet,
+// ordered by name. Each flag's Origin is taken from the first of the
+// given maps (such as those returned by Layered.Origins, EnvOrigins,
+// or FileOrigins) that contains it. Flags found in none of the maps
+// have CommandLineSource if they have been set and DefaultSource
+// otherwise. Values of flags marked sensitive are redacted.
+func Resolve(fs *flag.FlagSet, origins ...map[string]Origin) Resolution {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	resolution := Resolution{}
+	fs.VisitAll(func(f *flag.Flag) {
+		origin, found := Origin{}, false
+		for _, m := range origins {
+			if origin, found = m[f.Name]; found {
+				break
+			}
+		}
+
+		if !found {
+			if set[f.Name] {
+				origin = Origin{Source: CommandLineSource, Key: f.Name}
+			} else {
+				origin = Origin{Source: DefaultSource}
+			}
+		}
+
+		value := f.Value.String()
+		if usage.IsSensitive(f) {
+			value = redacted
+		}
+
+		resolution = append(
+			resolution,
+			ResolvedFlag{
+				Name:   f.Name,
+				Value:  value,
+				Source: origin.Source,
+				Key:    origin.Key,
+			},
+		)
+	})
+
+	sort.Slice(resolution, func(i, j int) bool {
+		return resolution[i].Name < resolution[j].Name
+	})
+
+	return resolution
+}
+
+// WriteTable writes the Resolution to w as an aligned table with
+// name, value, and source columns.
+func (r Resolution) WriteTable(w io.Writer) error {
+	rows := make([]string, len(r))
+	for i, rf := range r {
+		rows[i] = strings.Join([]string{rf.Name, rf.Value, rf.Origin().String()}, "\t")
+	}
+
+	_, err := io.WriteString(
+		w,
+		tbntabwriter.FormatWithHeader("NAME\tVALUE\tSOURCE", strings.Join(rows, "\n")),
+	)
+	return err
+}
+
+// WriteJSON writes the Resolution to w as an indented JSON array.
+func (r Resolution) WriteJSON(w io.Writer) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestOriginString(t *testing.T) {
+	assert.Equal(t, Origin{Source: EnvSource, Key: "X_Y"}.String(), "environment (X_Y)")
+	assert.Equal(t, Origin{Source: EnvSource}.String(), "environment")
+	assert.Equal(t, Origin{Source: FileSource, Key: "x"}.String(), "config file")
+	assert.Equal(t, Origin{Source: DefaultSource}.String(), "default")
+}
+
+func TestResolve(t *testing.T) {
+	fs, _, _, _ := testFlags()
+	fs.Parse([]string{"-bar=bar-cmdline"})
+
+	resolution := Resolve(
+		fs,
+		map[string]Origin{"qux": {Source: EnvSource, Key: "APP_QUX"}},
+		map[string]Origin{
+			"qux":     {Source: FileSource, Key: "qux"},
+			"foo-baz": {Source: FileSource, Key: "foo-baz"},
+		},
+	)
+
+	assert.DeepEqual(t, resolution, Resolution{
+		{Name: "bar", Value: "bar-cmdline", Source: CommandLineSource, Key: "bar"},
+		{Name: "foo-baz", Value: "", Source: FileSource, Key: "foo-baz"},
+		{Name: "qux", Value: redacted, Source: EnvSource, Key: "APP_QUX"},
+	})
+}
+
+func TestResolveDefaults(t *testing.T) {
+	fs, _, _, _ := testFlags()
+
+	resolution := Resolve(fs)
+	assert.Equal(t, len(resolution), 3)
+	for _, rf := range resolution {
+		assert.Equal(t, rf.Source, DefaultSource)
+		assert.Equal(t, rf.Key, "")
+	}
+}
+
+func TestResolveFromEnv(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().LookupEnv("APP_FOO_BAZ").Return("foo-env", true)
+	mockOS.EXPECT().LookupEnv("APP_QUX").Return("", false)
+
+	fs, _, _, _ := testFlags()
+	fs.Parse([]string{"-bar=bar-cmdline"})
+
+	fe := NewFromEnv(fs, "app").(fromEnv)
+	fe.os = mockOS
+	assert.Nil(t, fe.Fill())
+
+	assert.DeepEqual(t, EnvOrigins(fe), map[string]Origin{
+		"foo-baz": {Source: EnvSource, Key: "APP_FOO_BAZ"},
+	})
+
+	resolution := Resolve(fs, EnvOrigins(fe))
+	assert.Equal(t, resolution[0].Origin(), Origin{Source: CommandLineSource, Key: "bar"})
+	assert.Equal(t, resolution[1].Origin(), Origin{Source: EnvSource, Key: "APP_FOO_BAZ"})
+	assert.Equal(t, resolution[1].Value, "foo-env")
+	assert.Equal(t, resolution[2].Origin(), Origin{Source: DefaultSource})
+}
+
+func TestFileOrigins(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "config", "foo-baz = x\n")
+	defer cleanup()
+
+	fs, _, _, _ := testFlags()
+	ff := NewFromFile(fs, path)
+	assert.Nil(t, ff.Fill())
+
+	assert.DeepEqual(t, FileOrigins(ff), map[string]Origin{
+		"foo-baz": {Source: FileSource, Key: "foo-baz"},
+	})
+}
+
+func TestResolutionWriteTable(t *testing.T) {
+	resolution := Resolution{
+		{Name: "a", Value: "1", Source: CommandLineSource, Key: "a"},
+		{Name: "bb", Value: "22", Source: EnvSource, Key: "APP_BB"},
+		{Name: "c", Value: redacted, Source: DefaultSource},
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, resolution.WriteTable(buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.ArrayEqual(t, lines, []string{
+		"NAME  VALUE       SOURCE",
+		"a     1           command line",
+		"bb    22          environment (APP_BB)",
+		"c     <redacted>  default",
+	})
+}
+
+func TestResolutionWriteJSON(t *testing.T) {
+	resolution := Resolution{
+		{Name: "a", Value: "1", Source: CommandLineSource, Key: "a"},
+		{Name: "c", Value: "3", Source: DefaultSource},
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, resolution.WriteJSON(buf))
+
+	var decoded []map[string]string
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.DeepEqual(t, decoded, []map[string]string{
+		{"name": "a", "value": "1", "source": "command line", "key": "a"},
+		{"name": "c", "value": "3", "source": "default"},
+	})
+}
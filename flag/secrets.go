@@ -0,0 +1,196 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+//go:generate mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE --write_package_comment=false
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+)
+
+const (
+	// FileSecretScheme is the scheme of secret references naming a
+	// file containing the secret, e.g. "file:///run/secrets/db".
+	// Trailing newlines are removed from the file's contents.
+	FileSecretScheme = "file"
+
+	// EnvSecretScheme is the scheme of secret references naming an
+	// environment variable containing the secret, e.g.
+	// "env:DB_PASSWORD".
+	EnvSecretScheme = "env"
+)
+
+// SecretResolverFunc resolves a secret reference. It is given the
+// portion of the reference following the scheme and colon. For
+// example, given the reference "vault:secret/db", a resolver
+// registered for the "vault" scheme receives "secret/db".
+type SecretResolverFunc func(ref string) (string, error)
+
+// Secrets resolves the values of flags marked sensitive (see
+// usage.Sensitive) that are secret references, of the form
+// "scheme:reference", so that secrets need not appear in the
+// environment or on the command line. Values that do not begin with a
+// registered scheme are left unchanged. The flag's Value must accept
+// the reference itself, as string flags do, as well as the resolved
+// secret.
+type Secrets interface {
+	// Register adds a SecretResolverFunc for the given scheme,
+	// replacing any existing resolver for the scheme.
+	Register(scheme string, resolver SecretResolverFunc)
+
+	// Resolve replaces the value of each sensitive flag that is a
+	// secret reference with the secret it refers to. It should
+	// be invoked after the FlagSet is parsed and filled from any
+	// other sources. Errors never include secret values. Returns
+	// the first error encountered.
+	Resolve() error
+
+	// Resolved returns the secret reference from which each
+	// resolved flag's value was obtained, keyed by flag name.
+	Resolved() map[string]string
+}
+
+// NewSecrets produces a Secrets for the given FlagSet, with resolvers
+// registered for FileSecretScheme and EnvSecretScheme.
+func NewSecrets(fs *flag.FlagSet) Secrets {
+	s := &secrets{
+		fs:        fs,
+		os:        tbnos.New(),
+		resolvers: map[string]SecretResolverFunc{},
+		resolved:  map[string]string{},
+	}
+
+	s.Register(FileSecretScheme, s.resolveFile)
+	s.Register(EnvSecretScheme, s.resolveEnv)
+
+	return s
+}
+
+type secrets struct {
+	fs        *flag.FlagSet
+	os        tbnos.OS
+	resolvers map[string]SecretResolverFunc
+	resolved  map[string]string
+}
+
+func (s *secrets) Register(scheme string, resolver SecretResolverFunc) {
+	s.resolvers[scheme] = resolver
+}
+
+func (s *secrets) resolveFile(ref string) (string, error) {
+	path := strings.TrimPrefix(ref, "//")
+
+	f, err := s.os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func (s *secrets) resolveEnv(ref string) (string, error) {
+	value, found := s.os.LookupEnv(ref)
+	if !found {
+		return "", fmt.Errorf("environment variable %s is not set", ref)
+	}
+
+	return value, nil
+}
+
+// parseRef returns the scheme and reference of a secret reference, if
+// the value begins with a registered scheme.
+func (s *secrets) parseRef(value string) (string, string, bool) {
+	idx := strings.Index(value, ":")
+	if idx <= 0 {
+		return "", "", false
+	}
+
+	scheme := value[0:idx]
+	if _, ok := s.resolvers[scheme]; !ok {
+		return "", "", false
+	}
+
+	return scheme, value[idx+1:], true
+}
+
+func (s *secrets) Resolve() error {
+	names := []string{}
+	s.fs.VisitAll(func(f *flag.Flag) {
+		if usage.IsSensitive(f) {
+			names = append(names, f.Name)
+		}
+	})
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := s.fs.Lookup(name)
+		value := f.Value.String()
+
+		scheme, ref, ok := s.parseRef(value)
+		if !ok {
+			continue
+		}
+
+		secret, err := s.resolvers[scheme](ref)
+		if err != nil {
+			return fmt.Errorf(
+				"flag -%s: cannot resolve %s: %s",
+				name,
+				value,
+				redact(err.Error(), secret),
+			)
+		}
+
+		if err := resetAndSet(s.fs, f, secret); err != nil {
+			return fmt.Errorf(
+				"flag -%s: invalid value from %s: %s",
+				name,
+				value,
+				redact(err.Error(), secret),
+			)
+		}
+
+		s.resolved[name] = value
+	}
+
+	return nil
+}
+
+func (s *secrets) Resolved() map[string]string {
+	return s.resolved
+}
+
+// redact replaces any occurrence of secret in msg.
+func redact(msg, secret string) string {
+	if secret == "" {
+		return msg
+	}
+	return strings.Replace(msg, secret, redacted, -1)
+}
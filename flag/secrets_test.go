@@ -0,0 +1,148 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	tbnos "github.com/turbinelabs/nonstdlib/os"
+	"github.com/turbinelabs/test/assert"
+)
+
+func mkSecretsFlagSet() (*flag.FlagSet, *string, *string) {
+	fs := flag.NewFlagSet("secrets", flag.ContinueOnError)
+	password := fs.String("password", "", usage.Sensitive("password"))
+	pin := fs.String("pin", "", usage.Sensitive("pin"))
+	fs.String("plain", "", "not sensitive")
+	return fs, password, pin
+}
+
+func TestSecretsResolveFile(t *testing.T) {
+	path, cleanup := writeConfigFile(t, "db", "hunter2\n")
+	defer cleanup()
+
+	fs, password, _ := mkSecretsFlagSet()
+	fs.Parse([]string{"-password=file://" + path, "-plain=file://" + path})
+
+	s := NewSecrets(fs)
+	assert.Nil(t, s.Resolve())
+	assert.Equal(t, *password, "hunter2")
+	assert.Equal(t, fs.Lookup("plain").Value.String(), "file://"+path)
+	assert.DeepEqual(t, s.Resolved(), map[string]string{"password": "file://" + path})
+}
+
+func TestSecretsResolveFileError(t *testing.T) {
+	fs, _, _ := mkSecretsFlagSet()
+	fs.Parse([]string{"-password=file:///does/not/exist"})
+
+	err := NewSecrets(fs).Resolve()
+	assert.ErrorContains(t, err, "flag -password: cannot resolve file:///does/not/exist: ")
+}
+
+func TestSecretsResolveEnv(t *testing.T) {
+	ctrl := gomock.NewController(assert.Tracing(t))
+	defer ctrl.Finish()
+
+	mockOS := tbnos.NewMockOS(ctrl)
+	mockOS.EXPECT().LookupEnv("DB_PASSWORD").Return("hunter2", true)
+	mockOS.EXPECT().LookupEnv("DB_OTHER").Return("", false)
+
+	fs, password, _ := mkSecretsFlagSet()
+	fs.Parse([]string{"-password=env:DB_PASSWORD"})
+
+	s := NewSecrets(fs)
+	s.(*secrets).os = mockOS
+	assert.Nil(t, s.Resolve())
+	assert.Equal(t, *password, "hunter2")
+
+	fs.Set("password", "env:DB_OTHER")
+	assert.ErrorContains(
+		t,
+		s.Resolve(),
+		"flag -password: cannot resolve env:DB_OTHER: environment variable DB_OTHER is not set",
+	)
+}
+
+func TestSecretsResolveInvalidValueRedacted(t *testing.T) {
+	fs := flag.NewFlagSet("secrets", flag.ContinueOnError)
+
+	// accepts references, or a string of digits
+	var pin string
+	fs.Var(
+		NewValue(&pin, "", func(s string) (string, error) {
+			if strings.Contains(s, ":") || strings.Trim(s, "0123456789") == "" {
+				return s, nil
+			}
+			return "", fmt.Errorf("invalid pin %q", s)
+		}),
+		"pin",
+		usage.Sensitive("pin"),
+	)
+	fs.Parse([]string{"-pin=test:x"})
+
+	s := NewSecrets(fs)
+	s.Register("test", func(ref string) (string, error) { return "not-a-pin", nil })
+
+	err := s.Resolve()
+	assert.ErrorContains(t, err, "flag -pin: invalid value from test:x: ")
+	assert.ErrorContains(t, err, redacted)
+	assert.False(t, strings.Contains(err.Error(), "not-a-pin"))
+	assert.Equal(t, pin, "test:x")
+	assert.DeepEqual(t, s.Resolved(), map[string]string{})
+}
+
+func TestSecretsCustomScheme(t *testing.T) {
+	fs, password, pin := mkSecretsFlagSet()
+	fs.Parse([]string{"-password=vault:secret/db", "-pin=1234"})
+
+	s := NewSecrets(fs)
+	s.Register("vault", func(ref string) (string, error) {
+		if ref != "secret/db" {
+			return "", errors.New("not found")
+		}
+		return "s3cr3t", nil
+	})
+
+	assert.Nil(t, s.Resolve())
+	assert.Equal(t, *password, "s3cr3t")
+	assert.Equal(t, *pin, "1234")
+
+	fs.Set("password", "vault:nope")
+	assert.ErrorContains(t, s.Resolve(), "flag -password: cannot resolve vault:nope: not found")
+}
+
+func TestSecretsUnregisteredScheme(t *testing.T) {
+	fs, password, _ := mkSecretsFlagSet()
+	fs.Parse([]string{"-password=pass:word"})
+
+	s := NewSecrets(fs)
+	assert.Nil(t, s.Resolve())
+	assert.Equal(t, *password, "pass:word")
+	assert.DeepEqual(t, s.Resolved(), map[string]string{})
+}
+
+func TestRedact(t *testing.T) {
+	assert.Equal(t, redact("bad value x", "x"), fmt.Sprintf("bad value %s", redacted))
+	assert.Equal(t, redact("bad value", ""), "bad value")
+}
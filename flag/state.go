@@ -0,0 +1,316 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+)
+
+// SensitivePolicy determines how CaptureState handles flags marked
+// sensitive.
+type SensitivePolicy int
+
+const (
+	// OmitSensitive excludes sensitive flags from a State.
+	OmitSensitive SensitivePolicy = iota
+
+	// RedactSensitive includes sensitive flags in a State with the
+	// value "<redacted>". Redacted values are ignored by
+	// State.Apply.
+	RedactSensitive
+)
+
+// State is a serializable record of the effective values of a
+// FlagSet, keyed by flag name (including any scope prefix). A State
+// can be exported as command line arguments, environment variable
+// assignments, or a config document, and re-applied to a fresh
+// FlagSet with the same flags to reproduce the original
+// configuration.
+//
+// Each flag is recorded as a list of values, each of which is passed
+// to flag.Value.Set in turn. Multi-valued flags such as Strings,
+// Repeated, and StringMap record one entry per value, so that values
+// containing a delimiter (or flags with no delimiter) survive a round
+// trip. All other flags record a single value.
+type State map[string][]string
+
+// multiValued is implemented by flag.Values that hold a list of
+// values, each of which may be passed to Set.
+type multiValued interface {
+	stringValues() []string
+}
+
+// CaptureState records the flags in the FlagSet that have been set
+// or whose values differ from their defaults. Aliases defined with
+// FlagSet.Alias are not recorded; their values are recorded under the
+// flags they refer to. Sensitive flags are handled according to the
+// given SensitivePolicy.
+func CaptureState(fs *flag.FlagSet, policy SensitivePolicy) State {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	state := State{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if _, ok := AliasTarget(f); ok {
+			return
+		}
+
+		if !set[f.Name] && f.Value.String() == f.DefValue {
+			return
+		}
+
+		if usage.IsSensitive(f) {
+			if policy == OmitSensitive {
+				return
+			}
+			state[f.Name] = []string{redacted}
+			return
+		}
+
+		if mv, ok := f.Value.(multiValued); ok {
+			state[f.Name] = append([]string{}, mv.stringValues()...)
+		} else {
+			state[f.Name] = []string{f.Value.String()}
+		}
+	})
+
+	return state
+}
+
+// StateFromArgs produces a State from command line arguments of the
+// form produced by State.Args. Arguments of the form "-name" (or
+// "--name") are recorded with the value "true". Repeated arguments
+// with the same name are recorded as multiple values.
+func StateFromArgs(args []string) (State, error) {
+	state := State{}
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			return nil, fmt.Errorf("invalid argument %q: expected -name=value", arg)
+		}
+
+		nameValue := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		name, value := nameValue, "true"
+		if idx := strings.Index(nameValue, "="); idx >= 0 {
+			name, value = nameValue[0:idx], nameValue[idx+1:]
+		}
+
+		if name == "" || strings.HasPrefix(name, "-") {
+			return nil, fmt.Errorf("invalid argument %q: expected -name=value", arg)
+		}
+
+		state[name] = append(state[name], value)
+	}
+
+	return state, nil
+}
+
+// StateFromEnv produces a State from environment variable assignments
+// of the form "KEY=value", as returned by State.Env or os.Environ. Keys
+// are matched to flags in the FlagSet using EnvKey and the given
+// scopes; other keys are ignored. Multi-valued flags may also be given
+// as consecutive indexed keys (see FromEnv.Fill), which are appended to
+// any value given by the unindexed key.
+func StateFromEnv(fs *flag.FlagSet, env []string, scopes ...string) State {
+	values := map[string]string{}
+	for _, kv := range env {
+		if idx := strings.Index(kv, "="); idx >= 0 {
+			values[kv[0:idx]] = kv[idx+1:]
+		}
+	}
+
+	prefix := EnvKey(scopes...)
+	state := State{}
+	fs.VisitAll(func(f *flag.Flag) {
+		key := EnvKey(prefix, f.Name)
+		if value, ok := values[key]; ok {
+			state[f.Name] = []string{value}
+		}
+
+		if _, ok := f.Value.(multiValued); !ok {
+			return
+		}
+
+		for i := 0; ; i++ {
+			value, ok := values[key+"_"+strconv.Itoa(i)]
+			if !ok {
+				return
+			}
+			state[f.Name] = append(state[f.Name], value)
+		}
+	})
+
+	return state
+}
+
+// ReadState produces a State from a config document in the given
+// format. See ParseConfig.
+func ReadState(r io.Reader, format ConfigFormat) (State, error) {
+	values, err := parseConfigLists(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	return State(values), nil
+}
+
+func (s State) names() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Args returns the State as command line arguments of the form
+// "-name=value", ordered by flag name. Flags with multiple values
+// produce one argument per value.
+func (s State) Args() []string {
+	args := make([]string, 0, len(s))
+	for _, name := range s.names() {
+		values := s[name]
+		if len(values) == 0 {
+			values = []string{""}
+		}
+		for _, value := range values {
+			args = append(args, fmt.Sprintf("-%s=%s", name, value))
+		}
+	}
+	return args
+}
+
+// Env returns the State as environment variable assignments of the
+// form "KEY=value", suitable for use with FromEnv and the same scopes.
+// Flags with multiple values produce consecutive indexed assignments
+// (e.g., "KEY_0=a", "KEY_1=b"). Assignments are ordered by flag name.
+func (s State) Env(scopes ...string) []string {
+	prefix := EnvKey(scopes...)
+	env := make([]string, 0, len(s))
+	for _, name := range s.names() {
+		key := EnvKey(prefix, name)
+		values := s[name]
+		switch len(values) {
+		case 0:
+			env = append(env, key+"=")
+		case 1:
+			env = append(env, key+"="+values[0])
+		default:
+			for i, value := range values {
+				env = append(env, key+"_"+strconv.Itoa(i)+"="+value)
+			}
+		}
+	}
+	return env
+}
+
+// WriteConfig writes the State to w as a config document in the given
+// format. See ParseConfig. Flags with multiple values are written as
+// JSON arrays or as repeated keys, respectively.
+func (s State) WriteConfig(w io.Writer, format ConfigFormat) error {
+	switch format {
+	case JSONConfigFormat:
+		obj := make(map[string]interface{}, len(s))
+		for name, values := range s {
+			if len(values) == 1 {
+				obj[name] = values[0]
+			} else {
+				obj[name] = append([]string{}, values...)
+			}
+		}
+
+		b, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+
+	case KeyValueConfigFormat:
+		for _, name := range s.names() {
+			values := s[name]
+			if len(values) == 0 {
+				values = []string{""}
+			}
+
+			for _, value := range values {
+				if value != strings.TrimSpace(value) ||
+					strings.HasPrefix(value, `"`) ||
+					strings.ContainsAny(value, "\r\n") {
+					value = strconv.Quote(value)
+				}
+
+				if _, err := fmt.Fprintf(w, "%s=%s\n", name, value); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown config format %q", format)
+	}
+}
+
+// Apply sets the flags in the FlagSet to the values in the State,
+// replacing any default values of multi-valued flags such as Strings.
+// Each of a flag's values is set in turn. Redacted values of sensitive
+// flags are ignored. An error is returned if the State contains a flag
+// not defined in the FlagSet or if a value cannot be set.
+func (s State) Apply(fs *flag.FlagSet) error {
+	for _, name := range s.names() {
+		f := fs.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag -%s", name)
+		}
+
+		values := s[name]
+		if len(values) == 1 && values[0] == redacted && usage.IsSensitive(f) {
+			continue
+		}
+
+		if len(values) == 0 {
+			if r, ok := f.Value.(resettable); ok {
+				r.ResetDefault()
+				continue
+			}
+			values = []string{""}
+		}
+
+		if err := resetAndSet(fs, f, values[0]); err != nil {
+			return fmt.Errorf("flag -%s: %s", name, err)
+		}
+
+		for _, value := range values[1:] {
+			if err := fs.Set(name, value); err != nil {
+				return fmt.Errorf("flag -%s: %s", name, err)
+			}
+		}
+	}
+
+	return nil
+}
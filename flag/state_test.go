@@ -0,0 +1,275 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"bytes"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+	"github.com/turbinelabs/test/assert"
+)
+
+type stateTestFlags struct {
+	fs       *flag.FlagSet
+	name     *string
+	count    *int
+	verbose  *bool
+	tags     Strings
+	password *string
+	delay    *time.Duration
+	message  *string
+}
+
+func newStateTestFlags() *stateTestFlags {
+	tfs := NewTestFlagSet()
+	s := &stateTestFlags{fs: tfs.Unwrap()}
+
+	s.name = tfs.String("name", "default", "the name")
+	s.count = tfs.Int("count", 1, "the count")
+	s.verbose = tfs.Bool("verbose", false, "verbosity")
+	s.tags = NewStrings()
+	s.tags.ResetDefault("x", "y")
+	tfs.Var(&s.tags, "tags", "the tags")
+	s.password = tfs.String("password", "", usage.Sensitive("the password"))
+	s.delay = tfs.Scope("exec", "").Duration("delay", time.Second, "the delay")
+	s.message = tfs.String("message", "", "the message")
+	tfs.Alias("old-name", "name")
+
+	return s
+}
+
+func (s *stateTestFlags) set(t *testing.T) {
+	assert.Nil(t, s.fs.Parse([]string{
+		"-name=bob",
+		"-count=1",
+		"-verbose",
+		"-tags=a,b",
+		"-password=hunter2",
+		"-exec.delay=5s",
+		"-message=  hello\nworld",
+	}))
+}
+
+func TestCaptureState(t *testing.T) {
+	s := newStateTestFlags()
+
+	assert.Equal(t, len(CaptureState(s.fs, OmitSensitive)), 0)
+
+	s.set(t)
+
+	expected := State{
+		"name":       {"bob"},
+		"count":      {"1"},
+		"verbose":    {"true"},
+		"tags":       {"a", "b"},
+		"exec.delay": {"5s"},
+		"message":    {"  hello\nworld"},
+	}
+	assert.DeepEqual(t, CaptureState(s.fs, OmitSensitive), expected)
+
+	expected["password"] = []string{"<redacted>"}
+	assert.DeepEqual(t, CaptureState(s.fs, RedactSensitive), expected)
+}
+
+func TestStateArgs(t *testing.T) {
+	s := newStateTestFlags()
+	s.set(t)
+	state := CaptureState(s.fs, OmitSensitive)
+
+	assert.ArrayEqual(
+		t,
+		state.Args(),
+		[]string{
+			"-count=1",
+			"-exec.delay=5s",
+			"-message=  hello\nworld",
+			"-name=bob",
+			"-tags=a",
+			"-tags=b",
+			"-verbose=true",
+		},
+	)
+
+	restored := newStateTestFlags()
+	assert.Nil(t, restored.fs.Parse(state.Args()))
+	assert.DeepEqual(t, CaptureState(restored.fs, OmitSensitive), state)
+	assert.ArrayEqual(t, restored.tags.Strings, []string{"a", "b"})
+
+	parsed, err := StateFromArgs(append(state.Args(), "--debug"))
+	assert.Nil(t, err)
+	state["debug"] = []string{"true"}
+	assert.DeepEqual(t, parsed, state)
+}
+
+func TestStateFromArgsErrors(t *testing.T) {
+	_, err := StateFromArgs([]string{"name=bob"})
+	assert.ErrorContains(t, err, `invalid argument "name=bob"`)
+
+	_, err = StateFromArgs([]string{"-=bob"})
+	assert.ErrorContains(t, err, `invalid argument "-=bob"`)
+
+	_, err = StateFromArgs([]string{"---name=bob"})
+	assert.ErrorContains(t, err, `invalid argument "---name=bob"`)
+}
+
+func TestStateEnv(t *testing.T) {
+	s := newStateTestFlags()
+	s.set(t)
+	state := CaptureState(s.fs, RedactSensitive)
+
+	env := state.Env("app")
+	assert.ArrayEqual(
+		t,
+		env,
+		[]string{
+			"APP_COUNT=1",
+			"APP_EXEC_DELAY=5s",
+			"APP_MESSAGE=  hello\nworld",
+			"APP_NAME=bob",
+			"APP_PASSWORD=<redacted>",
+			"APP_TAGS_0=a",
+			"APP_TAGS_1=b",
+			"APP_VERBOSE=true",
+		},
+	)
+
+	restored := newStateTestFlags()
+	parsed := StateFromEnv(restored.fs, append(env, "APP_OTHER=1", "PATH=/bin", "NOEQUALS"), "app")
+	assert.DeepEqual(t, parsed, state)
+
+	assert.Nil(t, parsed.Apply(restored.fs))
+	assert.Equal(t, *restored.password, "")
+	assert.DeepEqual(t, CaptureState(restored.fs, OmitSensitive), CaptureState(s.fs, OmitSensitive))
+}
+
+func TestStateConfig(t *testing.T) {
+	s := newStateTestFlags()
+	s.set(t)
+	state := CaptureState(s.fs, OmitSensitive)
+
+	for _, format := range []ConfigFormat{JSONConfigFormat, KeyValueConfigFormat} {
+		assert.Group(string(format), t, func(g *assert.G) {
+			buf := &bytes.Buffer{}
+			assert.Nil(g, state.WriteConfig(buf, format))
+
+			parsed, err := ReadState(buf, format)
+			assert.Nil(g, err)
+			assert.DeepEqual(g, parsed, state)
+
+			restored := newStateTestFlags()
+			assert.Nil(g, parsed.Apply(restored.fs))
+			assert.DeepEqual(g, CaptureState(restored.fs, OmitSensitive), state)
+			assert.ArrayEqual(g, restored.tags.Strings, []string{"a", "b"})
+		})
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(t, State{"a": {"1", "2"}, "b.c": {`"q"`}}.WriteConfig(buf, KeyValueConfigFormat))
+	assert.Equal(t, buf.String(), "a=1\na=2\nb.c=\"\\\"q\\\"\"\n")
+
+	assert.ErrorContains(t, state.WriteConfig(buf, ConfigFormat("yaml")), `unknown config format "yaml"`)
+
+	_, err := ReadState(buf, ConfigFormat("yaml"))
+	assert.ErrorContains(t, err, `unknown config format "yaml"`)
+}
+
+func TestStateApplyErrors(t *testing.T) {
+	s := newStateTestFlags()
+	assert.ErrorContains(t, State{"nope": {"1"}}.Apply(s.fs), "unknown flag -nope")
+	assert.ErrorContains(t, State{"count": {"x"}}.Apply(s.fs), "flag -count: ")
+	assert.ErrorContains(t, State{"count": {"1", "x"}}.Apply(s.fs), "flag -count: ")
+}
+
+type multiValuedStateTestFlags struct {
+	fs        *flag.FlagSet
+	routes    Repeated[string]
+	labels    StringMap
+	endpoints Endpoints
+}
+
+func newMultiValuedStateTestFlags() *multiValuedStateTestFlags {
+	tfs := NewTestFlagSet()
+	s := &multiValuedStateTestFlags{
+		fs:        tfs.Unwrap(),
+		routes:    NewRepeated(func(s string) (string, error) { return s, nil }),
+		labels:    NewStringMap(),
+		endpoints: NewEndpoints(80),
+	}
+
+	tfs.Var(&s.routes, "route", "the routes")
+	tfs.Var(&s.labels, "labels", "the labels")
+	tfs.Var(&s.endpoints, "endpoints", "the endpoints")
+
+	return s
+}
+
+func TestStateRoundTripMultiValued(t *testing.T) {
+	s := newMultiValuedStateTestFlags()
+	assert.Nil(t, s.fs.Parse([]string{
+		"-route=a,b",
+		"-route=c",
+		"-labels=x=1,y=2",
+		"-labels=z=3",
+		"-endpoints=h1:1,h2",
+	}))
+
+	state := CaptureState(s.fs, OmitSensitive)
+	assert.DeepEqual(t, state, State{
+		"route":     {"a,b", "c"},
+		"labels":    {"x=1", "y=2", "z=3"},
+		"endpoints": {"h1:1", "h2:80"},
+	})
+
+	check := func(g *assert.G, parsed State) {
+		assert.DeepEqual(g, parsed, state)
+
+		restored := newMultiValuedStateTestFlags()
+		assert.Nil(g, parsed.Apply(restored.fs))
+		assert.ArrayEqual(g, restored.routes.Values, s.routes.Values)
+		assert.DeepEqual(g, restored.labels.Map, s.labels.Map)
+		assert.DeepEqual(g, restored.endpoints.Values, s.endpoints.Values)
+		assert.DeepEqual(g, CaptureState(restored.fs, OmitSensitive), state)
+	}
+
+	assert.Group("args", t, func(g *assert.G) {
+		restored := newMultiValuedStateTestFlags()
+		assert.Nil(g, restored.fs.Parse(state.Args()))
+		assert.ArrayEqual(g, restored.routes.Values, []string{"a,b", "c"})
+
+		parsed, err := StateFromArgs(state.Args())
+		assert.Nil(g, err)
+		check(g, parsed)
+	})
+
+	assert.Group("env", t, func(g *assert.G) {
+		check(g, StateFromEnv(newMultiValuedStateTestFlags().fs, state.Env("app"), "app"))
+	})
+
+	for _, format := range []ConfigFormat{JSONConfigFormat, KeyValueConfigFormat} {
+		assert.Group(string(format), t, func(g *assert.G) {
+			buf := &bytes.Buffer{}
+			assert.Nil(g, state.WriteConfig(buf, format))
+
+			parsed, err := ReadState(buf, format)
+			assert.Nil(g, err)
+			check(g, parsed)
+		})
+	}
+}
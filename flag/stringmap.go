@@ -0,0 +1,224 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/turbinelabs/nonstdlib/arrays/indexof"
+)
+
+// DuplicateKeyPolicy determines how flag values handle a key that is
+// given more than once.
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateKeyReplace causes later values to replace earlier
+	// values with the same key.
+	DuplicateKeyReplace DuplicateKeyPolicy = iota
+
+	// DuplicateKeyKeepFirst causes later values with the same key
+	// as an earlier value to be ignored.
+	DuplicateKeyKeepFirst
+
+	// DuplicateKeyError causes a repeated key to produce an error.
+	DuplicateKeyError
+)
+
+// StringMap conforms to the flag.Value and flag.Getter interfaces, and
+// can be used to populate a map of strings from a flag.Flag. After
+// command line parsing, the values can be retrieved via the Map
+// field. This implementation of flag.Value accepts multiple key/value
+// pairs via a single flag (e.g., "-flag=k1=v1,k2=v2"), via repetition
+// of the flag (e.g., "-flag=k1=v1 -flag=k2=v2"), or a combination of
+// the two styles. Keys given more than once are handled according to
+// the DuplicateKeys policy. Use ResetDefault to configure default
+// values or to prepare StringMap for re-use.
+type StringMap struct {
+	// Populated from the command line.
+	Map map[string]string
+
+	// All possible keys allowed to appear in Map. An empty slice
+	// means any key is allowed in Map.
+	AllowedKeys []string
+
+	// Delimiter used to separate key/value pairs.
+	Delimiter string
+
+	// Separator used to separate a key from its value.
+	Separator string
+
+	// Policy for keys given more than once.
+	DuplicateKeys DuplicateKeyPolicy
+
+	isSet bool
+}
+
+var _ flag.Getter = &StringMap{}
+var _ ConstrainedValue = &StringMap{}
+
+// NewStringMap produces a StringMap with the default delimiter (","),
+// separator ("="), and duplicate key policy (DuplicateKeyReplace).
+func NewStringMap() StringMap {
+	return StringMap{Delimiter: ",", Separator: "="}
+}
+
+// NewStringMapWithConstraint produces a StringMap with a set of
+// allowed keys and the default delimiter, separator, and duplicate
+// key policy.
+func NewStringMapWithConstraint(allowedKeys ...string) StringMap {
+	return StringMap{AllowedKeys: allowedKeys, Delimiter: ",", Separator: "="}
+}
+
+// WithDuplicateKeys sets the policy for keys given more than once.
+func (smv StringMap) WithDuplicateKeys(policy DuplicateKeyPolicy) StringMap {
+	smv.DuplicateKeys = policy
+	return smv
+}
+
+// ValidValuesDescription returns a string describing the allowed
+// keys for this StringMap. For example: "a", "b", or "c". If this
+// StringMap is unconstrained, it returns an empty string.
+func (smv *StringMap) ValidValuesDescription() string {
+	return allowedValuesToDescription(smv.AllowedKeys)
+}
+
+// Retrieves the key/value pairs set on StringMap, ordered by key and
+// joined by the delimiter.
+func (smv *StringMap) String() string {
+	if smv == nil || len(smv.Map) == 0 {
+		return ""
+	}
+
+	return strings.Join(smv.stringValues(), smv.Delimiter)
+}
+
+// stringValues returns the key/value pairs set on StringMap, ordered
+// by key, each of which may be passed to Set.
+func (smv *StringMap) stringValues() []string {
+	keys := make([]string, 0, len(smv.Map))
+	for k := range smv.Map {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + smv.Separator + smv.Map[k]
+	}
+	return pairs
+}
+
+// ResetDefault resets StringMap for use and assigns the given
+// key/value pairs (e.g., "k=v") as the default value. Any call to Set
+// (e.g., via flag.FlagSet) will replace these values. Default values
+// are not checked against the AllowedKeys. A pair without a separator
+// is treated as a key with an empty value.
+func (smv *StringMap) ResetDefault(pairs ...string) {
+	smv.Map = make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		k, v, _ := strings.Cut(pair, smv.Separator)
+		smv.Map[k] = v
+	}
+	smv.isSet = false
+}
+
+// Set sets the current value. The first call (after initialization or
+// a call to ResetDefault) will replace all current values. Subsequent
+// calls add values. If any pair is malformed, has a disallowed key, or
+// repeats a key under the DuplicateKeyError policy, an error is
+// returned and the current value is unchanged.
+func (smv *StringMap) Set(value string) error {
+	m := map[string]string{}
+	if smv.isSet {
+		for k, v := range smv.Map {
+			m[k] = v
+		}
+	}
+
+	malformed := []string{}
+	disallowed := []string{}
+	duplicated := []string{}
+
+	for _, pair := range strings.Split(value, smv.Delimiter) {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		k, v, ok := strings.Cut(pair, smv.Separator)
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			malformed = append(malformed, pair)
+			continue
+		}
+
+		if len(smv.AllowedKeys) > 0 {
+			if indexof.String(smv.AllowedKeys, k) == indexof.NotFound {
+				disallowed = append(disallowed, k)
+				continue
+			}
+		}
+
+		if _, exists := m[k]; exists {
+			switch smv.DuplicateKeys {
+			case DuplicateKeyKeepFirst:
+				continue
+			case DuplicateKeyError:
+				duplicated = append(duplicated, k)
+				continue
+			}
+		}
+
+		m[k] = strings.TrimSpace(v)
+	}
+
+	if len(malformed) > 0 {
+		return fmt.Errorf(
+			"invalid flag value(s): %s, expected key%svalue",
+			strings.Join(malformed, smv.Delimiter+" "),
+			smv.Separator,
+		)
+	}
+
+	if len(disallowed) > 0 {
+		return fmt.Errorf(
+			"invalid flag key(s): %s",
+			strings.Join(disallowed, smv.Delimiter+" "),
+		)
+	}
+
+	if len(duplicated) > 0 {
+		return fmt.Errorf(
+			"duplicate flag key(s): %s",
+			strings.Join(duplicated, smv.Delimiter+" "),
+		)
+	}
+
+	smv.Map = m
+	smv.isSet = true
+
+	return nil
+}
+
+// Get retrieves the current value.
+func (smv *StringMap) Get() interface{} {
+	return smv.Map
+}
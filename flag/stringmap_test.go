@@ -0,0 +1,106 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/turbinelabs/test/assert"
+)
+
+func TestNewStringMap(t *testing.T) {
+	m := NewStringMap()
+	assert.Nil(t, m.Map)
+	assert.Nil(t, m.AllowedKeys)
+	assert.Equal(t, m.Delimiter, ",")
+	assert.Equal(t, m.Separator, "=")
+	assert.Equal(t, m.DuplicateKeys, DuplicateKeyReplace)
+}
+
+func TestNewStringMapWithConstraint(t *testing.T) {
+	m := NewStringMapWithConstraint("a", "b").WithDuplicateKeys(DuplicateKeyError)
+	assert.ArrayEqual(t, m.AllowedKeys, []string{"a", "b"})
+	assert.Equal(t, m.DuplicateKeys, DuplicateKeyError)
+	assert.Equal(t, m.ValidValuesDescription(), `"a" or "b"`)
+}
+
+func TestStringMapString(t *testing.T) {
+	m := &StringMap{Map: map[string]string{"b": "2", "a": "1"}, Delimiter: ";", Separator: ":"}
+	assert.Equal(t, m.String(), "a:1;b:2")
+
+	var nilMap *StringMap
+	assert.Equal(t, nilMap.String(), "")
+}
+
+func TestStringMapSet(t *testing.T) {
+	m := NewStringMap()
+	assert.Nil(t, m.Set(" a = 1 ,, b=x=y "))
+	assert.DeepEqual(t, m.Map, map[string]string{"a": "1", "b": "x=y"})
+	assert.DeepEqual(t, m.Get(), m.Map)
+
+	assert.Nil(t, m.Set("c=,a=2"))
+	assert.DeepEqual(t, m.Map, map[string]string{"a": "2", "b": "x=y", "c": ""})
+
+	assert.ErrorContains(t, m.Set("d=1,e,=2"), "invalid flag value(s): e, =2, expected key=value")
+	assert.DeepEqual(t, m.Map, map[string]string{"a": "2", "b": "x=y", "c": ""})
+}
+
+func TestStringMapSetWithConstraint(t *testing.T) {
+	m := NewStringMapWithConstraint("a", "b")
+	assert.Nil(t, m.Set("a=1"))
+	assert.ErrorContains(t, m.Set("b=2,c=3,d=4"), "invalid flag key(s): c, d")
+	assert.DeepEqual(t, m.Map, map[string]string{"a": "1"})
+}
+
+func TestStringMapDuplicateKeys(t *testing.T) {
+	m := NewStringMap().WithDuplicateKeys(DuplicateKeyKeepFirst)
+	assert.Nil(t, m.Set("a=1,a=2"))
+	assert.Nil(t, m.Set("a=3,b=4"))
+	assert.DeepEqual(t, m.Map, map[string]string{"a": "1", "b": "4"})
+
+	m = NewStringMap().WithDuplicateKeys(DuplicateKeyError)
+	assert.ErrorContains(t, m.Set("a=1,a=2"), "duplicate flag key(s): a")
+	assert.Nil(t, m.Map)
+	assert.Nil(t, m.Set("a=1"))
+	assert.ErrorContains(t, m.Set("b=2,a=3"), "duplicate flag key(s): a")
+	assert.DeepEqual(t, m.Map, map[string]string{"a": "1"})
+}
+
+func TestStringMapResetDefault(t *testing.T) {
+	m := NewStringMapWithConstraint("a")
+	m.ResetDefault("x=1", "y")
+	assert.DeepEqual(t, m.Map, map[string]string{"x": "1", "y": ""})
+
+	assert.Nil(t, m.Set("a=2"))
+	assert.DeepEqual(t, m.Map, map[string]string{"a": "2"})
+
+	m.ResetDefault()
+	assert.DeepEqual(t, m.Map, map[string]string{})
+}
+
+func TestStringMapFlagSetIntegration(t *testing.T) {
+	m := NewStringMap()
+	m.ResetDefault("k=default")
+
+	fs := flag.NewFlagSet("map", flag.ContinueOnError)
+	fs.Var(&m, "header", "headers")
+	assert.Equal(t, fs.Lookup("header").DefValue, "k=default")
+
+	assert.Nil(t, fs.Parse([]string{"-header=k1=v1,k2=v2", "-header", "k3=v3"}))
+	assert.DeepEqual(t, m.Map, map[string]string{"k1": "v1", "k2": "v2", "k3": "v3"})
+}
@@ -72,6 +72,12 @@ func (ssv *Strings) String() string {
 	return strings.Join(ssv.Strings, ssv.Delimiter)
 }
 
+// stringValues returns the values set on Strings, each of which may
+// be passed to Set.
+func (ssv *Strings) stringValues() []string {
+	return ssv.Strings
+}
+
 // ResetDefault resets Strings for use and assigns the given values as
 // the default value. Any call to Set (e.g., via flag.FlagSet) will
 // replace these values. Default values are not checked against the
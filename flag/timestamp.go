@@ -17,11 +17,14 @@ limitations under the License.
 package flag
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"strconv"
 	"strings"
 	"time"
+
+	tbntime "github.com/turbinelabs/nonstdlib/time"
 )
 
 // TimestampConversionFencepost represents an integer time value.
@@ -38,6 +41,9 @@ const TimestampConversionFencepost = int64(95617584000)
 // - timestamps in time.RFC3339Nano format (fractional seconds
 //   optional)
 //
+// - timestamps without a time zone ("2006-01-02T15:04:05") and dates
+//   ("2006-01-02"), interpreted in UTC
+//
 // - integer seconds since the Unix epoch (see
 //   TimestampConversionFencepost)
 //
@@ -45,6 +51,18 @@ const TimestampConversionFencepost = int64(95617584000)
 //   TimestampConversionFencepost)
 //
 // - "now" (case insensitive)
+//
+// - durations relative to the current time, optionally preceded by
+//   "now" (e.g., "-15m", "now-1h", "+2d"). In addition to the units
+//   accepted by time.ParseDuration, "d" denotes 24 hours.
+//
+// - "today", "yesterday", and "tomorrow" (case insensitive), denoting
+//   midnight UTC
+//
+// Dates, timestamps without a time zone, and the named days may be
+// followed by a space and a time zone name (e.g., "2018-06-01
+// America/Los_Angeles"), which replaces UTC. See ZonedTimestamp to
+// use another default location or tbntime.Source.
 type Timestamp struct {
 	Value time.Time
 }
@@ -58,25 +76,13 @@ func NewTimestamp(defaultTime time.Time) Timestamp {
 
 // Set sets the current value of the Timestamp.
 func (t *Timestamp) Set(value string) error {
-	if strings.ToLower(value) == "now" {
-		t.Value = time.Now()
-		return nil
-	} else if ticks, err := strconv.ParseInt(value, 10, 64); err == nil {
-		if ticks >= TimestampConversionFencepost {
-			t.Value = time.Unix(ticks/1000, (ticks%1000)*int64(time.Millisecond)).UTC()
-		} else {
-			t.Value = time.Unix(ticks, 0).UTC()
-		}
-		return nil
-	} else if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
-		t.Value = ts
-		return nil
+	ts, err := parseTimestamp(value, nil, nil)
+	if err != nil {
+		return err
 	}
 
-	return fmt.Errorf(
-		"cannot parse '%s': expecting seconds or milliseconds since the Unix epoch or RFC3339 format (fractional seconds optional)",
-		value,
-	)
+	t.Value = ts
+	return nil
 }
 
 // Get retrieves the current value of the Timestamp.
@@ -89,3 +95,229 @@ func (t *Timestamp) Get() interface{} {
 func (t *Timestamp) String() string {
 	return t.Value.Format(time.RFC3339Nano)
 }
+
+// ZonedTimestamp is a Timestamp that interprets dates, timestamps
+// without a time zone, and named days in a given location rather than
+// UTC, and that may obtain the current time from its own
+// tbntime.Source.
+type ZonedTimestamp struct {
+	Timestamp
+
+	source   tbntime.Source
+	location *time.Location
+}
+
+var _ flag.Getter = &ZonedTimestamp{}
+
+// NewZonedTimestamp creates a new ZonedTimestamp with the given
+// default time and location. A nil location is treated as UTC.
+func NewZonedTimestamp(defaultTime time.Time, location *time.Location) ZonedTimestamp {
+	return ZonedTimestamp{Timestamp: Timestamp{Value: defaultTime}, location: location}
+}
+
+// WithSource returns a copy of the ZonedTimestamp that obtains the
+// current time from the given tbntime.Source.
+func (t ZonedTimestamp) WithSource(source tbntime.Source) ZonedTimestamp {
+	t.source = source
+	return t
+}
+
+// Set sets the current value of the ZonedTimestamp.
+func (t *ZonedTimestamp) Set(value string) error {
+	ts, err := parseTimestamp(value, t.source, t.location)
+	if err != nil {
+		return err
+	}
+
+	t.Value = ts
+	return nil
+}
+
+var (
+	namedDays = map[string]int{
+		"yesterday": -1,
+		"today":     0,
+		"tomorrow":  1,
+	}
+
+	zonelessLayouts = []string{
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02",
+	}
+)
+
+func parseTimestamp(
+	value string,
+	source tbntime.Source,
+	location *time.Location,
+) (time.Time, error) {
+	if source == nil {
+		source = tbntime.NewSource()
+	}
+	if location == nil {
+		location = time.UTC
+	}
+
+	lower := strings.ToLower(strings.TrimSpace(value))
+
+	if lower == "now" {
+		return source.Now(), nil
+	} else if ticks, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if ticks >= TimestampConversionFencepost {
+			return time.Unix(ticks/1000, (ticks%1000)*int64(time.Millisecond)).UTC(), nil
+		}
+		return time.Unix(ticks, 0).UTC(), nil
+	} else if ts, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return ts, nil
+	} else if d, ok := parseRelative(lower); ok {
+		return source.Now().Add(d), nil
+	}
+
+	// the remaining forms may be followed by a time zone name
+	if idx := strings.LastIndex(value, " "); idx > 0 {
+		loc, err := time.LoadLocation(value[idx+1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("cannot parse '%s': unknown time zone: %s", value, err)
+		}
+		location = loc
+		value = strings.TrimSpace(value[0:idx])
+		lower = strings.ToLower(value)
+	}
+
+	if days, ok := namedDays[lower]; ok {
+		now := source.Now().In(location)
+		midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+		return midnight.AddDate(0, 0, days), nil
+	}
+
+	for _, layout := range zonelessLayouts {
+		if ts, err := time.ParseInLocation(layout, value, location); err == nil {
+			return ts, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf(
+		"cannot parse '%s': expecting seconds or milliseconds since the Unix epoch, RFC3339 format (fractional seconds optional), a date, a relative time, or now, today, yesterday, or tomorrow",
+		value,
+	)
+}
+
+// parseRelative parses a signed duration, optionally preceded by
+// "now", such as "-15m" or "now+2d".
+func parseRelative(value string) (time.Duration, bool) {
+	value = strings.TrimPrefix(value, "now")
+	if !strings.HasPrefix(value, "-") && !strings.HasPrefix(value, "+") {
+		return 0, false
+	}
+
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.ParseInt(value[0:len(value)-1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(days) * 24 * time.Hour, true
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false
+	}
+	return d, true
+}
+
+// TimeRange conforms to the flag.Value and flag.Getter interfaces. It
+// can be used to populate a range of time from a command line argument
+// of the form "start..end", where start and end are any of the forms
+// accepted by ZonedTimestamp (e.g., "yesterday..today" or "-1h..now"). If
+// end is omitted, it is the current time. The start must be before the
+// end.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+
+	source   tbntime.Source
+	location *time.Location
+}
+
+var _ flag.Getter = &TimeRange{}
+
+// NewTimeRange creates a new TimeRange with the given default start
+// and end.
+func NewTimeRange(start, end time.Time) TimeRange {
+	return TimeRange{Start: start, End: end}
+}
+
+// WithSource returns a copy of the TimeRange that obtains the current
+// time from the given tbntime.Source.
+func (r TimeRange) WithSource(source tbntime.Source) TimeRange {
+	r.source = source
+	return r
+}
+
+// WithLocation returns a copy of the TimeRange that interprets dates,
+// timestamps without a time zone, and named days in the given
+// location. The default location is UTC.
+func (r TimeRange) WithLocation(location *time.Location) TimeRange {
+	r.location = location
+	return r
+}
+
+// Duration returns the length of the TimeRange.
+func (r *TimeRange) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// Set sets the current value of the TimeRange.
+func (r *TimeRange) Set(value string) error {
+	parts := strings.SplitN(value, "..", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("cannot parse '%s': expecting start..end", value)
+	}
+
+	startStr := strings.TrimSpace(parts[0])
+	if startStr == "" {
+		return errors.New("time range is missing a start time")
+	}
+
+	endStr := strings.TrimSpace(parts[1])
+	if endStr == "" {
+		endStr = "now"
+	}
+
+	start, err := parseTimestamp(startStr, r.source, r.location)
+	if err != nil {
+		return err
+	}
+
+	end, err := parseTimestamp(endStr, r.source, r.location)
+	if err != nil {
+		return err
+	}
+
+	if !start.Before(end) {
+		return fmt.Errorf(
+			"time range start %s must be before end %s",
+			start.Format(time.RFC3339Nano),
+			end.Format(time.RFC3339Nano),
+		)
+	}
+
+	r.Start = start
+	r.End = end
+	return nil
+}
+
+// Get retrieves the current value of the TimeRange.
+func (r *TimeRange) Get() interface{} {
+	return *r
+}
+
+// String returns the current value of the TimeRange as two RFC3339
+// Nano format timestamps separated by "..".
+func (r *TimeRange) String() string {
+	if r == nil || (r.Start.IsZero() && r.End.IsZero()) {
+		return ""
+	}
+
+	return r.Start.Format(time.RFC3339Nano) + ".." + r.End.Format(time.RFC3339Nano)
+}
@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	tbntime "github.com/turbinelabs/nonstdlib/time"
 	"github.com/turbinelabs/test/assert"
 )
 
@@ -116,3 +117,143 @@ func TestTimestampStringUsesRFC3339Nano(t *testing.T) {
 
 	assert.Equal(t, ts.String(), inRFC3339Nano)
 }
+
+var tsReference = time.Date(2018, 6, 15, 10, 30, 0, 0, time.UTC)
+
+func TestTimestampSetHandlesRelativeTimes(t *testing.T) {
+	testcases := []struct {
+		value    string
+		expected time.Time
+	}{
+		{"-15m", tsReference.Add(-15 * time.Minute)},
+		{"+90s", tsReference.Add(90 * time.Second)},
+		{"now-1h", tsReference.Add(-time.Hour)},
+		{"NOW+1h30m", tsReference.Add(90 * time.Minute)},
+		{"-2d", tsReference.AddDate(0, 0, -2)},
+		{"now", tsReference},
+	}
+
+	tbntime.WithTimeAt(tsReference, func(cs tbntime.ControlledSource) {
+		for _, tc := range testcases {
+			assert.Group(tc.value, t, func(g *assert.G) {
+				ts := NewZonedTimestamp(time.Time{}, nil).WithSource(cs)
+				assert.Nil(g, ts.Set(tc.value))
+				assert.Equal(g, ts.Value, tc.expected)
+			})
+		}
+	})
+}
+
+func TestTimestampSetHandlesNamedDays(t *testing.T) {
+	tbntime.WithTimeAt(tsReference, func(cs tbntime.ControlledSource) {
+		ts := NewZonedTimestamp(time.Time{}, nil).WithSource(cs)
+
+		assert.Nil(t, ts.Set("today"))
+		assert.Equal(t, ts.Value, time.Date(2018, 6, 15, 0, 0, 0, 0, time.UTC))
+
+		assert.Nil(t, ts.Set("Yesterday"))
+		assert.Equal(t, ts.Value, time.Date(2018, 6, 14, 0, 0, 0, 0, time.UTC))
+
+		assert.Nil(t, ts.Set("tomorrow"))
+		assert.Equal(t, ts.Value, time.Date(2018, 6, 16, 0, 0, 0, 0, time.UTC))
+	})
+}
+
+func TestTimestampSetHandlesDates(t *testing.T) {
+	ts := Timestamp{}
+
+	assert.Nil(t, ts.Set("2018-06-01"))
+	assert.Equal(t, ts.Value, time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	assert.Nil(t, ts.Set("2018-06-01T12:34:56"))
+	assert.Equal(t, ts.Value, time.Date(2018, 6, 1, 12, 34, 56, 0, time.UTC))
+}
+
+func TestTimestampSetHandlesLocations(t *testing.T) {
+	la, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %s", err)
+	}
+
+	zts := NewZonedTimestamp(time.Time{}, la)
+	assert.Nil(t, zts.Set("2018-06-01"))
+	assert.True(t, zts.Value.Equal(time.Date(2018, 6, 1, 7, 0, 0, 0, time.UTC)))
+	assert.Nil(t, zts.Set("2018-06-01 UTC"))
+	assert.True(t, zts.Value.Equal(time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, zts.Get(), zts.Value)
+	assert.Equal(t, zts.String(), "2018-06-01T00:00:00Z")
+
+	ts := Timestamp{}
+	assert.Nil(t, ts.Set("2018-06-01 America/Los_Angeles"))
+	assert.True(t, ts.Value.Equal(time.Date(2018, 6, 1, 7, 0, 0, 0, time.UTC)))
+
+	tbntime.WithTimeAt(tsReference, func(cs tbntime.ControlledSource) {
+		ts := NewZonedTimestamp(time.Time{}, nil).WithSource(cs)
+		assert.Nil(t, ts.Set("today America/Los_Angeles"))
+		assert.True(t, ts.Value.Equal(time.Date(2018, 6, 15, 7, 0, 0, 0, time.UTC)))
+	})
+
+	tbntime.WithTimeAt(tsReference, func(cs tbntime.ControlledSource) {
+		zts := NewZonedTimestamp(time.Time{}, la).WithSource(cs)
+		assert.Nil(t, zts.Set("tomorrow"))
+		assert.True(t, zts.Value.Equal(time.Date(2018, 6, 16, 7, 0, 0, 0, time.UTC)))
+		assert.Nil(t, zts.Set("-1h"))
+		assert.Equal(t, zts.Value, tsReference.Add(-time.Hour))
+	})
+
+	assert.ErrorContains(t, ts.Set("2018-06-01 Nowhere/Special"), "unknown time zone")
+}
+
+func TestNewTimeRange(t *testing.T) {
+	end := time.Now()
+	start := end.Add(-time.Hour)
+	tr := NewTimeRange(start, end)
+	assert.Equal(t, tr.Start, start)
+	assert.Equal(t, tr.End, end)
+	assert.Equal(t, tr.Duration(), time.Hour)
+}
+
+func TestTimeRangeSet(t *testing.T) {
+	tbntime.WithTimeAt(tsReference, func(cs tbntime.ControlledSource) {
+		tr := NewTimeRange(time.Time{}, time.Time{}).WithSource(cs)
+
+		assert.Nil(t, tr.Set("yesterday..today"))
+		assert.Equal(t, tr.Start, time.Date(2018, 6, 14, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, tr.End, time.Date(2018, 6, 15, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, tr.Duration(), 24*time.Hour)
+
+		assert.Nil(t, tr.Set("-15m.."))
+		assert.Equal(t, tr.Start, tsReference.Add(-15*time.Minute))
+		assert.Equal(t, tr.End, tsReference)
+
+		assert.Nil(t, tr.Set("2018-06-01..2018-06-02T12:00:00Z"))
+		assert.Equal(t, tr.Start, time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC))
+		assert.Equal(t, tr.End, time.Date(2018, 6, 2, 12, 0, 0, 0, time.UTC))
+	})
+}
+
+func TestTimeRangeSetErrors(t *testing.T) {
+	tbntime.WithTimeAt(tsReference, func(cs tbntime.ControlledSource) {
+		tr := NewTimeRange(time.Time{}, time.Time{}).WithSource(cs)
+
+		assert.ErrorContains(t, tr.Set("yesterday"), "expecting start..end")
+		assert.ErrorContains(t, tr.Set("..now"), "missing a start time")
+		assert.ErrorContains(t, tr.Set("bogus..now"), "cannot parse 'bogus'")
+		assert.ErrorContains(t, tr.Set("now..bogus"), "cannot parse 'bogus'")
+		assert.ErrorContains(t, tr.Set("today..yesterday"), "must be before end")
+		assert.ErrorContains(t, tr.Set("now..now"), "must be before end")
+
+		// unchanged on error
+		assert.True(t, tr.Start.IsZero())
+		assert.True(t, tr.End.IsZero())
+	})
+}
+
+func TestTimeRangeString(t *testing.T) {
+	tr := TimeRange{}
+	assert.Equal(t, tr.String(), "")
+
+	tr = NewTimeRange(tsSeconds, tsNanos)
+	assert.Equal(t, tr.String(), inRFC3339+".."+inRFC3339Nano)
+	assert.DeepEqual(t, tr.Get(), tr)
+}
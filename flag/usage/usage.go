@@ -23,9 +23,37 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"sort"
 	"strings"
 )
 
+// ValueKind describes the kind of value a flag accepts, beyond its type,
+// for use by tools such as shell completion.
+type ValueKind string
+
+const (
+	// NoValueKind indicates that no kind has been given.
+	NoValueKind ValueKind = ""
+
+	// FileValueKind indicates that the flag's value is a file path.
+	FileValueKind ValueKind = "file"
+
+	// DirValueKind indicates that the flag's value is a directory
+	// path.
+	DirValueKind ValueKind = "dir"
+
+	// PathValueKind indicates that the flag's value is a file or
+	// directory path.
+	PathValueKind ValueKind = "path"
+
+	// URLValueKind indicates that the flag's value is a URL.
+	URLValueKind ValueKind = "url"
+
+	// DurationValueKind indicates that the flag's value is a
+	// duration, such as "1m30s".
+	DurationValueKind ValueKind = "duration"
+)
+
 // Usage represents richer usage information for a flag.Flag
 type Usage interface {
 	// Usage returns the original usage string, without any decoration
@@ -41,6 +69,33 @@ type Usage interface {
 	// IsDeprecated returns true if the flag has been marked as deprecated
 	IsDeprecated() bool
 
+	// ValueKind returns the kind of value the flag accepts, or
+	// NoValueKind if none has been given
+	ValueKind() ValueKind
+
+	// IsHidden returns true if the flag has been marked as hidden from
+	// help and completion
+	IsHidden() bool
+
+	// IsAdvanced returns true if the flag has been marked as advanced, of
+	// interest only to experienced users
+	IsAdvanced() bool
+
+	// Category returns the category the flag belongs to, or the empty
+	// string if none has been given
+	Category() string
+
+	// Examples returns example values for the flag
+	Examples() []string
+
+	// Since returns the version in which the flag was introduced, or the
+	// empty string if none has been given
+	Since() string
+
+	// Replacement returns the name of the flag that replaces this
+	// deprecated flag, or the empty string if none has been given
+	Replacement() string
+
 	// SetRequired marks the flag as required
 	SetRequired() Usage
 
@@ -51,8 +106,31 @@ type Usage interface {
 	// SetDeprecated marks the flag as deprecated
 	SetDeprecated() Usage
 
+	// SetValueKind sets the kind of value the flag accepts
+	SetValueKind(kind ValueKind) Usage
+
+	// SetHidden marks the flag as hidden from help and completion
+	SetHidden() Usage
+
+	// SetAdvanced marks the flag as advanced
+	SetAdvanced() Usage
+
+	// SetCategory sets the category the flag belongs to
+	SetCategory(category string) Usage
+
+	// AddExamples appends example values for the flag
+	AddExamples(examples ...string) Usage
+
+	// SetSince sets the version in which the flag was introduced
+	SetSince(version string) Usage
+
+	// SetReplacement marks the flag as deprecated and sets the name of the
+	// flag that replaces it
+	SetReplacement(name string) Usage
+
 	// Pretty() returns a human-friendly usage string, decorated with an
-	// indication of whether the flag has been marked as required or sensitive
+	// indication of whether the flag has been marked as required, sensitive,
+	// deprecated, or advanced, and with the flag's replacement, if any
 	Pretty() string
 
 	// String() returns an encoded string that can be passed to New() to recover
@@ -71,10 +149,17 @@ func New(str string) Usage {
 }
 
 type usage struct {
-	Required   bool   `json:"is_required"`
-	Sensitive  bool   `json:"is_sensitive"`
-	Deprecated bool   `json:"is_deprecated"`
-	UsageStr   string `json:"usage"`
+	Required       bool      `json:"is_required"`
+	Sensitive      bool      `json:"is_sensitive"`
+	Deprecated     bool      `json:"is_deprecated"`
+	UsageStr       string    `json:"usage"`
+	Kind           ValueKind `json:"value_kind,omitempty"`
+	Hidden         bool      `json:"is_hidden,omitempty"`
+	Advanced       bool      `json:"is_advanced,omitempty"`
+	CategoryStr    string    `json:"category,omitempty"`
+	ExampleValues  []string  `json:"examples,omitempty"`
+	SinceVersion   string    `json:"since,omitempty"`
+	ReplacementStr string    `json:"replacement,omitempty"`
 }
 
 func (u *usage) Usage() string {
@@ -93,6 +178,34 @@ func (u *usage) IsDeprecated() bool {
 	return u.Deprecated
 }
 
+func (u *usage) ValueKind() ValueKind {
+	return u.Kind
+}
+
+func (u *usage) IsHidden() bool {
+	return u.Hidden
+}
+
+func (u *usage) IsAdvanced() bool {
+	return u.Advanced
+}
+
+func (u *usage) Category() string {
+	return u.CategoryStr
+}
+
+func (u *usage) Examples() []string {
+	return u.ExampleValues
+}
+
+func (u *usage) Since() string {
+	return u.SinceVersion
+}
+
+func (u *usage) Replacement() string {
+	return u.ReplacementStr
+}
+
 func (u *usage) SetRequired() Usage {
 	u.Required = true
 	return u
@@ -108,6 +221,42 @@ func (u *usage) SetDeprecated() Usage {
 	return u
 }
 
+func (u *usage) SetValueKind(kind ValueKind) Usage {
+	u.Kind = kind
+	return u
+}
+
+func (u *usage) SetHidden() Usage {
+	u.Hidden = true
+	return u
+}
+
+func (u *usage) SetAdvanced() Usage {
+	u.Advanced = true
+	return u
+}
+
+func (u *usage) SetCategory(category string) Usage {
+	u.CategoryStr = category
+	return u
+}
+
+func (u *usage) AddExamples(examples ...string) Usage {
+	u.ExampleValues = append(u.ExampleValues, examples...)
+	return u
+}
+
+func (u *usage) SetSince(version string) Usage {
+	u.SinceVersion = version
+	return u
+}
+
+func (u *usage) SetReplacement(name string) Usage {
+	u.Deprecated = true
+	u.ReplacementStr = name
+	return u
+}
+
 func (u *usage) Pretty() string {
 	str := u.Usage()
 	features := []string{}
@@ -120,9 +269,15 @@ func (u *usage) Pretty() string {
 	if u.IsDeprecated() {
 		features = append(features, "DEPRECATED")
 	}
+	if u.IsAdvanced() {
+		features = append(features, "ADVANCED")
+	}
 	if len(features) > 0 {
 		str = fmt.Sprintf("[%s] %s", strings.Join(features, "/"), str)
 	}
+	if u.Replacement() != "" {
+		str = fmt.Sprintf("%s (use -%s instead)", str, u.Replacement())
+	}
 	return str
 }
 
@@ -149,6 +304,83 @@ func Deprecated(usage string) string {
 	return New(usage).SetDeprecated().String()
 }
 
+// File produces an encoded usage string for a Flag indicating that the Flag's
+// value is a file path. It can be passed through usage.New() to recover the
+// full Usage.
+func File(usage string) string {
+	return New(usage).SetValueKind(FileValueKind).String()
+}
+
+// Dir produces an encoded usage string for a Flag indicating that the Flag's
+// value is a directory path. It can be passed through usage.New() to recover
+// the full Usage.
+func Dir(usage string) string {
+	return New(usage).SetValueKind(DirValueKind).String()
+}
+
+// Path produces an encoded usage string for a Flag indicating that the Flag's
+// value is a file or directory path. It can be passed through usage.New() to
+// recover the full Usage.
+func Path(usage string) string {
+	return New(usage).SetValueKind(PathValueKind).String()
+}
+
+// URL produces an encoded usage string for a Flag indicating that the Flag's
+// value is a URL. It can be passed through usage.New() to recover the full
+// Usage.
+func URL(usage string) string {
+	return New(usage).SetValueKind(URLValueKind).String()
+}
+
+// Duration produces an encoded usage string for a Flag indicating that the
+// Flag's value is a duration. It can be passed through usage.New() to recover
+// the full Usage.
+func Duration(usage string) string {
+	return New(usage).SetValueKind(DurationValueKind).String()
+}
+
+// Hidden produces an encoded usage string for a Flag indicating that the Flag
+// should be omitted from help and completion. It can be passed through
+// usage.New() to recover the full Usage.
+func Hidden(usage string) string {
+	return New(usage).SetHidden().String()
+}
+
+// Advanced produces an encoded usage string for a Flag indicating that the
+// Flag is of interest only to experienced users. It can be passed through
+// usage.New() to recover the full Usage.
+func Advanced(usage string) string {
+	return New(usage).SetAdvanced().String()
+}
+
+// InCategory produces an encoded usage string for a Flag indicating that the
+// Flag belongs to the given category. It can be passed through usage.New() to
+// recover the full Usage.
+func InCategory(usage, category string) string {
+	return New(usage).SetCategory(category).String()
+}
+
+// WithExamples produces an encoded usage string for a Flag with the given
+// example values. It can be passed through usage.New() to recover the full
+// Usage.
+func WithExamples(usage string, examples ...string) string {
+	return New(usage).AddExamples(examples...).String()
+}
+
+// Since produces an encoded usage string for a Flag indicating the version in
+// which the Flag was introduced. It can be passed through usage.New() to
+// recover the full Usage.
+func Since(usage, version string) string {
+	return New(usage).SetSince(version).String()
+}
+
+// ReplacedBy produces an encoded usage string for a Flag indicating that the
+// Flag is deprecated in favor of the named Flag. It can be passed through
+// usage.New() to recover the full Usage.
+func ReplacedBy(usage, name string) string {
+	return New(usage).SetReplacement(name).String()
+}
+
 // IsRequired checks the usage string of the given Flag to see if it is
 // marked as required.
 func IsRequired(f *flag.Flag) bool {
@@ -167,6 +399,36 @@ func IsDeprecated(f *flag.Flag) bool {
 	return New(f.Usage).IsDeprecated()
 }
 
+// ValueKindOf checks the usage string of the given Flag to determine the kind
+// of value it accepts.
+func ValueKindOf(f *flag.Flag) ValueKind {
+	return New(f.Usage).ValueKind()
+}
+
+// IsHidden checks the usage string of the given Flag to see if it is marked as
+// hidden.
+func IsHidden(f *flag.Flag) bool {
+	return New(f.Usage).IsHidden()
+}
+
+// IsAdvanced checks the usage string of the given Flag to see if it is marked
+// as advanced.
+func IsAdvanced(f *flag.Flag) bool {
+	return New(f.Usage).IsAdvanced()
+}
+
+// CategoryOf checks the usage string of the given Flag to determine its
+// category.
+func CategoryOf(f *flag.Flag) string {
+	return New(f.Usage).Category()
+}
+
+// ReplacementOf checks the usage string of the given Flag to determine the
+// name of the Flag that replaces it.
+func ReplacementOf(f *flag.Flag) string {
+	return New(f.Usage).Replacement()
+}
+
 // FlagSetFilterFn is a predicate function that takes a flag and whether or not
 // the flag is set as its input.
 type FlagSetFilterFn func(f *flag.Flag, set bool) bool
@@ -204,3 +466,71 @@ func DeprecatedAndSet(fs *flag.FlagSet) []string {
 		return set && IsDeprecated(f)
 	})
 }
+
+// Visible is a FlagSetFilterFn that accepts flags not marked as hidden.
+func Visible(f *flag.Flag, set bool) bool {
+	return !IsHidden(f)
+}
+
+// Basic is a FlagSetFilterFn that accepts flags marked as neither hidden nor
+// advanced.
+func Basic(f *flag.Flag, set bool) bool {
+	u := New(f.Usage)
+	return !u.IsHidden() && !u.IsAdvanced()
+}
+
+// WithCategory produces a FlagSetFilterFn that accepts flags in the given
+// category. The empty string accepts flags without a category.
+func WithCategory(category string) FlagSetFilterFn {
+	return func(f *flag.Flag, set bool) bool {
+		return CategoryOf(f) == category
+	}
+}
+
+// WithValueKind produces a FlagSetFilterFn that accepts flags whose value has
+// the given kind.
+func WithValueKind(kind ValueKind) FlagSetFilterFn {
+	return func(f *flag.Flag, set bool) bool {
+		return ValueKindOf(f) == kind
+	}
+}
+
+// And produces a FlagSetFilterFn that accepts flags accepted by all of the
+// given FlagSetFilterFns.
+func And(fns ...FlagSetFilterFn) FlagSetFilterFn {
+	return func(f *flag.Flag, set bool) bool {
+		for _, fn := range fns {
+			if !fn(f, set) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Not produces a FlagSetFilterFn that accepts flags rejected by the given
+// FlagSetFilterFn.
+func Not(fn FlagSetFilterFn) FlagSetFilterFn {
+	return func(f *flag.Flag, set bool) bool {
+		return !fn(f, set)
+	}
+}
+
+// Categories produces a sorted slice of the distinct, non-empty categories of
+// the flags in the given FlagSet.
+func Categories(fs *flag.FlagSet) []string {
+	seen := map[string]bool{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if c := CategoryOf(f); c != "" {
+			seen[c] = true
+		}
+	})
+
+	result := make([]string, 0, len(seen))
+	for c := range seen {
+		result = append(result, c)
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -101,6 +101,28 @@ func TestIsDeprecated(t *testing.T) {
 	assert.False(t, IsDeprecated(&flag.Flag{Usage: "foo"}))
 }
 
+func TestValueKind(t *testing.T) {
+	json := `{"is_required":false,"is_sensitive":false,"is_deprecated":false,"usage":"foo","value_kind":"file"}`
+	assert.Equal(t, File("foo"), json)
+	assert.Equal(t, New(Dir("foo")).ValueKind(), DirValueKind)
+
+	usage := New("foo")
+	assert.Equal(t, usage.ValueKind(), NoValueKind)
+	usage.SetValueKind(FileValueKind)
+	assert.Equal(t, usage.ValueKind(), FileValueKind)
+	assert.Equal(t, usage.Pretty(), "foo")
+	assert.Equal(t, usage.String(), json)
+
+	usage = New(Required(json))
+	assert.True(t, usage.IsRequired())
+	assert.Equal(t, usage.ValueKind(), FileValueKind)
+}
+
+func TestValueKindOf(t *testing.T) {
+	assert.Equal(t, ValueKindOf(&flag.Flag{Usage: Dir("foo")}), DirValueKind)
+	assert.Equal(t, ValueKindOf(&flag.Flag{Usage: "foo"}), NoValueKind)
+}
+
 func TestRequiredAndSensitiveAndDeprecated(t *testing.T) {
 	json := `{"is_required":true,"is_sensitive":true,"is_deprecated":true,"usage":"foo"}`
 	assert.Equal(t, Deprecated(Required(Sensitive("foo"))), json)
@@ -138,3 +160,98 @@ func TestMissingRequired(t *testing.T) {
 	fs.Parse([]string{"--bar=baz"})
 	assert.DeepEqual(t, MissingRequired(fs), []string{})
 }
+
+func TestHiddenAndAdvanced(t *testing.T) {
+	json := `{"is_required":false,"is_sensitive":false,"is_deprecated":false,"usage":"foo","is_hidden":true}`
+	assert.Equal(t, Hidden("foo"), json)
+	assert.True(t, IsHidden(&flag.Flag{Usage: json}))
+	assert.False(t, IsHidden(&flag.Flag{Usage: "foo"}))
+	assert.Equal(t, New(json).Pretty(), "foo")
+
+	json = `{"is_required":true,"is_sensitive":false,"is_deprecated":false,"usage":"foo","is_advanced":true}`
+	assert.Equal(t, Required(Advanced("foo")), json)
+	assert.True(t, IsAdvanced(&flag.Flag{Usage: json}))
+	assert.False(t, IsAdvanced(&flag.Flag{Usage: Hidden("foo")}))
+	assert.Equal(t, New(json).Pretty(), "[REQUIRED/ADVANCED] foo")
+}
+
+func TestCategoryExamplesAndSince(t *testing.T) {
+	json := `{"is_required":false,"is_sensitive":false,"is_deprecated":false,"usage":"foo",` +
+		`"category":"network","examples":["a:80","b:443"],"since":"1.2.0"}`
+	assert.Equal(t, Since(WithExamples(InCategory("foo", "network"), "a:80", "b:443"), "1.2.0"), json)
+
+	u := New(json)
+	assert.Equal(t, u.Category(), "network")
+	assert.ArrayEqual(t, u.Examples(), []string{"a:80", "b:443"})
+	assert.Equal(t, u.Since(), "1.2.0")
+	assert.Equal(t, u.Pretty(), "foo")
+	assert.Equal(t, u.String(), json)
+
+	u.AddExamples("c:8080")
+	assert.ArrayEqual(t, u.Examples(), []string{"a:80", "b:443", "c:8080"})
+
+	assert.Equal(t, CategoryOf(&flag.Flag{Usage: json}), "network")
+	assert.Equal(t, CategoryOf(&flag.Flag{Usage: "foo"}), "")
+
+	u = New("foo")
+	assert.Equal(t, u.Category(), "")
+	assert.Equal(t, len(u.Examples()), 0)
+	assert.Equal(t, u.Since(), "")
+}
+
+func TestReplacedBy(t *testing.T) {
+	json := `{"is_required":false,"is_sensitive":false,"is_deprecated":true,"usage":"foo","replacement":"bar"}`
+	assert.Equal(t, ReplacedBy("foo", "bar"), json)
+
+	f := &flag.Flag{Usage: json}
+	assert.True(t, IsDeprecated(f))
+	assert.Equal(t, ReplacementOf(f), "bar")
+	assert.Equal(t, ReplacementOf(&flag.Flag{Usage: Deprecated("foo")}), "")
+	assert.Equal(t, New(json).Pretty(), "[DEPRECATED] foo (use -bar instead)")
+}
+
+func TestAdditionalValueKinds(t *testing.T) {
+	assert.Equal(t, ValueKindOf(&flag.Flag{Usage: Path("foo")}), PathValueKind)
+	assert.Equal(t, ValueKindOf(&flag.Flag{Usage: URL("foo")}), URLValueKind)
+	assert.Equal(t, ValueKindOf(&flag.Flag{Usage: Duration("foo")}), DurationValueKind)
+}
+
+func TestBackwardCompatibleDecoding(t *testing.T) {
+	old := `{"is_required":true,"is_sensitive":false,"is_deprecated":false,"usage":"foo"}`
+	u := New(old)
+	assert.True(t, u.IsRequired())
+	assert.False(t, u.IsHidden())
+	assert.False(t, u.IsAdvanced())
+	assert.Equal(t, u.Replacement(), "")
+	assert.Equal(t, u.String(), old)
+}
+
+func TestFilterPredicates(t *testing.T) {
+	fs := &flag.FlagSet{}
+	fs.String("plain", "", "plain")
+	fs.String("hidden", "", Hidden("hidden"))
+	fs.String("advanced", "", InCategory(Advanced("advanced"), "tuning"))
+	fs.String("url", "", InCategory(URL("url"), "network"))
+	fs.String("addr", "", InCategory("addr", "network"))
+
+	assert.ArrayEqual(t, FilterFlagSet(fs, Visible), []string{"addr", "advanced", "plain", "url"})
+	assert.ArrayEqual(t, FilterFlagSet(fs, Basic), []string{"addr", "plain", "url"})
+	assert.ArrayEqual(t, FilterFlagSet(fs, WithCategory("network")), []string{"addr", "url"})
+	assert.ArrayEqual(t, FilterFlagSet(fs, WithCategory("")), []string{"hidden", "plain"})
+	assert.ArrayEqual(t, FilterFlagSet(fs, WithValueKind(URLValueKind)), []string{"url"})
+	assert.ArrayEqual(
+		t,
+		FilterFlagSet(fs, And(WithCategory("network"), Not(WithValueKind(URLValueKind)))),
+		[]string{"addr"},
+	)
+	assert.ArrayEqual(t, FilterFlagSet(fs, Not(Visible)), []string{"hidden"})
+
+	fs.Parse([]string{"-plain=x"})
+	assert.ArrayEqual(
+		t,
+		FilterFlagSet(fs, And(Visible, func(f *flag.Flag, set bool) bool { return set })),
+		[]string{"plain"},
+	)
+
+	assert.ArrayEqual(t, Categories(fs), []string{"network", "tuning"})
+}
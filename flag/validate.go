@@ -0,0 +1,400 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+//go:generate mockgen -source $GOFILE -destination mock_$GOFILE -package $GOPACKAGE --write_package_comment=false
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+)
+
+// Validator declares constraints on the flags of a FlagSet, which are
+// checked after the FlagSet is parsed (and filled from any other
+// sources). Flag names are given without the FlagSet's scope. A flag
+// is considered set if it was set on the command line or by any other
+// call to flag.FlagSet.Set, such as FromEnv.Fill.
+type Validator interface {
+	// MutuallyExclusive declares that at most one of the named
+	// flags may be set.
+	MutuallyExclusive(names ...string)
+
+	// Requires declares that if the named flag is set, each of
+	// the required flags must also be set.
+	Requires(name string, required ...string)
+
+	// Range declares that, if set, the named flag's value must be
+	// between min and max, inclusive. The flag's value must
+	// implement flag.Getter and produce a numeric value.
+	Range(name string, min, max float64)
+
+	// DurationRange declares that, if set, the named flag's value
+	// must be between min and max, inclusive. The flag's value
+	// must implement flag.Getter and produce a time.Duration.
+	DurationRange(name string, min, max time.Duration)
+
+	// LessOrEqual declares that the value of the flag named lesser
+	// must not exceed the value of the flag named greater,
+	// whether or not either is set. Both flags' values must
+	// implement flag.Getter and produce numeric values or
+	// time.Durations.
+	LessOrEqual(lesser, greater string)
+
+	// Matches declares that, if set, the named flag's value must
+	// match the given regular expression.
+	Matches(name string, pattern *regexp.Regexp)
+
+	// Check declares a custom constraint. The predicate is invoked
+	// with the unwrapped flag.FlagSet and returns an error
+	// describing any violation.
+	Check(predicate func(fs *flag.FlagSet) error)
+
+	// Warn declares a custom constraint whose violation is not
+	// fatal. The predicate is invoked with the unwrapped
+	// flag.FlagSet and returns an error describing the warning.
+	// Warnings are reported by Report, and alongside any
+	// violations by Validate, but never cause Validate to fail.
+	Warn(predicate func(fs *flag.FlagSet) error)
+
+	// Validate checks every declared constraint, returning a
+	// ValidationError describing all violations and warnings, or
+	// nil if there are no violations.
+	Validate() error
+
+	// Report checks every declared constraint, returning a
+	// ValidationError describing all violations and warnings, or
+	// nil if there are neither. Unlike Validate, Report returns a
+	// ValidationError when there are only warnings.
+	Report() *ValidationError
+}
+
+// ValidationError describes every constraint violated by the flags of
+// a FlagSet. Violations are fatal; Warnings are not. The values of
+// sensitive flags are redacted. See Validator.
+type ValidationError struct {
+	Violations []string
+	Warnings   []string
+}
+
+// Fatal returns true if the ValidationError contains any violations.
+func (e *ValidationError) Fatal() bool {
+	return len(e.Violations) > 0
+}
+
+func (e *ValidationError) Error() string {
+	header := "invalid flags"
+	if !e.Fatal() {
+		header = "flag warnings"
+	}
+
+	lines := append([]string{}, e.Violations...)
+	for _, w := range e.Warnings {
+		lines = append(lines, "warning: "+w)
+	}
+
+	if len(lines) == 1 {
+		return header + ": " + lines[0]
+	}
+
+	return header + ":\n  " + strings.Join(lines, "\n  ")
+}
+
+// NewValidator produces a Validator for the given FlagSet. Flag names
+// passed to the Validator are prefixed with the FlagSet's scope.
+func NewValidator(fs FlagSet) Validator {
+	return &validator{fs: fs.Unwrap(), scope: fs.GetScope()}
+}
+
+type constraint func(v *validation) []string
+
+type validator struct {
+	fs          *flag.FlagSet
+	scope       string
+	constraints []constraint
+	warnings    []constraint
+}
+
+// validation holds the state of a single call to Validate.
+type validation struct {
+	fs  *flag.FlagSet
+	set map[string]bool
+}
+
+func (vv *validation) lookup(name string) (*flag.Flag, []string) {
+	f := vv.fs.Lookup(name)
+	if f == nil {
+		return nil, []string{fmt.Sprintf("unknown flag -%s", name)}
+	}
+	return f, nil
+}
+
+func (v *validator) names(names []string) []string {
+	scoped := make([]string, len(names))
+	for i, name := range names {
+		scoped[i] = v.scope + name
+	}
+	return scoped
+}
+
+func (v *validator) add(c constraint) {
+	v.constraints = append(v.constraints, c)
+}
+
+func (v *validator) MutuallyExclusive(names ...string) {
+	names = v.names(names)
+	v.add(func(vv *validation) []string {
+		set := []string{}
+		for _, name := range names {
+			if _, unknown := vv.lookup(name); unknown != nil {
+				return unknown
+			}
+			if vv.set[name] {
+				set = append(set, "-"+name)
+			}
+		}
+
+		if len(set) > 1 {
+			return []string{
+				fmt.Sprintf("only one of %s may be set", strings.Join(set, ", ")),
+			}
+		}
+		return nil
+	})
+}
+
+func (v *validator) Requires(name string, required ...string) {
+	name = v.scope + name
+	required = v.names(required)
+	v.add(func(vv *validation) []string {
+		if _, unknown := vv.lookup(name); unknown != nil {
+			return unknown
+		}
+
+		violations := []string{}
+		for _, req := range required {
+			if _, unknown := vv.lookup(req); unknown != nil {
+				return unknown
+			}
+			if vv.set[name] && !vv.set[req] {
+				violations = append(violations, fmt.Sprintf("-%s requires -%s", name, req))
+			}
+		}
+		return violations
+	})
+}
+
+func (v *validator) Range(name string, min, max float64) {
+	name = v.scope + name
+	v.add(func(vv *validation) []string {
+		f, unknown := vv.lookup(name)
+		if unknown != nil {
+			return unknown
+		}
+
+		n, ok := numericValue(f)
+		if !ok {
+			return []string{fmt.Sprintf("-%s is not numeric", name)}
+		}
+
+		if vv.set[name] && (n < min || n > max) {
+			return []string{
+				fmt.Sprintf(
+					"-%s must be between %v and %v, got %s",
+					name,
+					min,
+					max,
+					displayValue(f),
+				),
+			}
+		}
+		return nil
+	})
+}
+
+func (v *validator) DurationRange(name string, min, max time.Duration) {
+	name = v.scope + name
+	v.add(func(vv *validation) []string {
+		f, unknown := vv.lookup(name)
+		if unknown != nil {
+			return unknown
+		}
+
+		d, ok := getFlagValue(f).(time.Duration)
+		if !ok {
+			return []string{fmt.Sprintf("-%s is not a duration", name)}
+		}
+
+		if vv.set[name] && (d < min || d > max) {
+			return []string{
+				fmt.Sprintf(
+					"-%s must be between %s and %s, got %s",
+					name,
+					min,
+					max,
+					displayValue(f),
+				),
+			}
+		}
+		return nil
+	})
+}
+
+func (v *validator) LessOrEqual(lesser, greater string) {
+	lesser = v.scope + lesser
+	greater = v.scope + greater
+	v.add(func(vv *validation) []string {
+		lf, unknown := vv.lookup(lesser)
+		if unknown != nil {
+			return unknown
+		}
+		gf, unknown := vv.lookup(greater)
+		if unknown != nil {
+			return unknown
+		}
+
+		l, lok := numericValue(lf)
+		g, gok := numericValue(gf)
+		if !lok || !gok {
+			return []string{fmt.Sprintf("-%s and -%s are not comparable", lesser, greater)}
+		}
+
+		if l > g {
+			return []string{
+				fmt.Sprintf(
+					"-%s (%s) must not exceed -%s (%s)",
+					lesser,
+					displayValue(lf),
+					greater,
+					displayValue(gf),
+				),
+			}
+		}
+		return nil
+	})
+}
+
+func (v *validator) Matches(name string, pattern *regexp.Regexp) {
+	name = v.scope + name
+	v.add(func(vv *validation) []string {
+		f, unknown := vv.lookup(name)
+		if unknown != nil {
+			return unknown
+		}
+
+		if vv.set[name] && !pattern.MatchString(f.Value.String()) {
+			got := redacted
+			if !usage.IsSensitive(f) {
+				got = strconv.Quote(f.Value.String())
+			}
+
+			return []string{
+				fmt.Sprintf("-%s must match %s, got %s", name, pattern.String(), got),
+			}
+		}
+		return nil
+	})
+}
+
+func (v *validator) Check(predicate func(fs *flag.FlagSet) error) {
+	v.add(predicateConstraint(predicate))
+}
+
+func (v *validator) Warn(predicate func(fs *flag.FlagSet) error) {
+	v.warnings = append(v.warnings, predicateConstraint(predicate))
+}
+
+func predicateConstraint(predicate func(fs *flag.FlagSet) error) constraint {
+	return func(vv *validation) []string {
+		if err := predicate(vv.fs); err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+}
+
+func (v *validator) Validate() error {
+	if err := v.Report(); err != nil && err.Fatal() {
+		return err
+	}
+
+	return nil
+}
+
+func (v *validator) Report() *ValidationError {
+	vv := &validation{fs: v.fs, set: map[string]bool{}}
+	v.fs.Visit(func(f *flag.Flag) {
+		vv.set[f.Name] = true
+	})
+
+	violations := []string{}
+	for _, c := range v.constraints {
+		violations = append(violations, c(vv)...)
+	}
+
+	warnings := []string{}
+	for _, c := range v.warnings {
+		warnings = append(warnings, c(vv)...)
+	}
+
+	if len(violations) == 0 && len(warnings) == 0 {
+		return nil
+	}
+
+	err := &ValidationError{Violations: violations}
+	if len(warnings) > 0 {
+		err.Warnings = warnings
+	}
+	return err
+}
+
+// displayValue returns the value of a flag for use in a violation,
+// redacting the values of sensitive flags.
+func displayValue(f *flag.Flag) string {
+	if usage.IsSensitive(f) {
+		return redacted
+	}
+	return f.Value.String()
+}
+
+// numericValue returns the value of a flag whose flag.Getter produces
+// a number or time.Duration as a float64.
+func numericValue(f *flag.Flag) (float64, bool) {
+	switch n := getFlagValue(f).(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float64:
+		return n, true
+	case time.Duration:
+		return float64(n), true
+	case Rate:
+		return n.PerSecond(), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2018 Turbine Labs, Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flag
+
+import (
+	"errors"
+	"flag"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/turbinelabs/test/assert"
+
+	"github.com/turbinelabs/nonstdlib/flag/usage"
+)
+
+func mkValidatorFlagSet() TestFlagSet {
+	fs := NewTestFlagSet()
+	scoped := fs.Scope("x", "")
+	scoped.Bool("a", false, "")
+	scoped.Bool("b", false, "")
+	scoped.Bool("c", false, "")
+	scoped.Int("n", 5, "")
+	scoped.Float64("f", 0.5, "")
+	scoped.Duration("min", time.Second, "")
+	scoped.Duration("max", time.Minute, "")
+	scoped.String("s", "", "")
+	return fs
+}
+
+func TestValidatorNoConstraints(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	assert.Nil(t, NewValidator(fs).Validate())
+}
+
+func TestValidatorMutuallyExclusive(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs.Scope("x", ""))
+	v.MutuallyExclusive("a", "b", "c")
+
+	assert.Nil(t, v.Validate())
+	fs.Parse([]string{"-x.a"})
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.b", "-x.c"})
+	assert.ErrorContains(t, v.Validate(), "invalid flags: only one of -x.a, -x.b, -x.c may be set")
+}
+
+func TestValidatorRequires(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs.Scope("x", ""))
+	v.Requires("a", "b", "c")
+
+	fs.Parse([]string{"-x.b"})
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.a"})
+	assert.DeepEqual(
+		t,
+		v.Validate(),
+		&ValidationError{Violations: []string{"-x.a requires -x.c"}},
+	)
+}
+
+func TestValidatorRange(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs.Scope("x", ""))
+	v.Range("n", 10, 20)
+	v.Range("f", 0, 1)
+
+	// unset flags are not checked
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.n=20", "-x.f=1"})
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.n=21", "-x.f=-0.1"})
+	assert.DeepEqual(
+		t,
+		v.Validate(),
+		&ValidationError{
+			Violations: []string{
+				"-x.n must be between 10 and 20, got 21",
+				"-x.f must be between 0 and 1, got -0.1",
+			},
+		},
+	)
+
+	v = NewValidator(fs.Scope("x", ""))
+	v.Range("s", 0, 1)
+	assert.ErrorContains(t, v.Validate(), "-x.s is not numeric")
+}
+
+func TestValidatorDurationRange(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs.Scope("x", ""))
+	v.DurationRange("min", time.Millisecond, time.Second)
+
+	fs.Parse([]string{"-x.min=1ms"})
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.min=2s"})
+	assert.ErrorContains(t, v.Validate(), "-x.min must be between 1ms and 1s, got 2s")
+
+	v = NewValidator(fs.Scope("x", ""))
+	v.DurationRange("n", 0, time.Second)
+	assert.ErrorContains(t, v.Validate(), "-x.n is not a duration")
+}
+
+func TestValidatorLessOrEqual(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs.Scope("x", ""))
+	v.LessOrEqual("min", "max")
+
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.min=2m"})
+	assert.ErrorContains(t, v.Validate(), "-x.min (2m0s) must not exceed -x.max (1m0s)")
+
+	v = NewValidator(fs.Scope("x", ""))
+	v.LessOrEqual("s", "n")
+	assert.ErrorContains(t, v.Validate(), "-x.s and -x.n are not comparable")
+}
+
+func TestValidatorMatches(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs.Scope("x", ""))
+	v.Matches("s", regexp.MustCompile(`^[a-z]+$`))
+
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.s=abc"})
+	assert.Nil(t, v.Validate())
+
+	fs.Parse([]string{"-x.s=ABC"})
+	assert.ErrorContains(t, v.Validate(), `-x.s must match ^[a-z]+$, got "ABC"`)
+}
+
+func TestValidatorCheck(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs)
+
+	var got *flag.FlagSet
+	v.Check(func(fs *flag.FlagSet) error {
+		got = fs
+		return nil
+	})
+	assert.Nil(t, v.Validate())
+	assert.SameInstance(t, got, fs.Unwrap())
+
+	v.Check(func(*flag.FlagSet) error { return errors.New("boom") })
+	assert.ErrorContains(t, v.Validate(), "invalid flags: boom")
+}
+
+func TestValidatorRedactsSensitiveValues(t *testing.T) {
+	fs := NewTestFlagSet()
+	fs.Int("port", 0, usage.Sensitive(""))
+	fs.Int("limit", 0, usage.Sensitive(""))
+	fs.Duration("ttl", 0, usage.Sensitive(""))
+	fs.String("token", "", usage.Sensitive(""))
+
+	v := NewValidator(fs)
+	v.Range("port", 1, 100)
+	v.DurationRange("ttl", 0, time.Second)
+	v.LessOrEqual("port", "limit")
+	v.Matches("token", regexp.MustCompile(`^[a-z]+$`))
+
+	fs.Parse([]string{"-port=12345", "-limit=10", "-ttl=987s", "-token=S3CR3T"})
+	assert.DeepEqual(
+		t,
+		v.Validate(),
+		&ValidationError{
+			Violations: []string{
+				"-port must be between 1 and 100, got <redacted>",
+				"-ttl must be between 0s and 1s, got <redacted>",
+				"-port (<redacted>) must not exceed -limit (<redacted>)",
+				"-token must match ^[a-z]+$, got <redacted>",
+			},
+		},
+	)
+}
+
+func TestValidatorWarn(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs)
+
+	var got *flag.FlagSet
+	v.Warn(func(fs *flag.FlagSet) error {
+		got = fs
+		return nil
+	})
+	assert.Nil(t, v.Validate())
+	assert.Nil(t, v.Report())
+	assert.SameInstance(t, got, fs.Unwrap())
+
+	v.Warn(func(*flag.FlagSet) error { return errors.New("careful") })
+	assert.Nil(t, v.Validate())
+
+	report := v.Report()
+	assert.NonNil(t, report)
+	assert.False(t, report.Fatal())
+	assert.Equal(t, len(report.Violations), 0)
+	assert.ArrayEqual(t, report.Warnings, []string{"careful"})
+	assert.Equal(t, report.Error(), "flag warnings: warning: careful")
+
+	v.Check(func(*flag.FlagSet) error { return errors.New("boom") })
+	err, ok := v.Validate().(*ValidationError)
+	assert.True(t, ok)
+	assert.True(t, err.Fatal())
+	assert.ArrayEqual(t, err.Violations, []string{"boom"})
+	assert.ArrayEqual(t, err.Warnings, []string{"careful"})
+	assert.Equal(t, err.Error(), "invalid flags:\n  boom\n  warning: careful")
+	assert.DeepEqual(t, v.Report(), err)
+}
+
+func TestValidatorUnknownFlag(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs)
+	v.MutuallyExclusive("x.a", "nope")
+	v.Requires("x.a", "nope")
+	v.Range("nope", 0, 1)
+	v.DurationRange("nope", 0, 1)
+	v.LessOrEqual("x.n", "nope")
+	v.Matches("nope", regexp.MustCompile(""))
+
+	err, ok := v.Validate().(*ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, len(err.Violations), 6)
+	for _, violation := range err.Violations {
+		assert.Equal(t, violation, "unknown flag -nope")
+	}
+}
+
+func TestValidatorCollectsViolations(t *testing.T) {
+	fs := mkValidatorFlagSet()
+	v := NewValidator(fs.Scope("x", ""))
+	v.MutuallyExclusive("a", "b")
+	v.Requires("c", "a")
+	v.LessOrEqual("min", "max")
+
+	fs.Parse([]string{"-x.a", "-x.b", "-x.c", "-x.min=1h"})
+	assert.Equal(
+		t,
+		v.Validate().Error(),
+		"invalid flags:\n"+
+			"  only one of -x.a, -x.b may be set\n"+
+			"  -x.min (1h0m0s) must not exceed -x.max (1m0s)",
+	)
+}
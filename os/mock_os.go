@@ -83,6 +83,18 @@ func (mr *MockOSMockRecorder) LookupEnv(key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupEnv", reflect.TypeOf((*MockOS)(nil).LookupEnv), key)
 }
 
+// Environ mocks base method
+func (m *MockOS) Environ() []string {
+	ret := m.ctrl.Call(m, "Environ")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// Environ indicates an expected call of Environ
+func (mr *MockOSMockRecorder) Environ() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Environ", reflect.TypeOf((*MockOS)(nil).Environ))
+}
+
 // ExpandEnv mocks base method
 func (m *MockOS) ExpandEnv(s string) string {
 	ret := m.ctrl.Call(m, "ExpandEnv", s)
@@ -42,6 +42,7 @@ type OS interface {
 	Create(name string) (*os.File, error)
 	Getenv(key string) string
 	LookupEnv(key string) (value string, found bool)
+	Environ() []string
 	ExpandEnv(s string) string
 	Setenv(key, value string) error
 	Exit(code int)
@@ -83,6 +84,10 @@ func (x goOS) LookupEnv(key string) (string, bool) {
 	return os.LookupEnv(key)
 }
 
+func (x goOS) Environ() []string {
+	return os.Environ()
+}
+
 func (x goOS) ExpandEnv(s string) string {
 	return os.ExpandEnv(s)
 }